@@ -98,6 +98,8 @@ func checkTextAccess(c context.Context, r *http.Request, tag string, id int64) (
 		return checkCrashTextAccess(c, r, "ReproSyz", id)
 	case textReproC:
 		return checkCrashTextAccess(c, r, "ReproC", id)
+	case textCoverContext:
+		return checkCrashTextAccess(c, r, "CoverContext", id)
 	}
 }
 
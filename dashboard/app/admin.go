@@ -5,7 +5,9 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"golang.org/x/net/context"
 	db "google.golang.org/appengine/datastore"
@@ -37,6 +39,7 @@ func dropNamespace(c context.Context, w http.ResponseWriter, r *http.Request) er
 		{textPatch, ""},
 		{textReproC, ""},
 		{textReproSyz, ""},
+		{textCoverContext, ""},
 		{textKernelConfig, ""},
 		{"Job", ""},
 		{textLog, ""},
@@ -181,8 +184,204 @@ func updateBugReportingBatch(c context.Context, cfg *Config, keys []*db.Key) err
 	return err
 }
 
+// migrateBugs moves bugs with all their crashes and jobs to another namespace,
+// e.g. when a kernel is moved to a separate namespace. Reporting IDs stay the same,
+// so external discussions and Reported-by tags keep referring to the moved bugs.
+// Use with care. There is no undo.
+// This functionality is intentionally not connected to any handler.
+// To use it, first make a backup of the db. Then, connect the function to a handler,
+// invoke it with to=target-namespace&bugs=comma-separated-bug-ids and double check the output.
+// Finally, invoke it again with commit=1. Each moved bug is recorded as a BugMigration entity.
+// Moved bugs keep referring to text entities (logs, reports, reproducers) of the old namespace,
+// so the old namespace must not be dropped with dropNamespace afterwards.
+func migrateBugs(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	if accessLevel(c, r) != AccessAdmin {
+		return fmt.Errorf("admin only")
+	}
+	to := r.FormValue("to")
+	if config.Namespaces[to] == nil {
+		return fmt.Errorf("unknown namespace %q", to)
+	}
+	dryRun := r.FormValue("commit") == ""
+	if !dryRun {
+		log.Criticalf(c, "migrating bugs %v to namespace %v", r.FormValue("bugs"), to)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, id := range strings.Split(r.FormValue("bugs"), ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if _, err := migrateBug(c, w, id, to, dryRun); err != nil {
+			return fmt.Errorf("failed to migrate bug %v: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// migrateBug moves a single bug to the namespace to, returns ID of the moved bug.
+func migrateBug(c context.Context, w io.Writer, bugHash, to string, dryRun bool) (string, error) {
+	oldKey := db.NewKey(c, "Bug", bugHash, 0, nil)
+	bug := new(Bug)
+	if err := db.Get(c, oldKey, bug); err != nil {
+		return "", fmt.Errorf("failed to get bug: %v", err)
+	}
+	if err := checkBugMigration(c, bug, bugHash, to); err != nil {
+		return "", err
+	}
+	var crashes []*Crash
+	if _, err := db.NewQuery("Crash").Ancestor(oldKey).GetAll(c, &crashes); err != nil {
+		return "", fmt.Errorf("failed to query crashes: %v", err)
+	}
+	var jobs []*Job
+	if _, err := db.NewQuery("Job").Ancestor(oldKey).GetAll(c, &jobs); err != nil {
+		return "", fmt.Errorf("failed to query jobs: %v", err)
+	}
+	buildIDs := make(map[string]bool)
+	for _, crash := range crashes {
+		buildIDs[crash.BuildID] = true
+	}
+	for _, job := range jobs {
+		if job.Finished.IsZero() {
+			return "", fmt.Errorf("bug has unfinished jobs")
+		}
+		if job.BuildID != "" {
+			buildIDs[job.BuildID] = true
+		}
+	}
+	var seq int64
+	var sameTitle []*Bug
+	if _, err := db.NewQuery("Bug").
+		Filter("Namespace=", to).
+		Filter("Title=", bug.Title).
+		Order("-Seq").
+		Limit(1).
+		GetAll(c, &sameTitle); err != nil {
+		return "", fmt.Errorf("failed to query bugs: %v", err)
+	}
+	if len(sameTitle) != 0 {
+		seq = sameTitle[0].Seq + 1
+	}
+	newHash := bugKeyHash(to, bug.Title, seq)
+	fmt.Fprintf(w, "bug %v %q: %v -> %v (bug %v), crashes: %v, jobs: %v, builds: %v\n",
+		bugHash, bug.Title, bug.Namespace, to, newHash, len(crashes), len(jobs), len(buildIDs))
+	if dryRun {
+		return newHash, nil
+	}
+	// Builds are shared with other bugs, so they are copied rather than moved.
+	for id := range buildIDs {
+		if err := copyBuild(c, bug.Namespace, to, id); err != nil {
+			return "", err
+		}
+	}
+	tx := func(c context.Context) error {
+		bug := new(Bug)
+		if err := db.Get(c, oldKey, bug); err != nil {
+			return fmt.Errorf("failed to get bug: %v", err)
+		}
+		from := bug.Namespace
+		newKey := db.NewKey(c, "Bug", newHash, 0, nil)
+		if err := db.Get(c, newKey, new(Bug)); err != db.ErrNoSuchEntity {
+			return fmt.Errorf("target bug %v already exists: %v", newHash, err)
+		}
+		var crashes []*Crash
+		crashKeys, err := db.NewQuery("Crash").Ancestor(oldKey).GetAll(c, &crashes)
+		if err != nil {
+			return fmt.Errorf("failed to query crashes: %v", err)
+		}
+		var jobs []*Job
+		jobKeys, err := db.NewQuery("Job").Ancestor(oldKey).GetAll(c, &jobs)
+		if err != nil {
+			return fmt.Errorf("failed to query jobs: %v", err)
+		}
+		bug.Namespace = to
+		bug.Seq = seq
+		if _, err := db.Put(c, newKey, bug); err != nil {
+			return fmt.Errorf("failed to put bug: %v", err)
+		}
+		var newCrashKeys, newJobKeys []*db.Key
+		for _, key := range crashKeys {
+			newCrashKeys = append(newCrashKeys, db.NewKey(c, "Crash", key.StringID(), key.IntID(), newKey))
+		}
+		for i, key := range jobKeys {
+			jobs[i].Namespace = to
+			newJobKeys = append(newJobKeys, db.NewKey(c, "Job", key.StringID(), key.IntID(), newKey))
+		}
+		if _, err := db.PutMulti(c, newCrashKeys, crashes); err != nil {
+			return fmt.Errorf("failed to put crashes: %v", err)
+		}
+		if _, err := db.PutMulti(c, newJobKeys, jobs); err != nil {
+			return fmt.Errorf("failed to put jobs: %v", err)
+		}
+		if err := db.DeleteMulti(c, append(append(crashKeys, jobKeys...), oldKey)); err != nil {
+			return fmt.Errorf("failed to delete old entities: %v", err)
+		}
+		migration := &BugMigration{
+			Time:    timeNow(c),
+			Title:   bug.Title,
+			From:    from,
+			To:      to,
+			OldBug:  bugHash,
+			NewBug:  newHash,
+			Crashes: len(crashes),
+			Jobs:    len(jobs),
+		}
+		if _, err := db.Put(c, db.NewIncompleteKey(c, "BugMigration", nil), migration); err != nil {
+			return fmt.Errorf("failed to put migration: %v", err)
+		}
+		return nil
+	}
+	if err := db.RunInTransaction(c, tx, &db.TransactionOptions{XG: true}); err != nil {
+		return "", err
+	}
+	return newHash, nil
+}
+
+func checkBugMigration(c context.Context, bug *Bug, bugHash, to string) error {
+	if bug.Namespace == to {
+		return fmt.Errorf("bug is already in namespace %v", to)
+	}
+	if bug.DupOf != "" {
+		return fmt.Errorf("bug is a dup of %v, migrate the canonical bug instead", bug.DupOf)
+	}
+	dups, err := db.NewQuery("Bug").
+		Filter("DupOf=", bugHash).
+		KeysOnly().
+		GetAll(c, nil)
+	if err != nil {
+		return fmt.Errorf("failed to query dups: %v", err)
+	}
+	if len(dups) != 0 {
+		return fmt.Errorf("bug has %v dups", len(dups))
+	}
+	cfg := config.Namespaces[to]
+	for _, reporting := range bug.Reporting {
+		if cfg.ReportingByName(reporting.Name) == nil {
+			return fmt.Errorf("namespace %v has no reporting %v", to, reporting.Name)
+		}
+	}
+	return nil
+}
+
+func copyBuild(c context.Context, from, to, id string) error {
+	if err := db.Get(c, buildKey(c, to, id), new(Build)); err == nil {
+		return nil
+	} else if err != db.ErrNoSuchEntity {
+		return fmt.Errorf("failed to get build: %v", err)
+	}
+	build, err := loadBuild(c, from, id)
+	if err != nil {
+		return err
+	}
+	build.Namespace = to
+	if _, err := db.Put(c, buildKey(c, to, id), build); err != nil {
+		return fmt.Errorf("failed to put build: %v", err)
+	}
+	return nil
+}
+
 // Prevent warnings about dead code.
 var (
 	_ = dropNamespace
 	_ = updateBugReporting
+	_ = migrateBugs
 )
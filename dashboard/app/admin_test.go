@@ -0,0 +1,67 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	db "google.golang.org/appengine/datastore"
+)
+
+func TestMigrateBug(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client.UploadBuild(build)
+	crash := testCrash(build, 1)
+	c.client.ReportCrash(crash)
+	rep := c.client.pollBug()
+	c.client.updateBug(rep.ID, dashapi.BugStatusOpen, "")
+	c.client.ReportCrash(crash)
+
+	bug, _, _ := c.loadBug(rep.ID)
+	oldHash := bug.keyHash()
+
+	// Target namespace has no such reporting.
+	_, err := migrateBug(c.ctx, new(bytes.Buffer), oldHash, "access-admin", true)
+	c.expectTrue(err != nil)
+
+	// Dry run does not change anything.
+	out := new(bytes.Buffer)
+	newHash, err := migrateBug(c.ctx, out, oldHash, "test2", true)
+	c.expectOK(err)
+	c.expectTrue(bytes.Contains(out.Bytes(), []byte("crashes: 2, jobs: 0, builds: 1")))
+	bug, _, _ = c.loadBug(rep.ID)
+	c.expectEQ(bug.Namespace, "test1")
+
+	newHash1, err := migrateBug(c.ctx, new(bytes.Buffer), oldHash, "test2", false)
+	c.expectOK(err)
+	c.expectEQ(newHash1, newHash)
+	bug, dbCrash, dbBuild := c.loadBug(rep.ID)
+	c.expectEQ(bug.Namespace, "test2")
+	c.expectEQ(bug.keyHash(), newHash)
+	c.expectEQ(bug.NumCrashes, int64(2))
+	c.expectEQ(dbCrash.BuildID, build.ID)
+	c.expectEQ(dbBuild.Namespace, "test2")
+	err = db.Get(c.ctx, db.NewKey(c.ctx, "Bug", oldHash, 0, nil), new(Bug))
+	c.expectEQ(err, db.ErrNoSuchEntity)
+	crashes, _, err := queryCrashesForBug(c.ctx, bug.key(c.ctx), 10)
+	c.expectOK(err)
+	c.expectEQ(len(crashes), 2)
+
+	var migrations []*BugMigration
+	_, err = db.NewQuery("BugMigration").GetAll(c.ctx, &migrations)
+	c.expectOK(err)
+	c.expectEQ(len(migrations), 1)
+	c.expectEQ(migrations[0].OldBug, oldHash)
+	c.expectEQ(migrations[0].NewBug, newHash)
+	c.expectEQ(migrations[0].Crashes, 2)
+
+	// The bug is already there.
+	_, err = migrateBug(c.ctx, new(bytes.Buffer), newHash, "test2", false)
+	c.expectTrue(err != nil)
+}
@@ -208,6 +208,7 @@ loop:
 	resp := &dashapi.BuilderPollResp{
 		PendingCommits: commits,
 		ReportEmail:    reportEmail(c, ns),
+		DashboardURL:   appURL(c),
 	}
 	return resp, nil
 }
@@ -223,7 +224,8 @@ func reportEmail(c context.Context, ns string) string {
 
 func apiCommitPoll(c context.Context, ns string, r *http.Request, payload []byte) (interface{}, error) {
 	resp := &dashapi.CommitPollResp{
-		ReportEmail: reportEmail(c, ns),
+		ReportEmail:  reportEmail(c, ns),
+		DashboardURL: appURL(c),
 	}
 	for _, repo := range config.Namespaces[ns].Repos {
 		resp.Repos = append(resp.Repos, dashapi.Repo{
@@ -481,6 +483,7 @@ func uploadBuild(c context.Context, now time.Time, ns string, req *dashapi.Build
 		KernelCommit:        req.KernelCommit,
 		KernelCommitTitle:   req.KernelCommitTitle,
 		KernelCommitDate:    req.KernelCommitDate,
+		KernelRelease:       req.KernelRelease,
 		KernelConfig:        configID,
 	}
 	if _, err := db.Put(c, buildKey(c, ns, req.ID), build); err != nil {
@@ -762,6 +765,7 @@ func reportCrash(c context.Context, build *Build, req *dashapi.Crash) (*Bug, err
 		if !stringInList(bug.HappenedOn, build.Manager) {
 			bug.HappenedOn = append(bug.HappenedOn, build.Manager)
 		}
+		bug.updateReleases(build.KernelRelease)
 		if _, err = db.Put(c, bugKey, bug); err != nil {
 			return fmt.Errorf("failed to put bug: %v", err)
 		}
@@ -810,6 +814,9 @@ func saveCrash(c context.Context, ns string, req *dashapi.Crash, bugKey *db.Key,
 	if crash.ReproC, err = putText(c, ns, textReproC, req.ReproC, false); err != nil {
 		return err
 	}
+	if crash.CoverContext, err = putText(c, ns, textCoverContext, req.CoverContext, false); err != nil {
+		return err
+	}
 	crashKey := db.NewIncompleteKey(c, "Crash", bugKey)
 	if _, err = db.Put(c, crashKey, crash); err != nil {
 		return fmt.Errorf("failed to put crash: %v", err)
@@ -874,6 +881,9 @@ func purgeOldCrashes(c context.Context, bug *Bug, bugKey *db.Key) {
 		if crash.ReproC != 0 {
 			toDelete = append(toDelete, db.NewKey(c, textReproC, "", crash.ReproC, nil))
 		}
+		if crash.CoverContext != 0 {
+			toDelete = append(toDelete, db.NewKey(c, textCoverContext, "", crash.CoverContext, nil))
+		}
 		deleted++
 		if deleted == 2*purgeEvery {
 			break
@@ -957,8 +967,14 @@ func apiManagerStats(c context.Context, ns string, r *http.Request, payload []by
 	now := timeNow(c)
 	err := updateManager(c, ns, req.Name, func(mgr *Manager, stats *ManagerStats) error {
 		mgr.Link = req.Addr
+		mgr.TriageRate = updateTriageRate(mgr.TriageRate, mgr.TriageQueueLen, int64(req.TriageQueueLen),
+			now.Sub(mgr.LastAlive))
 		mgr.LastAlive = now
 		mgr.CurrentUpTime = req.UpTime
+		mgr.TriageQueueLen = int64(req.TriageQueueLen)
+		mgr.ReproQueueLen = int64(req.ReproQueueLen)
+		mgr.NumVMs = int64(req.NumVMs)
+		mgr.FuzzingVMs = int64(req.FuzzingVMs)
 		if cur := int64(req.Corpus); cur > stats.MaxCorpus {
 			stats.MaxCorpus = cur
 		}
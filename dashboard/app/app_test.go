@@ -412,6 +412,27 @@ func checkRedirect(c *Ctx, accessLevel AccessLevel, from, to string, status int)
 	c.expectEQ(httpErr.Headers["Location"], []string{to})
 }
 
+func TestCrashCoverContext(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client.UploadBuild(build)
+	crash := testCrash(build, 1)
+	crash.CoverContext = []byte("foo (foo.c): 1 out of 2 coverage points covered\n+     1 foo();\n")
+	c.client.ReportCrash(crash)
+	rep := c.client.pollBug()
+
+	_, dbCrash, _ := c.loadBug(rep.ID)
+	coverContext, _, err := getText(c.ctx, textCoverContext, dbCrash.CoverContext)
+	c.expectOK(err)
+	c.expectEQ(coverContext, crash.CoverContext)
+
+	page, err := c.AuthGET(AccessAdmin, "/bug?extid="+rep.ID)
+	c.expectOK(err)
+	c.expectTrue(strings.Contains(string(page), "1 out of 2 coverage points covered"))
+}
+
 // Test purging of old crashes for bugs with lots of crashes.
 func TestPurgeOldCrashes(t *testing.T) {
 	if testing.Short() {
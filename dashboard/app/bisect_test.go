@@ -1139,3 +1139,126 @@ func TestBugBisectionStatus(t *testing.T) {
 	msg := c.client2.pollEmailBug()
 	c.expectTrue(strings.Contains(msg.Body, "syzbot suspects this issue was fixed by commit:"))
 }
+
+func TestBisectVerdict(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client2.UploadBuild(build)
+	crash := testCrashWithRepro(build, 1)
+	crash.Maintainers = []string{"maintainer@kernel.org"}
+	c.client2.ReportCrash(crash)
+	msg := c.client2.pollEmailBug()
+
+	resp := c.client2.pollJobs(build.Manager)
+	c.client2.expectEQ(resp.Type, dashapi.JobBisectCause)
+	jobID := resp.ID
+	done := &dashapi.JobDoneReq{
+		ID:    jobID,
+		Build: *build,
+		Log:   []byte("bisect log"),
+		Commits: []dashapi.Commit{
+			{
+				Hash:       "36e65cb4a0448942ec316b24d60446bbd5cc7827",
+				Title:      "kernel: add a bug",
+				Author:     "author@kernel.org",
+				AuthorName: "Author Kernelov",
+				Date:       time.Date(2000, 2, 9, 4, 5, 6, 7, time.UTC),
+			},
+		},
+	}
+	done.Build.ID = jobID
+	c.expectOK(c.client2.JobDone(done))
+	c.pollEmailBug()
+
+	// There is no fix bisection, so we should get an error reply.
+	c.incomingEmail(msg.Sender, "#syz bisect-wrong fix", EmailOptFrom("maintainer@kernel.org"))
+	reply := c.pollEmailBug()
+	c.expectTrue(strings.Contains(reply.Body, "Can't find the bisection for this bug."))
+
+	// Only people who received the report can judge the result.
+	c.incomingEmail(msg.Sender, "#syz bisect-correct", EmailOptFrom("stranger@example.com"))
+	reply = c.pollEmailBug()
+	c.expectTrue(strings.Contains(reply.Body, "Only people in CC of the bug report can judge bisection results."))
+	job, _, _ := c.loadJob(jobID)
+	c.expectEQ(job.Verdict, BisectVerdictNone)
+
+	c.incomingEmail(msg.Sender, "#syz bisect-wrong", EmailOptFrom("maintainer@kernel.org"))
+	c.expectNoEmail()
+	job, _, _ = c.loadJob(jobID)
+	c.expectEQ(job.Verdict, BisectVerdictWrong)
+	c.expectEQ(job.VerdictUser, "maintainer@kernel.org")
+
+	stats, err := loadBisectStats(c.ctx)
+	c.expectOK(err)
+	c.expectEQ(len(stats), 1)
+	c.expectEQ(stats[0].Manager, build.Manager)
+	c.expectEQ(stats[0].Wrong, 1)
+	c.expectEQ(stats[0].Precision, 0)
+
+	// The stats are also shown on the bug page.
+	_, extBugID, err := email.RemoveAddrContext(msg.Sender)
+	c.expectOK(err)
+	page, err := c.AuthGET(AccessUser, "/bug?extid="+extBugID)
+	c.expectOK(err)
+	c.expectTrue(strings.Contains(string(page),
+		fmt.Sprintf("Bisections on %v: 0 correct, 1 wrong (0%% precision)", build.Manager)))
+
+	// Admins can change the verdict in the UI.
+	_, err = c.AuthGET(AccessAdmin, "/admin?action=bisect_verdict&verdict=correct&job="+jobID)
+	c.expectOK(err)
+	job, _, _ = c.loadJob(jobID)
+	c.expectEQ(job.Verdict, BisectVerdictCorrect)
+}
+
+func TestBisectCulpritDiff(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client2.UploadBuild(build)
+	crash := testCrashWithRepro(build, 1)
+	c.client2.ReportCrash(crash)
+	msg := c.client2.pollEmailBug()
+
+	resp := c.client2.pollJobs(build.Manager)
+	c.client2.expectEQ(resp.Type, dashapi.JobBisectCause)
+	// The patch is larger than the limit, the dashboard must cut it at a line boundary.
+	line := "+\tfoo = bar;\n"
+	patch := "diff --git a/file.c b/file.c\n" + strings.Repeat(line, dashapi.MaxCulpritPatch/len(line)+10)
+	done := &dashapi.JobDoneReq{
+		ID:    resp.ID,
+		Build: *build,
+		Log:   []byte("bisect log"),
+		Commits: []dashapi.Commit{
+			{
+				Hash:       "36e65cb4a0448942ec316b24d60446bbd5cc7827",
+				Title:      "kernel: add a bug",
+				Author:     "author@kernel.org",
+				AuthorName: "Author Kernelov",
+				Date:       time.Date(2000, 2, 9, 4, 5, 6, 7, time.UTC),
+				Diffstat:   " file.c | 2700 +++++\n 1 file changed, 2700 insertions(+)",
+				Patch:      []byte(patch),
+			},
+		},
+	}
+	done.Build.ID = resp.ID
+	c.expectOK(c.client2.JobDone(done))
+	c.pollEmailBug()
+
+	job, _, _ := c.loadJob(resp.ID)
+	c.expectEQ(len(job.Commits), 1)
+	com := job.Commits[0]
+	c.expectEQ(com.Diffstat, done.Commits[0].Diffstat)
+	c.expectTrue(strings.HasSuffix(com.Patch, "+\tfoo = bar;\n[truncated]\n"))
+	c.expectEQ(com.Patch, patch[:strings.LastIndexByte(patch[:dashapi.MaxCulpritPatch], '\n')+1]+"[truncated]\n")
+
+	_, extBugID, err := email.RemoveAddrContext(msg.Sender)
+	c.expectOK(err)
+	page, err := c.AuthGET(AccessUser, "/bug?extid="+extBugID)
+	c.expectOK(err)
+	c.expectTrue(bytes.Contains(page, []byte(" file.c | 2700 +++++")))
+	c.expectTrue(bytes.Contains(page, []byte("diff --git a/file.c b/file.c\n+\tfoo = bar;\n")))
+	c.expectTrue(bytes.Contains(page, []byte("[truncated]")))
+}
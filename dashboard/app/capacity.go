@@ -0,0 +1,132 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	db "google.golang.org/appengine/datastore"
+)
+
+// Capacity page summarizes fleet load based on stats uploaded by managers:
+// execution speed, VM utilization and queues, to help decide where to add VMs.
+
+type uiCapacityPage struct {
+	Header   *uiHeader
+	Total    *uiCapacity
+	Managers []*uiCapacity
+}
+
+type uiCapacity struct {
+	Namespace   string
+	Name        string
+	NumVMs      int64
+	FuzzingVMs  int64
+	Utilization int     // percent of VMs that are fuzzing
+	ExecsPerSec float64 // fleet-wide estimate: per-VM speed today * fuzzing VMs
+	TriageQueue int64
+	TriageRate  float64       // triaged inputs per hour
+	TriageETA   time.Duration // projected time to drain the triage queue, 0 if unknown
+	ReproQueue  int64
+}
+
+func handleCapacity(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	hdr, err := commonHeader(c, r, w, "")
+	if err != nil {
+		return err
+	}
+	now := timeNow(c)
+	date := timeDate(now)
+	managers, keys, err := loadManagerList(c, accessLevel(c, r), "")
+	if err != nil {
+		return err
+	}
+	var alive []*Manager
+	var statsKeys []*db.Key
+	for i, mgr := range managers {
+		if now.Sub(mgr.LastAlive) > 6*time.Hour {
+			continue
+		}
+		alive = append(alive, mgr)
+		statsKeys = append(statsKeys, db.NewKey(c, "ManagerStats", "", int64(date), keys[i]))
+	}
+	stats := make([]*ManagerStats, len(statsKeys))
+	if err := db.GetMulti(c, statsKeys, stats); err != nil {
+		merr, ok := err.(appengine.MultiError)
+		if !ok {
+			return err
+		}
+		for i, err1 := range merr {
+			if err1 == db.ErrNoSuchEntity {
+				stats[i] = nil // no stats uploaded today yet
+			} else if err1 != nil {
+				return err1
+			}
+		}
+	}
+	data := &uiCapacityPage{
+		Header: hdr,
+		Total:  &uiCapacity{Name: "total"},
+	}
+	for i, mgr := range alive {
+		ui := makeUICapacity(mgr, stats[i])
+		data.Managers = append(data.Managers, ui)
+		data.Total.add(ui)
+	}
+	data.Total.finish()
+	return serveTemplate(w, "capacity.html", data)
+}
+
+func makeUICapacity(mgr *Manager, stats *ManagerStats) *uiCapacity {
+	ui := &uiCapacity{
+		Namespace:   mgr.Namespace,
+		Name:        mgr.Name,
+		NumVMs:      mgr.NumVMs,
+		FuzzingVMs:  mgr.FuzzingVMs,
+		TriageQueue: mgr.TriageQueueLen,
+		TriageRate:  mgr.TriageRate,
+		ReproQueue:  mgr.ReproQueueLen,
+	}
+	if stats != nil && stats.TotalFuzzingTime > 0 {
+		ui.ExecsPerSec = float64(stats.TotalExecs) / stats.TotalFuzzingTime.Seconds() * float64(mgr.FuzzingVMs)
+	}
+	ui.finish()
+	return ui
+}
+
+func (ui *uiCapacity) add(ui1 *uiCapacity) {
+	ui.NumVMs += ui1.NumVMs
+	ui.FuzzingVMs += ui1.FuzzingVMs
+	ui.ExecsPerSec += ui1.ExecsPerSec
+	ui.TriageQueue += ui1.TriageQueue
+	ui.TriageRate += ui1.TriageRate
+	ui.ReproQueue += ui1.ReproQueue
+}
+
+func (ui *uiCapacity) finish() {
+	if ui.NumVMs != 0 {
+		ui.Utilization = int(ui.FuzzingVMs * 100 / ui.NumVMs)
+	}
+	if ui.TriageQueue != 0 && ui.TriageRate > 0 {
+		ui.TriageETA = time.Duration(float64(ui.TriageQueue) / ui.TriageRate * float64(time.Hour))
+	}
+}
+
+// updateTriageRate updates smoothed triage rate (inputs per hour) given triage queue length
+// at the previous (prevQueue) and the current (curQueue) stats upload separated by elapsed.
+func updateTriageRate(rate float64, prevQueue, curQueue int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 || elapsed > time.Hour || prevQueue == 0 || curQueue > prevQueue {
+		// No measurement: the manager restarted, the queue was empty or got new inputs.
+		return rate
+	}
+	cur := float64(prevQueue-curQueue) / elapsed.Hours()
+	if rate == 0 {
+		return cur
+	}
+	const weight = 0.2
+	return rate*(1-weight) + cur*weight
+}
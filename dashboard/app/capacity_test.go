@@ -0,0 +1,30 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateTriageRate(t *testing.T) {
+	tests := []struct {
+		rate      float64
+		prev, cur int64
+		elapsed   time.Duration
+		result    float64
+	}{
+		{0, 100, 90, time.Minute, 600},
+		{600, 100, 80, time.Minute, 720},
+		{600, 0, 0, time.Minute, 600},
+		{600, 100, 200, time.Minute, 600},
+		{600, 100, 50, 2 * time.Hour, 600},
+		{600, 100, 100, time.Minute, 480},
+	}
+	for i, test := range tests {
+		if got := updateTriageRate(test.rate, test.prev, test.cur, test.elapsed); got != test.result {
+			t.Errorf("#%v: got %v, want %v", i, got, test.result)
+		}
+	}
+}
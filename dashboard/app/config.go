@@ -33,6 +33,10 @@ type GlobalConfig struct {
 	Clients map[string]string
 	// List of emails blocked from issuing test requests.
 	EmailBlocklist []string
+	// Secret key used to sign links to email preferences pages (optional).
+	// If set, report emails contain a link that allows recipients to mute syzbot emails
+	// or redirect them to a different address.
+	EmailPrefsKey string
 	// Bug obsoleting settings. See ObsoletingConfig for details.
 	Obsoleting ObsoletingConfig
 	// Namespace that is shown by default (no namespace selected yet).
@@ -0,0 +1,196 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/net/context"
+	db "google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+)
+
+// Email digests.
+// Recipients that chose hourly/daily digests on the email preferences page don't receive
+// individual bug reports and notifications. Instead the events are queued as EmailDigestItem's
+// and periodically sent as a single email per recipient.
+
+func (mode DigestMode) period() time.Duration {
+	switch mode {
+	case DigestHourly:
+		return time.Hour
+	case DigestDaily:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func (mode DigestMode) String() string {
+	switch mode {
+	case DigestHourly:
+		return "hourly"
+	case DigestDaily:
+		return "daily"
+	default:
+		return "immediate"
+	}
+}
+
+// queueDigestItems queues the event for all recipients in digests.
+func queueDigestItems(c context.Context, digests []*EmailPrefs, title, event, link, cmdAddr string) error {
+	var keys []*db.Key
+	var items []*EmailDigestItem
+	seen := make(map[string]bool)
+	for _, prefs := range digests {
+		recipient := prefs.Email
+		if prefs.Redirect != "" {
+			recipient = prefs.Redirect
+		}
+		if seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+		keys = append(keys, db.NewIncompleteKey(c, "EmailDigestItem", nil))
+		items = append(items, &EmailDigestItem{
+			Recipient: recipient,
+			Mode:      prefs.Digest,
+			Time:      timeNow(c),
+			Title:     title,
+			Event:     event,
+			Link:      link,
+			CmdAddr:   cmdAddr,
+		})
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := db.PutMulti(c, keys, items); err != nil {
+		return fmt.Errorf("failed to save digest items: %v", err)
+	}
+	return nil
+}
+
+// Bounds on the work done per poll, the rest is handled by the next polls.
+const (
+	maxDigestRecipients = 50  // digests sent per poll
+	maxDigestItems      = 200 // items in a single digest
+)
+
+func emailPollDigests(c context.Context) error {
+	now := timeNow(c)
+	// A digest is due if its oldest item is older than the digest period, so first find
+	// recipients of such items and only then query all items of these recipients.
+	var recipients []string
+	seen := make(map[string]bool)
+	for _, mode := range []DigestMode{DigestHourly, DigestDaily} {
+		var items []*EmailDigestItem
+		_, err := db.NewQuery("EmailDigestItem").
+			Filter("Mode=", mode).
+			Filter("Time<=", now.Add(-mode.period())).
+			Order("Time").
+			Limit(maxDigestRecipients*10).
+			GetAll(c, &items)
+		if err != nil {
+			return fmt.Errorf("failed to query digest items: %v", err)
+		}
+		for _, item := range items {
+			if !seen[item.Recipient] && len(recipients) < maxDigestRecipients {
+				seen[item.Recipient] = true
+				recipients = append(recipients, item.Recipient)
+			}
+		}
+	}
+	for _, recipient := range recipients {
+		var items []*EmailDigestItem
+		keys, err := db.NewQuery("EmailDigestItem").
+			Filter("Recipient=", recipient).
+			Order("Time").
+			Limit(maxDigestItems).
+			GetAll(c, &items)
+		if err != nil {
+			return fmt.Errorf("failed to query digest items: %v", err)
+		}
+		for _, digest := range dueDigests(items, keys, now) {
+			if err := emailSendDigest(c, digest); err != nil {
+				log.Errorf(c, "emailPollDigests: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+type emailDigest struct {
+	Recipient string
+	Mode      DigestMode
+	Items     []*EmailDigestItem
+	keys      []*db.Key
+}
+
+// dueDigests groups items by recipient and returns digests that are due to be sent,
+// that is, the oldest item was queued at least the digest period ago.
+// Items within a digest are sorted by time.
+func dueDigests(items []*EmailDigestItem, keys []*db.Key, now time.Time) []*emailDigest {
+	perRecipient := make(map[string]*emailDigest)
+	var recipients []string
+	for i, item := range items {
+		digest := perRecipient[item.Recipient]
+		if digest == nil {
+			digest = &emailDigest{Recipient: item.Recipient}
+			perRecipient[item.Recipient] = digest
+			recipients = append(recipients, item.Recipient)
+		}
+		digest.Items = append(digest.Items, item)
+		digest.keys = append(digest.keys, keys[i])
+	}
+	sort.Strings(recipients)
+	var res []*emailDigest
+	for _, recipient := range recipients {
+		digest := perRecipient[recipient]
+		sort.Sort(digestItemSorter(*digest))
+		oldest := digest.Items[0]
+		digest.Mode = oldest.Mode
+		if now.Sub(oldest.Time) < oldest.Mode.period() {
+			continue
+		}
+		res = append(res, digest)
+	}
+	return res
+}
+
+type digestItemSorter emailDigest
+
+func (s digestItemSorter) Len() int { return len(s.Items) }
+func (s digestItemSorter) Less(i, j int) bool {
+	if !s.Items[i].Time.Equal(s.Items[j].Time) {
+		return s.Items[i].Time.Before(s.Items[j].Time)
+	}
+	return s.Items[i].Title < s.Items[j].Title
+}
+func (s digestItemSorter) Swap(i, j int) {
+	s.Items[i], s.Items[j] = s.Items[j], s.Items[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+func emailSendDigest(c context.Context, digest *emailDigest) error {
+	subject := fmt.Sprintf("syzbot %v digest: %v bug events", digest.Mode, len(digest.Items))
+	data := struct {
+		*emailDigest
+		PrefsLink string
+	}{
+		emailDigest: digest,
+		PrefsLink:   appURL(c) + "/email_prefs",
+	}
+	log.Infof(c, "sending %v digest with %v items to %q", digest.Mode, len(digest.Items), digest.Recipient)
+	if err := sendMailTemplate(c, subject, fromAddr(c), []string{digest.Recipient}, "", nil,
+		"mail_digest.txt", data); err != nil {
+		return err
+	}
+	if err := db.DeleteMulti(c, digest.keys); err != nil {
+		return fmt.Errorf("failed to delete digest items: %v", err)
+	}
+	return nil
+}
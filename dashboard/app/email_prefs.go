@@ -0,0 +1,237 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/email"
+	"github.com/google/syzkaller/pkg/hash"
+	"golang.org/x/net/context"
+	"google.golang.org/appengine"
+	db "google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/memcache"
+	"google.golang.org/appengine/user"
+)
+
+// Self-service email preferences.
+// Report emails contain a link to the preferences page, where a logged in recipient enters
+// own email address and receives a link signed with GlobalConfig.EmailPrefsKey.
+// The signed link allows to mute syzbot emails, redirect them to a different address
+// in the same domain or receive them as hourly/daily digests.
+// Link emails are rate limited per requesting user and per address, so that the page
+// can't be used to flood arbitrary addresses.
+
+type uiEmailPrefsPage struct {
+	Header   *uiHeader
+	LinkSent string
+	Email    string
+	Sig      string
+	XSRF     string
+	Prefs    *EmailPrefs
+	Digests  []uiDigestMode
+	Saved    bool
+}
+
+const (
+	// Max number of preferences links sent per hour per requesting user and per address.
+	maxEmailPrefsLinksPerUser = 10
+	maxEmailPrefsLinksPerAddr = 2
+)
+
+type uiDigestMode struct {
+	Mode DigestMode
+	Name string
+}
+
+var uiDigestModes = []uiDigestMode{
+	{DigestImmediate, "send every email immediately"},
+	{DigestHourly, "hourly digest"},
+	{DigestDaily, "daily digest"},
+}
+
+func handleEmailPrefs(c context.Context, w http.ResponseWriter, r *http.Request) error {
+	if config.EmailPrefsKey == "" {
+		return ErrDontLog{fmt.Errorf("email preferences are not enabled")}
+	}
+	data := &uiEmailPrefsPage{
+		Header: commonHeaderRaw(c, r),
+		Email:  email.CanonicalEmail(r.FormValue("email")),
+		Sig:    r.FormValue("sig"),
+	}
+	if data.Sig == "" {
+		// Sending of links is available only to logged in users and is protected by XSRF token.
+		u := user.Current(c)
+		if u == nil {
+			return ErrAccess
+		}
+		data.XSRF = emailPrefsSig("xsrf:" + u.Email)
+		if r.Method == http.MethodPost && data.Email != "" {
+			if !hmac.Equal([]byte(r.FormValue("xsrf")), []byte(data.XSRF)) {
+				return ErrDontLog{fmt.Errorf("bad XSRF token")}
+			}
+			if _, err := mail.ParseAddress(data.Email); err != nil {
+				return ErrDontLog{fmt.Errorf("bad email address %q: %v", data.Email, err)}
+			}
+			if err := checkEmailPrefsRate(c, u.Email, data.Email); err != nil {
+				return err
+			}
+			if err := sendEmailPrefsLink(c, data.Email); err != nil {
+				return err
+			}
+			data.LinkSent = data.Email
+		}
+		return serveTemplate(w, "email_prefs.html", data)
+	}
+	if !hmac.Equal([]byte(data.Sig), []byte(emailPrefsSig(data.Email))) {
+		return ErrDontLog{fmt.Errorf("bad email preferences link")}
+	}
+	prefs, err := loadEmailPrefs(c, data.Email)
+	if err != nil {
+		return err
+	}
+	if r.Method == http.MethodPost {
+		prefs.Muted = r.FormValue("muted") != ""
+		prefs.Redirect = ""
+		if redirect := r.FormValue("redirect"); redirect != "" {
+			addr, err := mail.ParseAddress(redirect)
+			if err != nil {
+				return ErrDontLog{fmt.Errorf("bad redirect address %q: %v", redirect, err)}
+			}
+			redirect = email.CanonicalEmail(addr.Address)
+			if emailDomain(redirect) != emailDomain(data.Email) {
+				return ErrDontLog{fmt.Errorf("redirect address %q is not in the same domain as %q",
+					redirect, data.Email)}
+			}
+			prefs.Redirect = redirect
+		}
+		digest, err := strconv.Atoi(r.FormValue("digest"))
+		if err != nil || digest < int(DigestImmediate) || digest > int(DigestDaily) {
+			return ErrDontLog{fmt.Errorf("bad digest mode %q", r.FormValue("digest"))}
+		}
+		prefs.Digest = DigestMode(digest)
+		prefs.Updated = timeNow(c)
+		if _, err := db.Put(c, emailPrefsKey(c, data.Email), prefs); err != nil {
+			return fmt.Errorf("failed to save email preferences: %v", err)
+		}
+		data.Saved = true
+	}
+	data.Prefs = prefs
+	data.Digests = uiDigestModes
+	return serveTemplate(w, "email_prefs.html", data)
+}
+
+// checkEmailPrefsRate returns an error if too many preferences links were requested
+// by the user or sent to the address within the current hour.
+func checkEmailPrefsRate(c context.Context, requester, addr string) error {
+	hour := timeNow(c).Unix() / 3600
+	for _, limit := range []struct {
+		key string
+		max uint64
+	}{
+		{fmt.Sprintf("email-prefs-user-%v-%v", hash.String([]byte(requester)), hour), maxEmailPrefsLinksPerUser},
+		{fmt.Sprintf("email-prefs-addr-%v-%v", hash.String([]byte(addr)), hour), maxEmailPrefsLinksPerAddr},
+	} {
+		sent, err := memcache.Increment(c, limit.key, 1, 0)
+		if err != nil {
+			return err
+		}
+		if sent > limit.max {
+			return ErrDontLog{fmt.Errorf("too many email preferences requests, try again later")}
+		}
+	}
+	return nil
+}
+
+func emailDomain(addr string) string {
+	return addr[strings.LastIndexByte(addr, '@')+1:]
+}
+
+func sendEmailPrefsLink(c context.Context, addr string) error {
+	link := fmt.Sprintf("%v/email_prefs?email=%v&sig=%v", appURL(c),
+		url.QueryEscape(addr), emailPrefsSig(addr))
+	body := fmt.Sprintf("Somebody (hopefully you) requested to change syzbot email preferences for %v.\n"+
+		"Use the following link to do this:\n%v\n\n"+
+		"If you did not request this, ignore this email.\n", addr, link)
+	return sendMailText(c, "syzbot email preferences", fromAddr(c), []string{addr}, "", nil, body)
+}
+
+// emailPrefsSig returns signature that authorizes changes of preferences of the canonical address addr.
+func emailPrefsSig(addr string) string {
+	mac := hmac.New(sha256.New, []byte(config.EmailPrefsKey))
+	mac.Write([]byte(addr))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func emailPrefsKey(c context.Context, addr string) *db.Key {
+	return db.NewKey(c, "EmailPrefs", addr, 0, nil)
+}
+
+func loadEmailPrefs(c context.Context, addr string) (*EmailPrefs, error) {
+	prefs := &EmailPrefs{Email: addr}
+	if err := db.Get(c, emailPrefsKey(c, addr), prefs); err != nil && err != db.ErrNoSuchEntity {
+		return nil, fmt.Errorf("failed to load email preferences: %v", err)
+	}
+	return prefs, nil
+}
+
+// applyEmailPrefs removes recipients that muted syzbot emails and replaces redirected ones.
+// If digest is set, recipients that prefer digests are removed as well and their preferences
+// are returned separately. mailingList is never affected.
+func applyEmailPrefs(c context.Context, to []string, mailingList string, digest bool) (
+	[]string, []*EmailPrefs, error) {
+	if config.EmailPrefsKey == "" {
+		return to, nil, nil
+	}
+	var keys []*db.Key
+	for _, addr := range to {
+		keys = append(keys, emailPrefsKey(c, email.CanonicalEmail(addr)))
+	}
+	prefs := make([]*EmailPrefs, len(keys))
+	for i := range prefs {
+		prefs[i] = new(EmailPrefs)
+	}
+	err := db.GetMulti(c, keys, prefs)
+	if merr, ok := err.(appengine.MultiError); ok {
+		for i, err1 := range merr {
+			if err1 == db.ErrNoSuchEntity {
+				prefs[i] = nil
+			} else if err1 != nil {
+				return nil, nil, fmt.Errorf("failed to load email preferences: %v", err1)
+			}
+		}
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to load email preferences: %v", err)
+	}
+	res, digests := filterEmailPrefs(to, prefs, mailingList, digest)
+	return res, digests, nil
+}
+
+func filterEmailPrefs(to []string, prefs []*EmailPrefs, mailingList string, digest bool) (
+	[]string, []*EmailPrefs) {
+	var res []string
+	var digests []*EmailPrefs
+	for i, addr := range to {
+		switch {
+		case prefs[i] == nil || email.CanonicalEmail(addr) == email.CanonicalEmail(mailingList):
+			res = append(res, addr)
+		case prefs[i].Muted:
+		case digest && prefs[i].Digest != DigestImmediate:
+			digests = append(digests, prefs[i])
+		case prefs[i].Redirect != "":
+			res = append(res, prefs[i].Redirect)
+		default:
+			res = append(res, addr)
+		}
+	}
+	return email.MergeEmailLists(res), digests
+}
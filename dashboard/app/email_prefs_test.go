@@ -0,0 +1,209 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/email"
+	db "google.golang.org/appengine/datastore"
+)
+
+func TestFilterEmailPrefs(t *testing.T) {
+	to := []string{"list@googlegroups.com", "a@a.com", "b@b.com", "c@c.com", "d@d.com"}
+	prefs := []*EmailPrefs{
+		{Muted: true},
+		nil,
+		{Muted: true},
+		{Redirect: "a@a.com"},
+		{Redirect: "e@e.com"},
+	}
+	got, digests := filterEmailPrefs(to, prefs, "list@googlegroups.com", true)
+	want := []string{"a@a.com", "e@e.com", "list@googlegroups.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if len(digests) != 0 {
+		t.Fatalf("got unexpected digests: %+v", digests)
+	}
+}
+
+func TestFilterEmailPrefsDigest(t *testing.T) {
+	to := []string{"list@googlegroups.com", "a@a.com", "b@b.com", "c@c.com"}
+	prefs := []*EmailPrefs{
+		{Digest: DigestDaily},
+		{Email: "a@a.com", Digest: DigestHourly},
+		{Email: "b@b.com", Muted: true, Digest: DigestDaily},
+		nil,
+	}
+	got, digests := filterEmailPrefs(to, prefs, "list@googlegroups.com", true)
+	want := []string{"c@c.com", "list@googlegroups.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(digests, prefs[1:2]) {
+		t.Fatalf("got digests %+v, want %+v", digests, prefs[1:2])
+	}
+	// Digests are not used for emails that the recipient explicitly requested.
+	got, digests = filterEmailPrefs(to, prefs, "list@googlegroups.com", false)
+	want = []string{"a@a.com", "c@c.com", "list@googlegroups.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if len(digests) != 0 {
+		t.Fatalf("got unexpected digests: %+v", digests)
+	}
+}
+
+func TestDueDigests(t *testing.T) {
+	now := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	items := []*EmailDigestItem{
+		{Recipient: "a@a.com", Mode: DigestHourly, Time: now.Add(-10 * time.Minute), Title: "bug2"},
+		{Recipient: "b@b.com", Mode: DigestDaily, Time: now.Add(-2 * time.Hour), Title: "bug3"},
+		{Recipient: "a@a.com", Mode: DigestHourly, Time: now.Add(-2 * time.Hour), Title: "bug1"},
+		{Recipient: "c@c.com", Mode: DigestDaily, Time: now.Add(-25 * time.Hour), Title: "bug4"},
+	}
+	keys := make([]*db.Key, len(items))
+	got := dueDigests(items, keys, now)
+	if len(got) != 2 {
+		t.Fatalf("got %v digests, want 2", len(got))
+	}
+	if got[0].Recipient != "a@a.com" || got[0].Mode != DigestHourly || len(got[0].Items) != 2 ||
+		got[0].Items[0].Title != "bug1" || got[0].Items[1].Title != "bug2" {
+		t.Fatalf("bad digest %+v", got[0])
+	}
+	if got[1].Recipient != "c@c.com" || len(got[1].Items) != 1 {
+		t.Fatalf("bad digest %+v", got[1])
+	}
+}
+
+func TestEmailDigest(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	defer func(key string) { config.EmailPrefsKey = key }(config.EmailPrefsKey)
+	config.EmailPrefsKey = "prefs-key"
+	_, err := db.Put(c.ctx, emailPrefsKey(c.ctx, "foo@bar.com"), &EmailPrefs{
+		Email:  "foo@bar.com",
+		Digest: DigestDaily,
+	})
+	c.expectOK(err)
+
+	build := testBuild(1)
+	c.client2.UploadBuild(build)
+
+	crash := testCrash(build, 1)
+	crash.Maintainers = []string{"foo@bar.com", "bar@foo.com"}
+	c.client2.ReportCrash(crash)
+
+	msg := c.pollEmailBug()
+	c.incomingEmail(msg.Sender, "#syz upstream")
+	msg = c.pollEmailBug()
+	c.expectEQ(msg.To, []string{"bar@foo.com", "bugs@syzkaller.com", "default@maintainers.com"})
+	_, extBugID, err := email.RemoveAddrContext(msg.Sender)
+	c.expectOK(err)
+
+	c.advanceTime(time.Hour)
+	c.expectNoEmail()
+
+	c.advanceTime(24 * time.Hour)
+	digest := c.pollEmailBug()
+	c.expectEQ(digest.To, []string{"foo@bar.com"})
+	c.expectEQ(digest.Subject, "syzbot daily digest: 1 bug events")
+	c.expectEQ(digest.Body, fmt.Sprintf(`Hello,
+
+syzbot has the following bug updates for you:
+
+title1
+event:          new bug report
+dashboard link: https://testapp.appspot.com/bug?extid=%[1]v
+commands to:    syzbot+%[1]v@testapp.appspotmail.com
+
+To act on a bug, send #syz commands (e.g. #syz fix: exact-commit-title,
+#syz dup: exact-subject-of-another-report or #syz invalid) in an email
+to the "commands to" address of the bug.
+See https://goo.gl/tpsmEJ#status for how to communicate with syzbot.
+
+To change how often you receive these digests, see:
+https://testapp.appspot.com/email_prefs
+`, extBugID))
+	c.expectNoEmail()
+}
+
+func TestEmailDigestLimits(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	prefs := []*EmailPrefs{{Email: "foo@bar.com", Digest: DigestHourly}}
+	for i := 0; i < maxDigestItems+1; i++ {
+		c.expectOK(queueDigestItems(c.ctx, prefs, fmt.Sprintf("title%v", i), "new bug report", "link", "cmd"))
+		c.advanceTime(time.Second)
+	}
+	c.expectNoEmail()
+	c.advanceTime(time.Hour)
+	// Items that don't fit into one digest are sent with the next poll.
+	digest := c.pollEmailBug()
+	c.expectEQ(digest.Subject, fmt.Sprintf("syzbot hourly digest: %v bug events", maxDigestItems))
+	digest = c.pollEmailBug()
+	c.expectEQ(digest.Subject, "syzbot hourly digest: 1 bug events")
+	c.expectNoEmail()
+}
+
+func TestEmailPrefsHandler(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	defer func(key string) { config.EmailPrefsKey = key }(config.EmailPrefsKey)
+	config.EmailPrefsKey = "prefs-key"
+
+	// Links are sent only to logged in users with the right XSRF token.
+	_, err := c.httpRequest("POST", "/email_prefs?email=foo@bar.com", "", AccessPublic)
+	c.expectNE(err, nil)
+	_, err = c.httpRequest("POST", "/email_prefs?email=foo@bar.com&xsrf=bad", "", AccessUser)
+	c.expectNE(err, nil)
+	c.expectNoEmail()
+	xsrf := emailPrefsSig("xsrf:user@syzkaller.com")
+	reply, err := c.httpRequest("GET", "/email_prefs", "", AccessUser)
+	c.expectOK(err)
+	c.expectTrue(strings.Contains(string(reply), xsrf))
+
+	sendLink := "/email_prefs?email=foo@bar.com&xsrf=" + xsrf
+	for i := 0; i < maxEmailPrefsLinksPerAddr; i++ {
+		_, err = c.httpRequest("POST", sendLink, "", AccessUser)
+		c.expectOK(err)
+		msg := c.pollEmailBug()
+		c.expectEQ(msg.To, []string{"foo@bar.com"})
+		sig := emailPrefsSig("foo@bar.com")
+		c.expectTrue(strings.Contains(msg.Body, "email_prefs?email=foo%40bar.com&sig="+sig))
+	}
+	// Further requests for the same address are rate limited.
+	_, err = c.httpRequest("POST", sendLink, "", AccessUser)
+	c.expectNE(err, nil)
+	c.expectNoEmail()
+	c.advanceTime(time.Hour)
+	_, err = c.httpRequest("POST", sendLink, "", AccessUser)
+	c.expectOK(err)
+	c.pollEmailBug()
+
+	// The signed link allows to change preferences.
+	prefsLink := "/email_prefs?email=foo@bar.com&sig=" + emailPrefsSig("foo@bar.com")
+	_, err = c.httpRequest("POST", "/email_prefs?email=foo@bar.com&sig=bad&muted=1&digest=0", "", AccessPublic)
+	c.expectNE(err, nil)
+	_, err = c.httpRequest("POST", prefsLink+"&digest=0&redirect="+url.QueryEscape("evil@evil.com"),
+		"", AccessPublic)
+	c.expectNE(err, nil)
+	_, err = c.httpRequest("POST", prefsLink+"&digest=1&redirect="+url.QueryEscape("other@bar.com"),
+		"", AccessPublic)
+	c.expectOK(err)
+	prefs, err := loadEmailPrefs(c.ctx, "foo@bar.com")
+	c.expectOK(err)
+	c.expectEQ(prefs.Redirect, "other@bar.com")
+	c.expectEQ(prefs.Digest, DigestHourly)
+	c.expectEQ(prefs.Muted, false)
+}
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/google/syzkaller/dashboard/dashapi"
 	"github.com/google/syzkaller/pkg/email"
+	db "google.golang.org/appengine/datastore"
 )
 
 // nolint: funlen
@@ -619,3 +621,77 @@ syzbot will keep track of this issue. See:
 https://goo.gl/tpsmEJ#status for how to communicate with syzbot.`,
 		extBugID, crashLogLink, kernelConfigLink))
 }
+
+func TestEmailQuarantine(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	// Replies to bad commands are sent only for the first few identical emails,
+	// the rest looks like a mail loop and is quarantined.
+	for i := 0; i < maxIdenticalEmails; i++ {
+		c.incomingEmail("syzbot+123@testapp.appspotmail.com", "#syz invalid")
+		c.pollEmailBug()
+	}
+	c.incomingEmail("syzbot+123@testapp.appspotmail.com", "#syz invalid")
+	c.expectNoEmail()
+
+	// Autoresponder emails are not processed at all.
+	c.expectOK(c.POST("/_ah/mail/", `Sender: robot@sender.com
+Date: Tue, 15 Aug 2017 14:59:00 -0700
+Message-ID: <1>
+Subject: Out of office
+From: robot@sender.com
+To: syzbot+123@testapp.appspotmail.com
+Auto-Submitted: auto-replied
+Content-Type: text/plain
+
+#syz invalid
+`))
+	c.expectNoEmail()
+
+	var quarantined []*QuarantinedEmail
+	_, err := db.NewQuery("QuarantinedEmail").GetAll(c.ctx, &quarantined)
+	c.expectOK(err)
+	c.expectEQ(len(quarantined), 2)
+
+	// The quarantine is reviewable on the admin page.
+	reply, err := c.AuthGET(AccessAdmin, "/admin")
+	c.expectOK(err)
+	c.expectTrue(bytes.Contains(reply, []byte("auto-submitted email")))
+}
+
+func TestEmailHelp(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client2.UploadBuild(build)
+
+	crash := testCrash(build, 1)
+	c.client2.ReportCrash(crash)
+	sender := c.pollEmailBug().Sender
+
+	// No reproducer: testing commands are not offered.
+	c.incomingEmail(sender, "#syz help")
+	body := c.pollEmailBug().Body
+	c.expectTrue(strings.Contains(body, "#syz fix: exact-commit-title"))
+	c.expectTrue(strings.Contains(body, "#syz upstream"))
+	c.expectTrue(!strings.Contains(body, "#syz test:"))
+
+	crash.ReproSyz = []byte("repro syz")
+	c.client2.ReportCrash(crash)
+	c.client2.pollAndFailBisectJob(build.Manager)
+	c.pollEmailBug()
+	c.incomingEmail(sender, "#syz help")
+	body = c.pollEmailBug().Body
+	c.expectTrue(strings.Contains(body, "#syz test: git://repo/address.git branch-or-commit-hash"))
+	c.expectTrue(strings.Contains(body, "#syz test: --dry-run"))
+
+	// Closed bugs accept only a few commands.
+	c.incomingEmail(sender, "#syz invalid")
+	c.incomingEmail(sender, "#syz help")
+	body = c.pollEmailBug().Body
+	c.expectTrue(strings.Contains(body, "This bug is already closed"))
+	c.expectTrue(!strings.Contains(body, "#syz fix:"))
+	c.expectTrue(strings.Contains(body, "#syz uncc"))
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/google/syzkaller/dashboard/dashapi"
 	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/vcs"
 	"golang.org/x/net/context"
 	db "google.golang.org/appengine/datastore"
 )
@@ -33,6 +34,11 @@ type Manager struct {
 	FailedSyzBuildBug string
 	LastAlive         time.Time
 	CurrentUpTime     time.Duration
+	TriageQueueLen    int64
+	ReproQueueLen     int64
+	NumVMs            int64
+	FuzzingVMs        int64
+	TriageRate        float64 // smoothed number of triaged inputs per hour
 }
 
 // ManagerStats holds per-day manager runtime stats.
@@ -66,6 +72,7 @@ type Build struct {
 	KernelCommit        string
 	KernelCommitTitle   string    `datastore:",noindex"`
 	KernelCommitDate    time.Time `datastore:",noindex"`
+	KernelRelease       string    `datastore:",noindex"`
 	KernelConfig        int64     // reference to KernelConfig text entity
 }
 
@@ -95,6 +102,9 @@ type Bug struct {
 	HappenedOn     []string // list of managers
 	PatchedOn      []string `datastore:",noindex"` // list of managers
 	UNCC           []string // don't CC these emails on this bug
+	// Oldest and newest kernel releases of builds the bug was observed on.
+	FirstRelease string `datastore:",noindex"`
+	LastRelease  string `datastore:",noindex"`
 }
 
 type Commit struct {
@@ -104,6 +114,9 @@ type Commit struct {
 	AuthorName string
 	CC         string `datastore:",noindex"` // (|-delimited list)
 	Date       time.Time
+	// Only for the culprit commit of bisection jobs.
+	Diffstat string `datastore:",noindex"`
+	Patch    string `datastore:",noindex"` // patch excerpt (truncated to dashapi.MaxCulpritPatch)
 }
 
 type BugReporting struct {
@@ -135,6 +148,8 @@ type Crash struct {
 	// For example, a crash in mainline kernel has higher priority than a crash in a side branch.
 	// For historical reasons this is called ReportLen.
 	ReportLen int64
+	// Source of the crashing function annotated with fuzzer coverage.
+	CoverContext int64 // reference to CoverContext text entity
 }
 
 // ReportingState holds dynamic info associated with reporting.
@@ -189,6 +204,11 @@ type Job struct {
 	Flags       JobFlags
 
 	Reported bool // have we reported result back to user?
+
+	// Maintainer feedback on the bisection result (only for bisection jobs).
+	Verdict     BisectVerdict
+	VerdictUser string
+	VerdictTime time.Time
 }
 
 type JobType int
@@ -221,6 +241,68 @@ func (job *Job) isUnreliableBisect() bool {
 		job.Flags&BisectResultIgnore != 0
 }
 
+// EmailPrefs are email preferences set by a recipient of syzbot emails (see email_prefs.go).
+// The key is the canonical email address.
+type EmailPrefs struct {
+	Email    string
+	Redirect string     // send emails to this address instead
+	Muted    bool       // don't send any emails to this address
+	Digest   DigestMode // bundle bug events into periodic digests
+	Updated  time.Time
+}
+
+type DigestMode int
+
+const (
+	DigestImmediate DigestMode = iota
+	DigestHourly
+	DigestDaily
+)
+
+// EmailDigestItem is a bug event queued for a recipient that receives digests (see email_digest.go).
+type EmailDigestItem struct {
+	Recipient string
+	Mode      DigestMode
+	Time      time.Time
+	Title     string
+	Event     string
+	Link      string
+	CmdAddr   string // bug-specific address that accepts #syz commands
+}
+
+// BugMigration records that a bug was moved to another namespace (see migrateBugs in admin.go).
+type BugMigration struct {
+	Time    time.Time
+	Title   string
+	From    string // old namespace
+	To      string // new namespace
+	OldBug  string // old bug ID
+	NewBug  string // new bug ID
+	Crashes int
+	Jobs    int
+}
+
+// FeatureFlag overrides the default state of a dashboard feature (see features.go).
+// The key is the feature name.
+type FeatureFlag struct {
+	Name       string
+	Namespaces []string // namespaces where the feature is enabled ("*" means all)
+	Percent    int      // percent of bugs in other namespaces where the feature is enabled
+	Updated    time.Time
+}
+
+// QuarantinedEmail is an incoming email that was dropped before command processing
+// because it looks like spam or a mail loop (e.g. an autoresponder replying to our replies).
+type QuarantinedEmail struct {
+	Time      time.Time
+	From      string
+	BugID     string
+	Subject   string `datastore:",noindex"`
+	MessageID string `datastore:",noindex"`
+	Reason    string `datastore:",noindex"`
+	Body      string `datastore:",noindex"` // truncated to maxQuarantinedBody
+}
+
 // Text holds text blobs (crash logs, reports, reproducers, etc).
 type Text struct {
 	Namespace string
@@ -236,6 +318,7 @@ const (
 	textPatch        = "Patch"
 	textLog          = "Log"
 	textError        = "Error"
+	textCoverContext = "CoverContext"
 )
 
 const (
@@ -271,6 +354,28 @@ const (
 	BisectYes
 )
 
+// BisectVerdict is maintainer feedback on quality of a bisection result.
+type BisectVerdict int
+
+const (
+	BisectVerdictNone BisectVerdict = iota
+	BisectVerdictCorrect
+	BisectVerdictWrong
+)
+
+func (v BisectVerdict) String() string {
+	switch v {
+	case BisectVerdictNone:
+		return ""
+	case BisectVerdictCorrect:
+		return "correct"
+	case BisectVerdictWrong:
+		return "wrong"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(v))
+	}
+}
+
 func mgrKey(c context.Context, ns, name string) *db.Key {
 	return db.NewKey(c, "Manager", fmt.Sprintf("%v-%v", ns, name), 0, nil)
 }
@@ -461,6 +566,19 @@ func (bug *Bug) updateCommits(commits []string, now time.Time) {
 	bug.PatchedOn = nil
 }
 
+// updateReleases extends the range of releases the bug was observed on with the given release.
+func (bug *Bug) updateReleases(release string) {
+	if release == "" {
+		return
+	}
+	if bug.FirstRelease == "" || vcs.ReleaseTagLess(release, bug.FirstRelease) {
+		bug.FirstRelease = release
+	}
+	if bug.LastRelease == "" || vcs.ReleaseTagLess(bug.LastRelease, release) {
+		bug.LastRelease = release
+	}
+}
+
 func (bug *Bug) getCommitInfo(i int) Commit {
 	if i < len(bug.CommitInfo) {
 		return bug.CommitInfo[i]
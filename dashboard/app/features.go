@@ -0,0 +1,142 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/context"
+	db "google.golang.org/appengine/datastore"
+	"google.golang.org/appengine/log"
+)
+
+// Feature flags allow staged rollout of risky dashboard behavior.
+// Every feature has a default state that is used while there is no FeatureFlag entity for it.
+// Admins can override the state on the admin page: enable the feature for a list of namespaces
+// and/or for a percentage of bugs in the remaining namespaces.
+
+const (
+	// Obsolete bugs with reproducers if fix bisection is disabled on all managers they happened on.
+	featureObsoleteNoFixBisection = "obsolete_no_fix_bisection"
+	// Send "bad fix commit" notifications for bugs whose fixing commits never showed up.
+	featureBadCommitNotif = "bad_commit_notif"
+	// Upstream moderated bugs that are filtered out by the reporting filter.
+	featureUpstreamSkipped = "upstream_skipped"
+)
+
+var featureDefaults = map[string]bool{
+	featureObsoleteNoFixBisection: false,
+	featureBadCommitNotif:         true,
+	featureUpstreamSkipped:        true,
+}
+
+type featureFlags map[string]*FeatureFlag
+
+func loadFeatureFlags(c context.Context) (featureFlags, error) {
+	var flags []*FeatureFlag
+	if _, err := db.NewQuery("FeatureFlag").GetAll(c, &flags); err != nil {
+		return nil, fmt.Errorf("failed to load feature flags: %v", err)
+	}
+	res := make(featureFlags)
+	for _, flag := range flags {
+		res[flag.Name] = flag
+	}
+	return res, nil
+}
+
+// enabled says if the feature is enabled for the bug.
+func (flags featureFlags) enabled(name string, bug *Bug) bool {
+	def, ok := featureDefaults[name]
+	if !ok {
+		panic(fmt.Sprintf("unknown feature %q", name))
+	}
+	flag := flags[name]
+	if flag == nil {
+		return def
+	}
+	for _, ns := range flag.Namespaces {
+		if ns == bug.Namespace || ns == "*" {
+			return true
+		}
+	}
+	if flag.Percent <= 0 {
+		return false
+	}
+	// Use a stable hash so that the same bugs stay in the enabled group
+	// while the percentage is increased.
+	hash := fnv.New32a()
+	hash.Write([]byte(name + "|" + bug.Namespace + "|" + bug.Title))
+	return int(hash.Sum32()%100) < flag.Percent
+}
+
+type uiFeatureFlag struct {
+	Name       string
+	Default    bool
+	Overridden bool
+	Namespaces string
+	Percent    int
+}
+
+func uiFeatureFlags(flags featureFlags) []*uiFeatureFlag {
+	var res []*uiFeatureFlag
+	for name, def := range featureDefaults {
+		ui := &uiFeatureFlag{
+			Name:    name,
+			Default: def,
+		}
+		if flag := flags[name]; flag != nil {
+			ui.Overridden = true
+			ui.Namespaces = strings.Join(flag.Namespaces, ",")
+			ui.Percent = flag.Percent
+		}
+		res = append(res, ui)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// handleFeatureFlagUpdate handles the feature_flag admin action.
+// Parameters: name of the feature, namespaces (comma-separated, "*" for all),
+// percent of bugs in other namespaces and reset (deletes the override).
+func handleFeatureFlagUpdate(c context.Context, r *http.Request) error {
+	name := r.FormValue("name")
+	if _, ok := featureDefaults[name]; !ok {
+		return fmt.Errorf("unknown feature %q", name)
+	}
+	key := db.NewKey(c, "FeatureFlag", name, 0, nil)
+	if r.FormValue("reset") != "" {
+		log.Infof(c, "resetting feature %v", name)
+		return db.Delete(c, key)
+	}
+	flag := &FeatureFlag{
+		Name:    name,
+		Updated: timeNow(c),
+	}
+	for _, ns := range strings.Split(r.FormValue("namespaces"), ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+		if ns != "*" && config.Namespaces[ns] == nil {
+			return fmt.Errorf("unknown namespace %q", ns)
+		}
+		flag.Namespaces = append(flag.Namespaces, ns)
+	}
+	if percent := r.FormValue("percent"); percent != "" {
+		var err error
+		if flag.Percent, err = strconv.Atoi(percent); err != nil || flag.Percent < 0 || flag.Percent > 100 {
+			return fmt.Errorf("bad percent %q", percent)
+		}
+	}
+	log.Infof(c, "setting feature %v: namespaces %q, percent %v", name, flag.Namespaces, flag.Percent)
+	_, err := db.Put(c, key, flag)
+	return err
+}
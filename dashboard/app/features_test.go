@@ -0,0 +1,48 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	bug := &Bug{Namespace: "test1", Title: "title"}
+	flags := make(featureFlags)
+	if flags.enabled(featureObsoleteNoFixBisection, bug) || !flags.enabled(featureBadCommitNotif, bug) {
+		t.Fatalf("defaults are not respected")
+	}
+	flags[featureBadCommitNotif] = &FeatureFlag{Name: featureBadCommitNotif}
+	if flags.enabled(featureBadCommitNotif, bug) {
+		t.Fatalf("override is not respected")
+	}
+	flags[featureObsoleteNoFixBisection] = &FeatureFlag{Namespaces: []string{"test2", "test1"}}
+	if !flags.enabled(featureObsoleteNoFixBisection, bug) {
+		t.Fatalf("namespace override is not respected")
+	}
+	flags[featureObsoleteNoFixBisection] = &FeatureFlag{Namespaces: []string{"*"}}
+	if !flags.enabled(featureObsoleteNoFixBisection, bug) {
+		t.Fatalf("* override is not respected")
+	}
+	enabled := func(percent int) int {
+		flags[featureObsoleteNoFixBisection] = &FeatureFlag{Percent: percent}
+		count := 0
+		for i := 0; i < 1000; i++ {
+			if flags.enabled(featureObsoleteNoFixBisection, &Bug{Namespace: "test1", Title: fmt.Sprint(i)}) {
+				count++
+			}
+		}
+		return count
+	}
+	if n := enabled(0); n != 0 {
+		t.Fatalf("0%%: enabled for %v bugs", n)
+	}
+	if n := enabled(100); n != 1000 {
+		t.Fatalf("100%%: enabled for %v bugs", n)
+	}
+	if n := enabled(30); n < 200 || n > 400 {
+		t.Fatalf("30%%: enabled for %v bugs", n)
+	}
+}
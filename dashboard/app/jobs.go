@@ -4,6 +4,7 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -76,25 +77,9 @@ func handleTestRequest(c context.Context, bugID, user, extID, link, patch, repo,
 
 func addTestJob(c context.Context, bug *Bug, bugKey *db.Key, bugReporting *BugReporting,
 	user, extID, link, patch, repo, branch string, jobCC []string, now time.Time) (string, error) {
-	crash, crashKey, err := findCrashForBug(c, bug)
-	if err != nil {
-		return "", err
-	}
-	if reason := checkTestJob(c, bug, bugReporting, crash, repo, branch); reason != "" {
-		return reason, nil
-	}
-
-	manager := crash.Manager
-	for _, ns := range config.Namespaces {
-		if mgr, ok := ns.Managers[manager]; ok {
-			if mgr.RestrictedTestingRepo != "" && repo != mgr.RestrictedTestingRepo {
-				return mgr.RestrictedTestingReason, nil
-			}
-			if mgr.Decommissioned {
-				manager = mgr.DelegatedTo
-			}
-			break
-		}
+	_, crashKey, manager, reason, err := prepareTestJob(c, bug, bugReporting, repo, branch)
+	if reason != "" || err != nil {
+		return reason, err
 	}
 
 	patchID, err := putText(c, bug.Namespace, textPatch, []byte(patch), false)
@@ -164,6 +149,111 @@ func addTestJob(c context.Context, bug *Bug, bugKey *db.Key, bugReporting *BugRe
 	return "", nil
 }
 
+// prepareTestJob returns the crash to test and the manager to run the test job on,
+// or a reason why the test request can't be satisfied.
+func prepareTestJob(c context.Context, bug *Bug, bugReporting *BugReporting, repo, branch string) (
+	crash *Crash, crashKey *db.Key, manager, reason string, err error) {
+	crash, crashKey, err = findCrashForBug(c, bug)
+	if err != nil {
+		return
+	}
+	if reason = checkTestJob(c, bug, bugReporting, crash, repo, branch); reason != "" {
+		return
+	}
+	manager = crash.Manager
+	for _, ns := range config.Namespaces {
+		if mgr, ok := ns.Managers[manager]; ok {
+			if mgr.RestrictedTestingRepo != "" && repo != mgr.RestrictedTestingRepo {
+				reason = mgr.RestrictedTestingReason
+				return
+			}
+			if mgr.Decommissioned {
+				manager = mgr.DelegatedTo
+			}
+			break
+		}
+	}
+	return
+}
+
+// dryRunTestRequest validates a test request the same way handleTestRequest does,
+// but instead of creating a job returns a description of what would be done.
+func dryRunTestRequest(c context.Context, bugID, user, patch, repo, branch string) string {
+	log.Infof(c, "dry run test request: bug=%q user=%q patch=%v, repo=%q branch=%q",
+		bugID, user, len(patch), repo, branch)
+	for _, blocked := range config.EmailBlocklist {
+		if user == blocked {
+			log.Errorf(c, "test request from blocked user: %v", user)
+			return ""
+		}
+	}
+	bug, _, err := findBugByReportingID(c, bugID)
+	if err != nil {
+		log.Errorf(c, "can't find bug: %v", err)
+		myEmail, _ := email.AddAddrContext(ownEmail(c), "hash")
+		return fmt.Sprintf("can't find the associated bug (do you have %v in To/CC?)", myEmail)
+	}
+	bugReporting, _ := bugReportingByID(bug, bugID)
+	crash, _, manager, reason, err := prepareTestJob(c, bug, bugReporting, repo, branch)
+	if err != nil {
+		log.Errorf(c, "dry run test request failed: %v", err)
+		return internalError
+	}
+	if reason != "" {
+		return reason
+	}
+	patchDesc := "no patch attached, the tree would be tested as is"
+	if patch != "" {
+		files := patchFiles(patch)
+		if len(files) == 0 {
+			return "The attached patch does not modify any files. I cannot apply it."
+		}
+		patchDesc = fmt.Sprintf("%v changed file(s): %v", len(files), strings.Join(files, ", "))
+	}
+	reproType := "syz"
+	if crash.ReproC != 0 {
+		reproType = "C"
+	}
+	return fmt.Sprintf("This is a dry run, no testing job was created.\n\n"+
+		"Without %v I would test the %v reproducer of %q on:\n"+
+		"git tree: %v %v\n"+
+		"manager:  %v\n"+
+		"patch:    %v\n\n"+
+		"Note: reachability of the git tree and applicability of the patch\n"+
+		"are checked only when the testing job runs.",
+		email.DryRunFlag, reproType, bug.displayTitle(), repo, branch, manager, patchDesc)
+}
+
+// patchFiles returns names of files modified by the unified diff patch.
+func patchFiles(patch string) []string {
+	var files []string
+	oldFile := ""
+	for _, line := range strings.Split(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- "):
+			oldFile = patchFileName(line, "a/")
+		case strings.HasPrefix(line, "+++ "):
+			file := patchFileName(line, "b/")
+			if file == "/dev/null" {
+				// The file is deleted.
+				file = oldFile
+			}
+			if file != "" && file != "/dev/null" {
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+func patchFileName(line, prefix string) string {
+	file := strings.TrimSpace(line[4:])
+	if tab := strings.IndexByte(file, '\t'); tab != -1 {
+		file = file[:tab] // strip timestamp
+	}
+	return strings.TrimPrefix(file, prefix)
+}
+
 func checkTestJob(c context.Context, bug *Bug, bugReporting *BugReporting, crash *Crash,
 	repo, branch string) string {
 	switch {
@@ -492,6 +582,7 @@ func doneJob(c context.Context, req *dashapi.JobDoneReq) error {
 			cc := email.MergeEmailLists(com.CC,
 				GetEmails(com.Recipients, dashapi.To),
 				GetEmails(com.Recipients, dashapi.Cc))
+			patch := truncateCulpritPatch(com.Patch)
 			job.Commits = append(job.Commits, Commit{
 				Hash:       com.Hash,
 				Title:      com.Title,
@@ -499,6 +590,8 @@ func doneJob(c context.Context, req *dashapi.JobDoneReq) error {
 				AuthorName: com.AuthorName,
 				CC:         strings.Join(sanitizeCC(c, cc), "|"),
 				Date:       com.Date,
+				Diffstat:   com.Diffstat,
+				Patch:      string(patch),
 			})
 		}
 		job.BuildID = req.Build.ID
@@ -560,6 +653,31 @@ func updateBugBisection(c context.Context, job *Job, jobKey *db.Key, req *dashap
 	return nil
 }
 
+// setBisectVerdict records maintainer feedback on the bisection result of the given job.
+func setBisectVerdict(c context.Context, jobKey *db.Key, verdict BisectVerdict, user string) error {
+	now := timeNow(c)
+	tx := func(c context.Context) error {
+		job := new(Job)
+		if err := db.Get(c, jobKey, job); err != nil {
+			return fmt.Errorf("failed to get job: %v", err)
+		}
+		if job.Type != JobBisectCause && job.Type != JobBisectFix {
+			return fmt.Errorf("job %v is not a bisection", extJobID(jobKey))
+		}
+		if job.Finished.IsZero() || job.Error != 0 || len(job.Commits) == 0 {
+			return fmt.Errorf("job %v has no bisection result", extJobID(jobKey))
+		}
+		job.Verdict = verdict
+		job.VerdictUser = user
+		job.VerdictTime = now
+		if _, err := db.Put(c, jobKey, job); err != nil {
+			return fmt.Errorf("failed to put job: %v", err)
+		}
+		return nil
+	}
+	return db.RunInTransaction(c, tx, nil)
+}
+
 // TODO: this is temporal for gradual bisection rollout.
 // Notify only about successful cause bisection for now.
 // For now we only enable this in tests.
@@ -708,6 +826,21 @@ func createBugReportForJob(c context.Context, job *Job, jobKey *db.Key, config i
 	return rep, nil
 }
 
+// truncateCulpritPatch guards against syz-ci sending patches larger than agreed on.
+// The patch is cut at a line boundary so that it's still readable.
+func truncateCulpritPatch(patch []byte) []byte {
+	if len(patch) <= dashapi.MaxCulpritPatch {
+		return patch
+	}
+	res := append([]byte{}, patch[:dashapi.MaxCulpritPatch]...)
+	if pos := bytes.LastIndexByte(res, '\n'); pos != -1 {
+		res = res[:pos+1]
+	} else {
+		res = append(res, '\n')
+	}
+	return append(res, "[truncated]\n"...)
+}
+
 func bisectFromJob(c context.Context, rep *dashapi.BugReport, job *Job) *dashapi.BisectResult {
 	bisect := &dashapi.BisectResult{
 		LogLink:         externalLink(c, textLog, job.Log),
@@ -722,6 +855,7 @@ func bisectFromJob(c context.Context, rep *dashapi.BugReport, job *Job) *dashapi
 			Author:     com.Author,
 			AuthorName: com.AuthorName,
 			Date:       com.Date,
+			Diffstat:   com.Diffstat,
 		})
 	}
 	if len(bisect.Commits) == 1 {
@@ -6,6 +6,7 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -331,6 +332,69 @@ Note: testing is done by a robot and is best-effort only.
 	c.expectEQ(pollResp.ID, "")
 }
 
+func TestJobDryRun(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client2.UploadBuild(build)
+
+	crash := testCrash(build, 1)
+	crash.ReproSyz = []byte("repro syz")
+	c.client2.ReportCrash(crash)
+	c.client2.pollAndFailBisectJob(build.Manager)
+	sender := c.pollEmailBug().Sender
+
+	patch := `--- a/mm/kasan/kasan.c
++++ b/mm/kasan/kasan.c
+-       current->kasan_depth++;
++       current->kasan_depth--;
+`
+	c.incomingEmail(sender, "#syz test: --dry-run git://git.git/git.git kernel-branch\n"+patch,
+		EmailOptMessageID(1))
+	body := c.pollEmailBug().Body
+	c.expectTrue(strings.Contains(body, "This is a dry run, no testing job was created."))
+	c.expectTrue(strings.Contains(body, "git tree: git://git.git/git.git kernel-branch"))
+	c.expectTrue(strings.Contains(body, "patch:    1 changed file(s): mm/kasan/kasan.c"))
+	pollResp := c.client2.pollJobs(build.Manager)
+	c.expectEQ(pollResp.ID, "")
+
+	// Validation errors are the same as for real test requests.
+	c.incomingEmail(sender, "#syz test --dry-run repo branch", EmailOptMessageID(2))
+	body = c.pollEmailBug().Body
+	c.expectTrue(strings.Contains(body, "does not look like a valid git repo"))
+
+	c.incomingEmail(sender, "#syz test --dry-run git://git.git/git.git", EmailOptMessageID(3))
+	body = c.pollEmailBug().Body
+	c.expectTrue(strings.Contains(body, "want 2 args"))
+}
+
+func TestPatchFiles(t *testing.T) {
+	patch := `diff --git a/foo.c b/foo.c
+--- a/foo.c
++++ b/foo.c
+@@ -1 +1 @@
+-a
++b
+--- a/dir/deleted.h	2020-01-01 00:00:00
++++ /dev/null
+@@ -1 +0,0 @@
+-a
+--- /dev/null
++++ b/dir/new.c
+@@ -0,0 +1 @@
++a
+`
+	files := patchFiles(patch)
+	want := []string{"foo.c", "dir/deleted.h", "dir/new.c"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("got %q, want %q", files, want)
+	}
+	if files := patchFiles("just some text"); len(files) != 0 {
+		t.Fatalf("got files %q for non-patch", files)
+	}
+}
+
 // Test on a restricted manager.
 func TestJobRestrictedManager(t *testing.T) {
 	c := NewCtx(t)
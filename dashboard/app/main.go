@@ -20,6 +20,7 @@ import (
 	db "google.golang.org/appengine/datastore"
 	"google.golang.org/appengine/log"
 	"google.golang.org/appengine/memcache"
+	"google.golang.org/appengine/user"
 )
 
 // This file contains web UI http handlers.
@@ -29,6 +30,8 @@ func initHTTPHandlers() {
 	http.Handle("/bug", handlerWrapper(handleBug))
 	http.Handle("/text", handlerWrapper(handleText))
 	http.Handle("/admin", handlerWrapper(handleAdmin))
+	http.Handle("/email_prefs", handlerWrapper(handleEmailPrefs))
+	http.Handle("/capacity", handlerWrapper(handleCapacity))
 	http.Handle("/x/.config", handlerWrapper(handleTextX(textKernelConfig)))
 	http.Handle("/x/log.txt", handlerWrapper(handleTextX(textCrashLog)))
 	http.Handle("/x/report.txt", handlerWrapper(handleTextX(textCrashReport)))
@@ -63,9 +66,23 @@ type uiAdminPage struct {
 	Log           []byte
 	Managers      []*uiManager
 	Jobs          *uiJobList
+	BisectStats   []*uiBisectStats
+	Quarantine    []*QuarantinedEmail
+	FeatureFlags  []*uiFeatureFlag
 	MemcacheStats *memcache.Statistics
 }
 
+// uiBisectStats holds aggregated maintainer verdicts on bisection results
+// for a single bisection configuration (namespace/manager/type).
+type uiBisectStats struct {
+	Namespace string
+	Manager   string
+	Type      JobType
+	Correct   int
+	Wrong     int
+	Precision int // percent
+}
+
 type uiManager struct {
 	Now                   time.Time
 	Namespace             string
@@ -100,12 +117,14 @@ type uiBuild struct {
 }
 
 type uiCommit struct {
-	Hash   string
-	Title  string
-	Link   string
-	Author string
-	CC     []string
-	Date   time.Time
+	Hash     string
+	Title    string
+	Link     string
+	Author   string
+	CC       []string
+	Date     time.Time
+	Diffstat string
+	Patch    string
 }
 
 type uiBugPage struct {
@@ -118,6 +137,7 @@ type uiBugPage struct {
 	Dups          *uiBugGroup
 	Similar       *uiBugGroup
 	SampleReport  []byte
+	CoverContext  []byte
 	Crashes       *uiCrashTable
 	FixBisections *uiCrashTable
 	TestPatchJobs *uiJobList
@@ -162,6 +182,8 @@ type uiBug struct {
 	PatchedOn       []string
 	MissingOn       []string
 	NumManagers     int
+	FirstRelease    string
+	LastRelease     string
 }
 
 type uiCrash struct {
@@ -208,6 +230,11 @@ type uiJob struct {
 	Commits         []*uiCommit // for inconclusive bisection
 	Crash           *uiCrash
 	Reported        bool
+	ID              string
+	Verdict         string
+	VerdictUser     string
+	ShowVerdict     bool           // show controls to set the verdict
+	VerdictStats    *uiBisectStats // verdicts on other bisections of the same manager
 }
 
 // handleMain serves main page.
@@ -289,6 +316,14 @@ func handleAdmin(c context.Context, w http.ResponseWriter, r *http.Request) erro
 		if err := memcache.Flush(c); err != nil {
 			return fmt.Errorf("failed to flush memcache: %v", err)
 		}
+	case "bisect_verdict":
+		if err := handleBisectVerdict(c, r); err != nil {
+			return err
+		}
+	case "feature_flag":
+		if err := handleFeatureFlagUpdate(c, r); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unknown action %q", action)
 	}
@@ -312,16 +347,124 @@ func handleAdmin(c context.Context, w http.ResponseWriter, r *http.Request) erro
 	if err != nil {
 		return err
 	}
+	bisectStats, err := loadBisectStats(c)
+	if err != nil {
+		return err
+	}
+	quarantine, err := loadQuarantinedEmails(c)
+	if err != nil {
+		return err
+	}
+	flags, err := loadFeatureFlags(c)
+	if err != nil {
+		return err
+	}
 	data := &uiAdminPage{
 		Header:        hdr,
 		Log:           errorLog,
 		Managers:      managers,
 		Jobs:          &uiJobList{Jobs: jobs},
+		BisectStats:   bisectStats,
+		Quarantine:    quarantine,
+		FeatureFlags:  uiFeatureFlags(flags),
 		MemcacheStats: memcacheStats,
 	}
 	return serveTemplate(w, "admin.html", data)
 }
 
+func handleBisectVerdict(c context.Context, r *http.Request) error {
+	jobKey, err := jobID2Key(c, r.FormValue("job"))
+	if err != nil {
+		return err
+	}
+	var verdict BisectVerdict
+	switch v := r.FormValue("verdict"); v {
+	case "correct":
+		verdict = BisectVerdictCorrect
+	case "wrong":
+		verdict = BisectVerdictWrong
+	case "none":
+		verdict = BisectVerdictNone
+	default:
+		return fmt.Errorf("unknown bisection verdict %q", v)
+	}
+	userEmail := ""
+	if u := user.Current(c); u != nil {
+		userEmail = u.Email
+	}
+	return setBisectVerdict(c, jobKey, verdict, userEmail)
+}
+
+func loadBisectStats(c context.Context) ([]*uiBisectStats, error) {
+	var jobs []*Job
+	if _, err := db.NewQuery("Job").
+		Filter("Verdict>", BisectVerdictNone).
+		GetAll(c, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %v", err)
+	}
+	return aggregateBisectStats(jobs), nil
+}
+
+// loadManagerBisectStats returns verdict stats for bisections of the given type on the given manager,
+// or nil if there are no verdicts yet.
+func loadManagerBisectStats(c context.Context, ns, manager string, typ JobType) (*uiBisectStats, error) {
+	var jobs []*Job
+	if _, err := db.NewQuery("Job").
+		Filter("Namespace=", ns).
+		Filter("Manager=", manager).
+		Filter("Type=", typ).
+		Filter("Verdict>", BisectVerdictNone).
+		GetAll(c, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %v", err)
+	}
+	stats := aggregateBisectStats(jobs)
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	return stats[0], nil
+}
+
+func aggregateBisectStats(jobs []*Job) []*uiBisectStats {
+	type statsKey struct {
+		ns      string
+		manager string
+		typ     JobType
+	}
+	stats := make(map[statsKey]*uiBisectStats)
+	var res []*uiBisectStats
+	for _, job := range jobs {
+		key := statsKey{job.Namespace, job.Manager, job.Type}
+		st := stats[key]
+		if st == nil {
+			st = &uiBisectStats{
+				Namespace: job.Namespace,
+				Manager:   job.Manager,
+				Type:      job.Type,
+			}
+			stats[key] = st
+			res = append(res, st)
+		}
+		if job.Verdict == BisectVerdictCorrect {
+			st.Correct++
+		} else {
+			st.Wrong++
+		}
+	}
+	for _, st := range res {
+		st.Precision = st.Correct * 100 / (st.Correct + st.Wrong)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Namespace != res[j].Namespace {
+			return res[i].Namespace < res[j].Namespace
+		}
+		if res[i].Manager != res[j].Manager {
+			return res[i].Manager < res[j].Manager
+		}
+		return res[i].Type < res[j].Type
+	})
+	return res
+}
+
 // handleBug serves page about a single bug (which is passed in id argument).
 func handleBug(c context.Context, w http.ResponseWriter, r *http.Request) error {
 	bug, err := findBugByID(c, r)
@@ -359,7 +502,7 @@ func handleBug(c context.Context, w http.ResponseWriter, r *http.Request) error
 		}
 	}
 	uiBug := createUIBug(c, bug, state, managers)
-	crashes, sampleReport, err := loadCrashesForBug(c, bug)
+	crashes, sampleReport, coverContext, err := loadCrashesForBug(c, bug)
 	if err != nil {
 		return err
 	}
@@ -395,6 +538,18 @@ func handleBug(c context.Context, w http.ResponseWriter, r *http.Request) error
 			return err
 		}
 	}
+	for _, job := range []*uiJob{bisectCause, bisectFix} {
+		if job == nil {
+			continue
+		}
+		if hdr.Admin && job.ErrorLink == "" && (job.Commit != nil || len(job.Commits) != 0) {
+			job.ShowVerdict = true
+		}
+		job.VerdictStats, err = loadManagerBisectStats(c, bug.Namespace, job.Manager, job.Type)
+		if err != nil {
+			return err
+		}
+	}
 	testPatchJobs, err := loadTestPatchJobs(c, bug)
 	if err != nil {
 		return err
@@ -409,6 +564,7 @@ func handleBug(c context.Context, w http.ResponseWriter, r *http.Request) error
 		Dups:         dups,
 		Similar:      similar,
 		SampleReport: sampleReport,
+		CoverContext: coverContext,
 		Crashes:      crashesTable,
 		TestPatchJobs: &uiJobList{
 			PerBug: true,
@@ -806,6 +962,8 @@ func createUIBug(c context.Context, bug *Bug, state *ReportingState, managers []
 		NumCrashes:      bug.NumCrashes,
 		FirstTime:       bug.FirstTime,
 		LastTime:        bug.LastTime,
+		FirstRelease:    bug.FirstRelease,
+		LastRelease:     bug.LastRelease,
 		ReportedTime:    reported,
 		ClosedTime:      bug.Closed,
 		ReproLevel:      bug.ReproLevel,
@@ -864,31 +1022,41 @@ func updateBugBadness(c context.Context, bug *uiBug) {
 	bug.NumCrashesBad = bug.NumCrashes >= 10000 && timeNow(c).Sub(bug.LastTime) < 24*time.Hour
 }
 
-func loadCrashesForBug(c context.Context, bug *Bug) ([]*uiCrash, []byte, error) {
+// loadCrashesForBug returns crashes of the bug, a sample crash report
+// and coverage context of the most relevant crash that has it.
+func loadCrashesForBug(c context.Context, bug *Bug) ([]*uiCrash, []byte, []byte, error) {
 	bugKey := bug.key(c)
 	// We can have more than maxCrashes crashes, if we have lots of reproducers.
 	crashes, _, err := queryCrashesForBug(c, bugKey, 2*maxCrashes+200)
 	if err != nil || len(crashes) == 0 {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	builds := make(map[string]*Build)
 	var results []*uiCrash
+	var coverContext int64
 	for _, crash := range crashes {
 		build := builds[crash.BuildID]
 		if build == nil {
 			build, err = loadBuild(c, bug.Namespace, crash.BuildID)
 			if err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 			builds[crash.BuildID] = build
 		}
 		results = append(results, makeUICrash(crash, build))
+		if coverContext == 0 {
+			coverContext = crash.CoverContext
+		}
 	}
 	sampleReport, _, err := getText(c, textCrashReport, crashes[0].Report)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
+	}
+	coverContextText, _, err := getText(c, textCoverContext, coverContext)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	return results, sampleReport, nil
+	return results, sampleReport, coverContextText, nil
 }
 
 func loadFixBisectionsForBug(c context.Context, bug *Bug) ([]*uiCrash, error) {
@@ -1109,6 +1277,9 @@ func makeUIJob(job *Job, jobKey *db.Key, bug *Bug, crash *Crash, build *Build) *
 		LogLink:         textLink(textLog, job.Log),
 		ErrorLink:       textLink(textError, job.Error),
 		Reported:        job.Reported,
+		ID:              extJobID(jobKey),
+		Verdict:         job.Verdict.String(),
+		VerdictUser:     job.VerdictUser,
 	}
 	if !job.Finished.IsZero() {
 		ui.Duration = job.Finished.Sub(job.Started)
@@ -1127,6 +1298,9 @@ func makeUIJob(job *Job, jobKey *db.Key, bug *Bug, crash *Crash, build *Build) *
 			Author: fmt.Sprintf("%v <%v>", com.AuthorName, com.Author),
 			CC:     strings.Split(com.CC, "|"),
 			Date:   com.Date,
+			// Stored only for conclusive bisections.
+			Diffstat: com.Diffstat,
+			Patch:    com.Patch,
 		})
 	}
 	if len(ui.Commits) == 1 {
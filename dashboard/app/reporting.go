@@ -154,12 +154,17 @@ func reportingPollNotifications(c context.Context, typ string) []*dashapi.BugNot
 		return nil
 	}
 	log.Infof(c, "fetched %v bugs", len(bugs))
+	flags, err := loadFeatureFlags(c)
+	if err != nil {
+		log.Errorf(c, "%v", err)
+		return nil
+	}
 	var notifs []*dashapi.BugNotification
 	for _, bug := range bugs {
 		if config.Namespaces[bug.Namespace].Decommissioned {
 			continue
 		}
-		notif, err := handleReportNotif(c, typ, bug)
+		notif, err := handleReportNotif(c, typ, bug, flags)
 		if err != nil {
 			log.Errorf(c, "%v: failed to create bug notif %v: %v", bug.Namespace, bug.Title, err)
 			continue
@@ -175,7 +180,8 @@ func reportingPollNotifications(c context.Context, typ string) []*dashapi.BugNot
 	return notifs
 }
 
-func handleReportNotif(c context.Context, typ string, bug *Bug) (*dashapi.BugNotification, error) {
+func handleReportNotif(c context.Context, typ string, bug *Bug, flags featureFlags) (
+	*dashapi.BugNotification, error) {
 	reporting, bugReporting, _, _, err := currentReporting(c, bug)
 	if err != nil || reporting == nil {
 		return nil, nil
@@ -198,12 +204,13 @@ func handleReportNotif(c context.Context, typ string, bug *Bug) (*dashapi.BugNot
 	if reporting.moderation &&
 		len(bug.Commits) == 0 &&
 		bugReporting.OnHold.IsZero() &&
-		reporting.Filter(bug) == FilterSkip {
+		reporting.Filter(bug) == FilterSkip &&
+		flags.enabled(featureUpstreamSkipped, bug) {
 		log.Infof(c, "%v: upstreaming (skip): %v", bug.Namespace, bug.Title)
 		return createNotification(c, dashapi.BugNotifUpstream, true, "", bug, reporting, bugReporting)
 	}
 	if len(bug.Commits) == 0 &&
-		bug.wontBeFixBisected() &&
+		bug.wontBeFixBisected(flags) &&
 		timeSince(c, bug.LastActivity) > notifyResendPeriod &&
 		timeSince(c, bug.LastTime) > bug.obsoletePeriod() {
 		log.Infof(c, "%v: obsoleting: %v", bug.Namespace, bug.Title)
@@ -212,7 +219,8 @@ func handleReportNotif(c context.Context, typ string, bug *Bug) (*dashapi.BugNot
 	if len(bug.Commits) > 0 &&
 		len(bug.PatchedOn) == 0 &&
 		timeSince(c, bug.LastActivity) > notifyResendPeriod &&
-		timeSince(c, bug.FixTime) > notifyAboutBadCommitPeriod {
+		timeSince(c, bug.FixTime) > notifyAboutBadCommitPeriod &&
+		flags.enabled(featureBadCommitNotif, bug) {
 		log.Infof(c, "%v: bad fix commit: %v", bug.Namespace, bug.Title)
 		commits := strings.Join(bug.Commits, "\n")
 		return createNotification(c, dashapi.BugNotifBadCommit, true, commits, bug, reporting, bugReporting)
@@ -223,14 +231,14 @@ func handleReportNotif(c context.Context, typ string, bug *Bug) (*dashapi.BugNot
 // TODO: this is what we would like to do, but we need to figure out
 // KMSAN story: we don't do fix bisection on it (rebased),
 // do we want to close all old KMSAN bugs with repros?
-// For now we only enable this in tests.
+// For now this is enabled in tests and rolled out with the obsolete_no_fix_bisection feature.
 var obsoleteWhatWontBeFixBisected = false
 
-func (bug *Bug) wontBeFixBisected() bool {
+func (bug *Bug) wontBeFixBisected(flags featureFlags) bool {
 	if bug.ReproLevel == ReproLevelNone {
 		return true
 	}
-	if obsoleteWhatWontBeFixBisected {
+	if obsoleteWhatWontBeFixBisected || flags.enabled(featureObsoleteNoFixBisection, bug) {
 		cfg := config.Namespaces[bug.Namespace]
 		for _, mgr := range bug.HappenedOn {
 			if !cfg.Managers[mgr].FixBisectionDisabled {
@@ -429,6 +437,8 @@ func createBugReport(c context.Context, bug *Bug, crash *Crash, crashKey *db.Key
 		CrashID:      crashKey.IntID(),
 		NumCrashes:   bug.NumCrashes,
 		HappenedOn:   managersToRepos(c, bug.Namespace, bug.HappenedOn),
+		FirstRelease: bug.FirstRelease,
+		LastRelease:  bug.LastRelease,
 	}
 	if bugReporting.CC != "" {
 		rep.CC = append(rep.CC, strings.Split(bugReporting.CC, "|")...)
@@ -17,11 +17,14 @@ import (
 
 	"github.com/google/syzkaller/dashboard/dashapi"
 	"github.com/google/syzkaller/pkg/email"
+	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/pkg/html"
 	"golang.org/x/net/context"
 	"google.golang.org/appengine"
+	db "google.golang.org/appengine/datastore"
 	"google.golang.org/appengine/log"
 	aemail "google.golang.org/appengine/mail"
+	"google.golang.org/appengine/memcache"
 )
 
 // Email reporting interface.
@@ -104,6 +107,11 @@ func handleEmailPoll(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if err := emailPollDigests(c); err != nil {
+		log.Errorf(c, "digest poll failed: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	w.Write([]byte("OK"))
 }
 
@@ -154,10 +162,11 @@ func emailPollNotifications(c context.Context) error {
 }
 
 func emailSendBugNotif(c context.Context, notif *dashapi.BugNotification) error {
-	status, body := dashapi.BugStatusOpen, ""
+	status, body, event := dashapi.BugStatusOpen, "", ""
 	switch notif.Type {
 	case dashapi.BugNotifUpstream:
 		body = "Sending this report upstream."
+		event = "sent upstream"
 		status = dashapi.BugStatusUpstream
 	case dashapi.BugNotifBadCommit:
 		days := int(notifyAboutBadCommitPeriod / time.Hour / 24)
@@ -168,10 +177,12 @@ func emailSendBugNotif(c context.Context, notif *dashapi.BugNotification) error
 			"Until then the bug is still considered open and\n"+
 			"new crashes with the same signature are ignored.\n",
 			notif.Text, days)
+		event = "fixing commit is not found in any tested tree"
 	case dashapi.BugNotifObsoleted:
 		body = "Auto-closing this bug as obsolete.\n" +
 			"Crashes did not happen for a while, no reproducer and no activity."
 		status = dashapi.BugStatusInvalid
+		event = "auto-closed as obsolete"
 	default:
 		return fmt.Errorf("bad notification type %v", notif.Type)
 	}
@@ -183,14 +194,24 @@ func emailSendBugNotif(c context.Context, notif *dashapi.BugNotification) error
 	if cfg.MailMaintainers && notif.Public {
 		to = email.MergeEmailLists(to, notif.Maintainers, cfg.DefaultMaintainers)
 	}
+	to, digests, err := applyEmailPrefs(c, to, cfg.Email, notif.Public)
+	if err != nil {
+		return err
+	}
 	from, err := email.AddAddrContext(fromAddr(c), notif.ID)
 	if err != nil {
 		return err
 	}
-	log.Infof(c, "sending notif %v for %q to %q: %v", notif.Type, notif.Title, to, body)
-	if err := sendMailText(c, notif.Title, from, to, notif.ExtID, nil, body); err != nil {
+	link := fmt.Sprintf("%v/bug?extid=%v", appURL(c), notif.ID)
+	if err := queueDigestItems(c, digests, notif.Title, event, link, commandsAddr(c, notif.ID)); err != nil {
 		return err
 	}
+	if len(to) != 0 {
+		log.Infof(c, "sending notif %v for %q to %q: %v", notif.Type, notif.Title, to, body)
+		if err := sendMailText(c, notif.Title, from, to, notif.ExtID, nil, body); err != nil {
+			return err
+		}
+	}
 	cmd := &dashapi.BugUpdate{
 		ID:           notif.ID,
 		Status:       status,
@@ -222,15 +243,21 @@ func emailPollJobs(c context.Context) error {
 }
 
 func emailReport(c context.Context, rep *dashapi.BugReport) error {
-	templ, public := "", false
+	templ, event, public := "", "", false
 	switch rep.Type {
-	case dashapi.ReportNew, dashapi.ReportRepro:
-		templ = "mail_bug.txt"
+	case dashapi.ReportNew:
+		templ, event = "mail_bug.txt", "new bug report"
+		public = true
+	case dashapi.ReportRepro:
+		templ, event = "mail_bug.txt", "reproducer found"
 		public = true
 	case dashapi.ReportTestPatch:
 		templ = "mail_test_result.txt"
-	case dashapi.ReportBisectCause, dashapi.ReportBisectFix:
-		templ = "mail_bisect_result.txt"
+	case dashapi.ReportBisectCause:
+		templ, event = "mail_bisect_result.txt", "cause bisection result"
+		public = true
+	case dashapi.ReportBisectFix:
+		templ, event = "mail_bisect_result.txt", "fix bisection result"
 		public = true
 	default:
 		return fmt.Errorf("unknown report type %v", rep.Type)
@@ -243,13 +270,30 @@ func emailReport(c context.Context, rep *dashapi.BugReport) error {
 	if cfg.MailMaintainers && public {
 		to = email.MergeEmailLists(to, rep.Maintainers, cfg.DefaultMaintainers)
 	}
+	// Test results were explicitly requested, so they are never digested.
+	to, digests, err := applyEmailPrefs(c, to, cfg.Email, public)
+	if err != nil {
+		return err
+	}
 	from, err := email.AddAddrContext(fromAddr(c), rep.ID)
 	if err != nil {
 		return err
 	}
-
+	if err := queueDigestItems(c, digests, rep.Title, event, rep.Link, commandsAddr(c, rep.ID)); err != nil {
+		return err
+	}
+	if len(to) == 0 {
+		return nil
+	}
+	body := new(bytes.Buffer)
+	if err := mailTemplates.ExecuteTemplate(body, templ, rep); err != nil {
+		return fmt.Errorf("failed to execute %v template: %v", templ, err)
+	}
+	if public && config.EmailPrefsKey != "" {
+		fmt.Fprintf(body, "\n\nTo stop receiving syzbot emails or redirect them, see:\n%v/email_prefs\n", appURL(c))
+	}
 	log.Infof(c, "sending email %q to %q", rep.Title, to)
-	return sendMailTemplate(c, rep.Title, from, to, rep.ExtID, nil, templ, rep)
+	return sendMailText(c, rep.Title, from, to, rep.ExtID, nil, body.String())
 }
 
 // handleIncomingMail is the entry point for incoming emails.
@@ -273,13 +317,29 @@ func incomingMail(c context.Context, r *http.Request) error {
 	if ownEmail(c) == msg.From {
 		return nil
 	}
+	if msg.BounceError != nil {
+		log.Errorf(c, "failed to parse delivery status notification %v: %v", msg.MessageID, msg.BounceError)
+	}
+	if msg.Bounce != nil {
+		// These are mostly stale emails in MAINTAINERS, nothing to process.
+		log.Infof(c, "email bounced: recipients %q, status %q, bug %q, msg %q",
+			msg.Bounce.Recipients, msg.Bounce.Status, msg.Bounce.BugID, msg.Bounce.MessageID)
+		return nil
+	}
+	// Check for spam and loops before any processing as we may reply to the email.
+	if reason, err := emailSpamReason(c, msg); err != nil {
+		log.Errorf(c, "failed to check email for spam: %v", err)
+	} else if reason != "" {
+		quarantineEmail(c, msg, reason)
+		return nil
+	}
 	log.Infof(c, "received email: subject %q, from %q, cc %q, msg %q, bug %q, cmd %q, link %q",
 		msg.Subject, msg.From, msg.Cc, msg.MessageID, msg.BugID, msg.Command, msg.Link)
 	if msg.Command == email.CmdFix && msg.CommandArgs == "exact-commit-title" {
 		// Sometimes it happens that somebody sends us our own text back, ignore it.
 		msg.Command, msg.CommandArgs = email.CmdNone, ""
 	}
-	bug, _, reporting := loadBugInfo(c, msg)
+	bug, bugReporting, reporting := loadBugInfo(c, msg)
 	if bug == nil {
 		return nil // error was already logged
 	}
@@ -293,10 +353,20 @@ func incomingMail(c context.Context, r *http.Request) error {
 	if msg.Command == email.CmdTest {
 		return handleTestCommand(c, msg)
 	}
+	if msg.Command == email.CmdBisectCorrect || msg.Command == email.CmdBisectWrong {
+		if fromMailingList {
+			return nil
+		}
+		return handleBisectVerdictCommand(c, bug, bugReporting, reporting, msg)
+	}
 	if fromMailingList && msg.Command != email.CmdNone {
 		log.Infof(c, "duplicate email from mailing list, ignoring")
 		return nil
 	}
+	if msg.Command == email.CmdHelp {
+		_, final := bugReportingByID(bug, msg.BugID)
+		return replyTo(c, msg, emailCommandsHelp(bug, bugReporting, final), nil)
+	}
 	cmd := &dashapi.BugUpdate{
 		Status: emailCmdToStatus[msg.Command],
 		ID:     msg.BugID,
@@ -347,11 +417,108 @@ var emailCmdToStatus = map[email.Command]dashapi.BugStatus{
 	email.CmdUnCC:     dashapi.BugStatusUnCC,
 }
 
+const (
+	// Max number of emails per sender per hour.
+	maxEmailsPerSender = 50
+	// Max number of identical emails (same sender, bug and body) per hour.
+	maxIdenticalEmails = 3
+	maxQuarantinedBody = 10 << 10
+)
+
+// emailSpamReason returns a non-empty reason if the email must not be processed.
+// Emails from our mailing lists are not rate limited since they relay all discussions.
+func emailSpamReason(c context.Context, msg *email.Email) (string, error) {
+	if msg.AutoSubmitted {
+		return "auto-submitted email", nil
+	}
+	sender := email.CanonicalEmail(msg.From)
+	if ownMailingLists()[sender] {
+		return "", nil
+	}
+	hour := timeNow(c).Unix() / 3600
+	senderKey := fmt.Sprintf("email-sender-%v-%v", hash.String([]byte(sender)), hour)
+	sent, err := memcache.Increment(c, senderKey, 1, 0)
+	if err != nil {
+		return "", err
+	}
+	if sent > maxEmailsPerSender {
+		return fmt.Sprintf("more than %v emails from the sender per hour", maxEmailsPerSender), nil
+	}
+	bodyKey := fmt.Sprintf("email-body-%v-%v", hash.String([]byte(sender), []byte(msg.BugID),
+		[]byte(msg.Subject), []byte(msg.Body)), hour)
+	identical, err := memcache.Increment(c, bodyKey, 1, 0)
+	if err != nil {
+		return "", err
+	}
+	if identical > maxIdenticalEmails {
+		return fmt.Sprintf("more than %v identical emails per hour", maxIdenticalEmails), nil
+	}
+	return "", nil
+}
+
+func ownMailingLists() map[string]bool {
+	lists := make(map[string]bool)
+	for _, ns := range config.Namespaces {
+		for _, reporting := range ns.Reporting {
+			if cfg, ok := reporting.Config.(*EmailConfig); ok {
+				lists[email.CanonicalEmail(cfg.Email)] = true
+			}
+		}
+	}
+	return lists
+}
+
+func quarantineEmail(c context.Context, msg *email.Email, reason string) {
+	log.Warningf(c, "quarantined email from %q, msg %q: %v", msg.From, msg.MessageID, reason)
+	body := msg.Body
+	if len(body) > maxQuarantinedBody {
+		body = body[:maxQuarantinedBody]
+	}
+	quarantined := &QuarantinedEmail{
+		Time:      timeNow(c),
+		From:      msg.From,
+		BugID:     msg.BugID,
+		Subject:   msg.Subject,
+		MessageID: msg.MessageID,
+		Reason:    reason,
+		Body:      body,
+	}
+	if _, err := db.Put(c, db.NewIncompleteKey(c, "QuarantinedEmail", nil), quarantined); err != nil {
+		log.Errorf(c, "failed to save quarantined email: %v", err)
+	}
+}
+
+func loadQuarantinedEmails(c context.Context) ([]*QuarantinedEmail, error) {
+	var emails []*QuarantinedEmail
+	if _, err := db.NewQuery("QuarantinedEmail").
+		Order("-Time").
+		Limit(50).
+		GetAll(c, &emails); err != nil {
+		return nil, fmt.Errorf("failed to query quarantined emails: %v", err)
+	}
+	return emails, nil
+}
+
 func handleTestCommand(c context.Context, msg *email.Email) error {
 	args := strings.Split(msg.CommandArgs, " ")
+	dryRun := args[0] == email.DryRunFlag
+	if dryRun {
+		args = args[1:]
+	}
 	if len(args) != 2 {
 		return replyTo(c, msg, fmt.Sprintf("want 2 args (repo, branch), got %v", len(args)), nil)
 	}
+	if dryRun {
+		if msg.Link != "" {
+			return nil // the direct copy of the email gets the reply
+		}
+		reply := dryRunTestRequest(c, msg.BugID, email.CanonicalEmail(msg.From),
+			msg.Patch, args[0], args[1])
+		if reply == "" {
+			return nil
+		}
+		return replyTo(c, msg, reply, nil)
+	}
 	reply := handleTestRequest(c, msg.BugID, email.CanonicalEmail(msg.From),
 		msg.MessageID, msg.Link, msg.Patch, args[0], args[1], msg.Cc)
 	if reply != "" {
@@ -360,6 +527,113 @@ func handleTestCommand(c context.Context, msg *email.Email) error {
 	return nil
 }
 
+// emailCommandsHelp describes commands that can be applied to the bug in its current state.
+func emailCommandsHelp(bug *Bug, bugReporting *BugReporting, final bool) string {
+	buf := new(bytes.Buffer)
+	cmd := func(syntax, desc string) {
+		fmt.Fprintf(buf, "\n#syz %v\n    %v\n", syntax, desc)
+	}
+	switch {
+	case bug.Status == BugStatusFixed || bug.Status == BugStatusInvalid:
+		buf.WriteString("This bug is already closed, it does not accept status commands.\n")
+	case !bugReporting.Closed.IsZero():
+		buf.WriteString("This bug is already upstreamed, please send commands in reply to the newer report.\n")
+	default:
+		buf.WriteString("Commands for this bug (one per email, at the beginning of a line):\n")
+		cmd("fix: exact-commit-title",
+			"Marks the bug as fixed by the commit, it's closed once the commit reaches all tested trees.")
+		if bug.Status == BugStatusDup {
+			cmd("undup", "Marks the bug as no longer a duplicate.")
+		} else {
+			cmd("dup: exact-subject-of-another-report", "Marks the bug as a duplicate of another bug.")
+		}
+		cmd("invalid", "Closes the bug, e.g. if it's a non-reproducible or already fixed crash.")
+		if !final && len(bug.Commits) == 0 {
+			cmd("upstream", "Sends the bug to the next reporting stage.")
+		}
+		if bug.ReproLevel != ReproLevelNone {
+			cmd("test: git://repo/address.git branch-or-commit-hash",
+				"Tests the attached (or inline) patch with the reproducer on the given tree.")
+			cmd(fmt.Sprintf("test: %v git://repo/address.git branch-or-commit-hash", email.DryRunFlag),
+				"Validates the test request and describes it without testing.")
+		}
+		if bug.BisectCause == BisectYes || bug.BisectFix == BisectYes {
+			cmd("bisect-correct [cause|fix]", "Marks the bisection result as correct.")
+			cmd("bisect-wrong [cause|fix]", "Marks the bisection result as wrong.")
+		}
+	}
+	cmd("uncc", "Removes you from CC of further emails about this bug.")
+	cmd("help", "Shows this message.")
+	buf.WriteString("\nSee https://goo.gl/tpsmEJ#status for more information.")
+	return buf.String()
+}
+
+func handleBisectVerdictCommand(c context.Context, bug *Bug, bugReporting *BugReporting,
+	reporting *Reporting, msg *email.Email) error {
+	jobType := JobBisectCause
+	switch msg.CommandArgs {
+	case "", "cause":
+	case "fix":
+		jobType = JobBisectFix
+	default:
+		return replyTo(c, msg, fmt.Sprintf("unknown bisection type %q, want cause or fix",
+			msg.CommandArgs), nil)
+	}
+	job, crash, jobKey, _, err := loadBisectJob(c, bug, jobType)
+	if err != nil {
+		return replyTo(c, msg, "Can't find the bisection for this bug.", nil)
+	}
+	user := email.CanonicalEmail(msg.From)
+	allowed, err := bisectVerdictUsers(c, bugReporting, reporting, job, crash)
+	if err != nil {
+		return err
+	}
+	if !stringInList(allowed, user) {
+		log.Warningf(c, "bisection verdict from %v who is not in %q", user, allowed)
+		return replyTo(c, msg, "Only people in CC of the bug report can judge bisection results.", nil)
+	}
+	verdict := BisectVerdictCorrect
+	if msg.Command == email.CmdBisectWrong {
+		verdict = BisectVerdictWrong
+	}
+	if err := setBisectVerdict(c, jobKey, verdict, user); err != nil {
+		log.Errorf(c, "failed to set bisection verdict: %v", err)
+		return replyTo(c, msg, "This bisection does not have a result to judge.", nil)
+	}
+	return nil
+}
+
+// bisectVerdictUsers returns canonical emails of people who can judge the bisection result:
+// everybody who was sent the bug report or the bisection result.
+func bisectVerdictUsers(c context.Context, bugReporting *BugReporting, reporting *Reporting,
+	job *Job, crash *Crash) ([]string, error) {
+	build, err := loadBuild(c, job.Namespace, crash.BuildID)
+	if err != nil {
+		return nil, err
+	}
+	kernelRepo := kernelRepoInfo(build)
+	lists := [][]string{crash.Maintainers, kernelRepo.CC, kernelRepo.Maintainers, job.CC}
+	if bugReporting.CC != "" {
+		lists = append(lists, strings.Split(bugReporting.CC, "|"))
+	}
+	if cfg, ok := reporting.Config.(*EmailConfig); ok {
+		lists = append(lists, cfg.DefaultMaintainers)
+	}
+	if len(job.Commits) == 1 {
+		com := job.Commits[0]
+		lists = append(lists, []string{com.Author}, strings.Split(com.CC, "|"))
+	}
+	var users []string
+	for _, list := range lists {
+		for _, addr := range list {
+			if addr != "" {
+				users = append(users, email.CanonicalEmail(addr))
+			}
+		}
+	}
+	return users, nil
+}
+
 func handleEmailBounce(w http.ResponseWriter, r *http.Request) {
 	c := appengine.NewContext(r)
 	body, err := ioutil.ReadAll(r.Body)
@@ -518,6 +792,15 @@ func fromAddr(c context.Context) string {
 	return fmt.Sprintf("\"syzbot\" <%v>", ownEmail(c))
 }
 
+// commandsAddr returns the bug-specific address that accepts #syz commands for the bug.
+func commandsAddr(c context.Context, bugID string) string {
+	addr, err := email.AddAddrContext(ownEmail(c), bugID)
+	if err != nil {
+		return ownEmail(c)
+	}
+	return addr
+}
+
 func ownEmails(c context.Context) []string {
 	// Now we use syzbot@ but we used to use bot@, so we add them both.
 	return []string{
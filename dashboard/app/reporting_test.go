@@ -246,6 +246,22 @@ func TestReportingQuota(t *testing.T) {
 	}
 }
 
+func TestReportingReleases(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	releases := []string{"v5.7", "v5.4", ""}
+	for i, release := range releases {
+		build := testBuild(i + 1)
+		build.KernelRelease = release
+		c.client.UploadBuild(build)
+		c.client.ReportCrash(testCrash(build, 1))
+	}
+	rep := c.client.pollBug()
+	c.expectEQ(rep.FirstRelease, "v5.4")
+	c.expectEQ(rep.LastRelease, "v5.7")
+}
+
 // Basic dup scenario: mark one bug as dup of another.
 func TestReportingDup(t *testing.T) {
 	c := NewCtx(t)
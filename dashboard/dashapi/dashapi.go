@@ -68,6 +68,7 @@ type Build struct {
 	KernelCommit        string
 	KernelCommitTitle   string
 	KernelCommitDate    time.Time
+	KernelRelease       string // latest release tag the kernel commit is based on (e.g. v5.6)
 	KernelConfig        []byte
 	Commits             []string // see BuilderPoll
 	FixCommits          []Commit
@@ -82,8 +83,14 @@ type Commit struct {
 	Recipients Recipients
 	BugIDs     []string // ID's extracted from Reported-by tags
 	Date       time.Time
+	// Diffstat and a patch excerpt, filled in only for the culprit commit of bisection.
+	Diffstat string
+	Patch    []byte
 }
 
+// MaxCulpritPatch is the maximum size of Commit.Patch that syz-ci sends and the dashboard stores.
+const MaxCulpritPatch = 32 << 10
+
 func (dash *Dashboard) UploadBuild(build *Build) error {
 	return dash.Query("upload_build", build, nil)
 }
@@ -103,6 +110,7 @@ type BuilderPollReq struct {
 type BuilderPollResp struct {
 	PendingCommits []string
 	ReportEmail    string
+	DashboardURL   string // bugs may be referenced as DashboardURL/bug?extid=tag in fixing commits
 }
 
 func (dash *Dashboard) BuilderPoll(manager string) (*BuilderPollResp, error) {
@@ -205,9 +213,10 @@ func (dash *Dashboard) ReportBuildError(req *BuildErrorReq) error {
 }
 
 type CommitPollResp struct {
-	ReportEmail string
-	Repos       []Repo
-	Commits     []string
+	ReportEmail  string
+	DashboardURL string // see BuilderPollResp
+	Repos        []Repo
+	Commits      []string
 }
 
 type CommitPollResultReq struct {
@@ -245,6 +254,9 @@ type Crash struct {
 	ReproOpts []byte
 	ReproSyz  []byte
 	ReproC    []byte
+	// Source of the crashing function and a summary of nearby functions
+	// annotated with coverage of the crashing programs (optional).
+	CoverContext []byte
 }
 
 type ReportCrashResp struct {
@@ -336,6 +348,9 @@ type BugReport struct {
 	CrashID           int64 // returned back in BugUpdate
 	NumCrashes        int64
 	HappenedOn        []string // list of kernel repo aliases
+	// Range of kernel releases the bug was observed on (e.g. v5.4..v5.7), empty if unknown.
+	FirstRelease string
+	LastRelease  string
 
 	CrashTitle     string // job execution crash title
 	Error          []byte // job execution error
@@ -473,6 +488,11 @@ type ManagerStatsReq struct {
 	Cover      uint64 // what we call feedback signal everywhere else
 	CrashTypes uint64
 
+	TriageQueueLen uint64 // inputs waiting for triage (corpus/hub candidates)
+	ReproQueueLen  uint64 // crashes waiting for reproduction
+	NumVMs         uint64
+	FuzzingVMs     uint64 // VMs that are currently fuzzing
+
 	// Delta since last sync:
 	FuzzingTime       time.Duration
 	Crashes           uint64
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/google/syzkaller/pkg/build"
@@ -28,6 +29,16 @@ type Config struct {
 	Syzkaller SyzkallerConfig
 	Repro     ReproConfig
 	Manager   mgrconfig.Config
+	// CompilerEras override the toolchain selected by vcs.Bisecter for commits
+	// with dates falling into the specified ranges.
+	CompilerEras []CompilerEra
+	// SkipPolicy controls handling of commits that fail to build or boot (optional).
+	SkipPolicy *vcs.BisectPolicy
+	// ParallelBisection is the number of commits tested concurrently during commit bisection
+	// (optional, 0 and 1 mean no parallelism). Each concurrent test uses a separate kernel
+	// worktree (next to the kernel dir), workdir and VM pool (of Manager.Count VMs).
+	// Can't be combined with SkipPolicy.
+	ParallelBisection int
 }
 
 type KernelConfig struct {
@@ -45,6 +56,26 @@ type KernelConfig struct {
 	// this minimized one.
 	BaselineConfig []byte
 	Userspace      string
+	// Credentials for the kernel repo (optional).
+	Auth *vcs.RepoAuth
+	// Format of release tags of the kernel repo (optional).
+	ReleaseScheme *vcs.ReleaseScheme
+	// Git LFS settings of the kernel repo (optional).
+	LFS *vcs.LFSConfig
+}
+
+// CompilerEra specifies toolchain to build kernel commits with dates in [Start, End).
+// Zero Start/End mean the range is not bounded on that side.
+type CompilerEra struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Compiler string    `json:"compiler"`
+	Linker   string    `json:"linker,omitempty"` // if empty, the default linker is used; linux only
+}
+
+func (era *CompilerEra) contains(date time.Time) bool {
+	return (era.Start.IsZero() || !date.Before(era.Start)) &&
+		(era.End.IsZero() || date.Before(era.End))
 }
 
 type SyzkallerConfig struct {
@@ -68,10 +99,23 @@ type env struct {
 	head         *vcs.Commit
 	kernelConfig []byte
 	inst         instance.Env
-	numTests     int
+	main         *checkout
+	newInst      func(cfg *mgrconfig.Config) (instance.Env, error)
 	startTime    time.Time
-	buildTime    time.Duration
-	testTime     time.Duration
+
+	mu        sync.Mutex // protects stats and trace in parallel bisection
+	numTests  int
+	buildTime time.Duration
+	testTime  time.Duration
+}
+
+// checkout is a kernel source tree where commits are built and tested.
+type checkout struct {
+	repo     vcs.Repo
+	bisecter vcs.Bisecter
+	mgr      *mgrconfig.Config
+	inst     instance.Env
+	trace    io.Writer
 }
 
 const NumTests = 10 // number of tests we do per commit
@@ -112,6 +156,15 @@ func Run(cfg *Config) (*Result, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := vcs.SetRepoAuth(repo, cfg.Kernel.Repo, cfg.Kernel.Auth); err != nil {
+		return nil, err
+	}
+	if err := vcs.SetReleaseScheme(repo, cfg.Kernel.ReleaseScheme); err != nil {
+		return nil, err
+	}
+	if err := vcs.SetLFS(repo, cfg.Kernel.LFS); err != nil {
+		return nil, err
+	}
 	inst, err := instance.NewEnv(&cfg.Manager)
 	if err != nil {
 		return nil, err
@@ -119,10 +172,13 @@ func Run(cfg *Config) (*Result, error) {
 	if _, err = repo.CheckoutBranch(cfg.Kernel.Repo, cfg.Kernel.Branch); err != nil {
 		return nil, err
 	}
-	return runImpl(cfg, repo, inst)
+	return runImpl(cfg, repo, inst, instance.NewEnv)
 }
 
-func runImpl(cfg *Config, repo vcs.Repo, inst instance.Env) (*Result, error) {
+// runImpl does the bisection using inst for tests in the repo checkout,
+// newInst creates test instances for additional checkouts used in parallel bisection.
+func runImpl(cfg *Config, repo vcs.Repo, inst instance.Env,
+	newInst func(cfg *mgrconfig.Config) (instance.Env, error)) (*Result, error) {
 	bisecter, ok := repo.(vcs.Bisecter)
 	if !ok {
 		return nil, fmt.Errorf("bisection is not implemented for %v", cfg.Manager.TargetOS)
@@ -137,7 +193,15 @@ func runImpl(cfg *Config, repo vcs.Repo, inst instance.Env) (*Result, error) {
 		bisecter:  bisecter,
 		minimizer: minimizer,
 		inst:      inst,
+		newInst:   newInst,
 		startTime: time.Now(),
+		main: &checkout{
+			repo:     repo,
+			bisecter: bisecter,
+			mgr:      &cfg.Manager,
+			inst:     inst,
+			trace:    cfg.Trace,
+		},
 	}
 	head, err := repo.HeadCommit()
 	if err != nil {
@@ -241,11 +305,7 @@ func (env *env) bisect() (*Result, error) {
 	for _, res := range results1 {
 		results[res.com.Hash] = res
 	}
-	pred := func() (vcs.BisectResult, error) {
-		testRes1, err := env.test()
-		if err != nil {
-			return 0, err
-		}
+	verdict := func(testRes1 *testResult) vcs.BisectResult {
 		if cfg.Fix {
 			if testRes1.verdict == vcs.BisectBad {
 				testRes1.verdict = vcs.BisectGood
@@ -253,10 +313,24 @@ func (env *env) bisect() (*Result, error) {
 				testRes1.verdict = vcs.BisectBad
 			}
 		}
+		env.mu.Lock()
 		results[testRes1.com.Hash] = testRes1
-		return testRes1.verdict, err
+		env.mu.Unlock()
+		return testRes1.verdict
+	}
+	var commits []*vcs.Commit
+	if cfg.ParallelBisection > 1 {
+		commits, err = env.bisectParallel(bad, good, verdict)
+	} else {
+		commits, err = env.bisecter.Bisect(bad.Hash, good.Hash, cfg.SkipPolicy, cfg.Trace,
+			func() (vcs.BisectResult, error) {
+				testRes1, err := env.test()
+				if err != nil {
+					return 0, err
+				}
+				return verdict(testRes1), nil
+			})
 	}
-	commits, err := env.bisecter.Bisect(bad.Hash, good.Hash, cfg.Trace, pred)
 	if err != nil {
 		return nil, err
 	}
@@ -285,6 +359,75 @@ func (env *env) bisect() (*Result, error) {
 	return res, nil
 }
 
+// bisectParallel bisects bad..good testing cfg.ParallelBisection commits concurrently.
+func (env *env) bisectParallel(bad, good *vcs.Commit, verdict func(*testResult) vcs.BisectResult) (
+	[]*vcs.Commit, error) {
+	bisecter, ok := env.repo.(vcs.ParallelBisecter)
+	if !ok {
+		return nil, fmt.Errorf("parallel bisection is not implemented for %v", env.cfg.Manager.TargetOS)
+	}
+	trace := &lockedWriter{mu: &env.mu, w: env.cfg.Trace}
+	checkouts := make(map[string]*checkout)
+	var checkoutsMu sync.Mutex
+	return bisecter.BisectParallel(bad.Hash, good.Hash, env.cfg.ParallelBisection, trace,
+		func(com *vcs.Commit, dir string) (vcs.BisectResult, error) {
+			checkoutsMu.Lock()
+			co := checkouts[dir]
+			checkoutsMu.Unlock()
+			if co == nil {
+				var err error
+				if co, err = env.newCheckout(dir); err != nil {
+					return 0, err
+				}
+				checkoutsMu.Lock()
+				checkouts[dir] = co
+				checkoutsMu.Unlock()
+			}
+			// Buffer the log, so that logs of concurrent tests are not interleaved.
+			log := new(bytes.Buffer)
+			co.trace = log
+			testRes, err := env.testCheckout(co)
+			trace.Write(log.Bytes())
+			if err != nil {
+				return 0, err
+			}
+			return verdict(testRes), nil
+		})
+}
+
+// newCheckout creates a checkout for parallel bisection in the worktree dir.
+func (env *env) newCheckout(dir string) (*checkout, error) {
+	cfg := env.cfg
+	repo, err := vcs.NewRepo(cfg.Manager.TargetOS, cfg.Manager.Type, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := vcs.SetReleaseScheme(repo, cfg.Kernel.ReleaseScheme); err != nil {
+		return nil, err
+	}
+	bisecter, ok := repo.(vcs.Bisecter)
+	if !ok {
+		return nil, fmt.Errorf("bisection is not implemented for %v", cfg.Manager.TargetOS)
+	}
+	// Each checkout gets own workdir (kernel image) and VMs.
+	mgr := new(mgrconfig.Config)
+	*mgr = cfg.Manager
+	suffix := "bisect" + filepath.Base(dir)
+	mgr.KernelSrc = dir
+	mgr.Workdir = filepath.Join(cfg.Manager.Workdir, suffix)
+	mgr.Name = cfg.Manager.Name + "-" + suffix
+	inst, err := env.newInst(mgr)
+	if err != nil {
+		return nil, err
+	}
+	return &checkout{
+		repo:     repo,
+		bisecter: bisecter,
+		mgr:      mgr,
+		inst:     inst,
+	}, nil
+}
+
 func (env *env) minimizeConfig() (*testResult, error) {
 	cfg := env.cfg
 	// Check if crash reproduces with baseline config.
@@ -428,42 +571,70 @@ type testResult struct {
 }
 
 func (env *env) build() (*vcs.Commit, string, error) {
-	current, err := env.repo.HeadCommit()
+	return env.buildCheckout(env.main)
+}
+
+func (env *env) buildCheckout(co *checkout) (*vcs.Commit, string, error) {
+	current, err := co.repo.HeadCommit()
 	if err != nil {
 		return nil, "", err
 	}
 
-	bisectEnv, err := env.bisecter.EnvForCommit(env.cfg.BinDir, current.Hash, env.kernelConfig)
+	bisectEnv, err := co.bisecter.EnvForCommit(env.cfg.BinDir, current.Hash, env.kernelConfig)
 	if err != nil {
 		return nil, "", err
 	}
-	compilerID, err := build.CompilerIdentity(bisectEnv.Compiler)
+	compiler, linker := bisectEnv.Compiler, ""
+	if era := env.compilerEra(current.Date); era != nil {
+		compiler, linker = era.Compiler, era.Linker
+	}
+	compilerID, err := build.CompilerIdentity(compiler)
 	if err != nil {
 		return nil, "", err
 	}
-	env.log("testing commit %v with %v", current.Hash, compilerID)
+	co.log("testing commit %v with %v", current.Hash, compilerID)
+	if linker != "" {
+		co.log("using linker %v", linker)
+	}
 	buildStart := time.Now()
-	mgr := &env.cfg.Manager
+	mgr := co.mgr
 	if err := build.Clean(mgr.TargetOS, mgr.TargetVMArch, mgr.Type, mgr.KernelSrc); err != nil {
 		return nil, "", fmt.Errorf("kernel clean failed: %v", err)
 	}
 	kern := &env.cfg.Kernel
-	_, kernelSign, err := env.inst.BuildKernel(bisectEnv.Compiler, kern.Userspace,
+	_, kernelSign, err := co.inst.BuildKernel(compiler, linker, kern.Userspace,
 		kern.Cmdline, kern.Sysctl, bisectEnv.KernelConfig)
 	if kernelSign != "" {
-		env.log("kernel signature: %v", kernelSign)
+		co.log("kernel signature: %v", kernelSign)
 	}
+	env.mu.Lock()
 	env.buildTime += time.Since(buildStart)
+	env.mu.Unlock()
 	return current, kernelSign, err
 }
 
+func (env *env) compilerEra(date time.Time) *CompilerEra {
+	for i := range env.cfg.CompilerEras {
+		if era := &env.cfg.CompilerEras[i]; era.contains(date) {
+			return era
+		}
+	}
+	return nil
+}
+
 func (env *env) test() (*testResult, error) {
+	return env.testCheckout(env.main)
+}
+
+func (env *env) testCheckout(co *checkout) (*testResult, error) {
 	cfg := env.cfg
 	if cfg.Timeout != 0 && time.Since(env.startTime) > cfg.Timeout {
 		return nil, fmt.Errorf("bisection is taking too long (>%v), aborting", cfg.Timeout)
 	}
+	env.mu.Lock()
 	env.numTests++
-	current, kernelSign, err := env.build()
+	env.mu.Unlock()
+	current, kernelSign, err := env.buildCheckout(co)
 	res := &testResult{
 		verdict:    vcs.BisectSkip,
 		com:        current,
@@ -471,24 +642,26 @@ func (env *env) test() (*testResult, error) {
 	}
 	if err != nil {
 		if verr, ok := err.(*osutil.VerboseError); ok {
-			env.log("%v", verr.Title)
+			co.log("%v", verr.Title)
 			env.saveDebugFile(current.Hash, 0, verr.Output)
 		} else if verr, ok := err.(*build.KernelError); ok {
-			env.log("%s", verr.Report)
+			co.log("%s", verr.Report)
 			env.saveDebugFile(current.Hash, 0, verr.Output)
 		} else {
-			env.log("%v", err)
+			co.log("%v", err)
 		}
 		return res, nil
 	}
 	testStart := time.Now()
-	results, err := env.inst.Test(NumTests, cfg.Repro.Syz, cfg.Repro.Opts, cfg.Repro.C)
+	results, err := co.inst.Test(NumTests, cfg.Repro.Syz, cfg.Repro.Opts, cfg.Repro.C)
+	env.mu.Lock()
 	env.testTime += time.Since(testStart)
+	env.mu.Unlock()
 	if err != nil {
-		env.log("failed: %v", err)
+		co.log("failed: %v", err)
 		return res, nil
 	}
-	bad, good, rep := env.processResults(current, results)
+	bad, good, rep := env.processResults(co, current, results)
 	res.rep = rep
 	res.verdict = vcs.BisectSkip
 	if bad != 0 {
@@ -503,7 +676,8 @@ func (env *env) test() (*testResult, error) {
 	return res, nil
 }
 
-func (env *env) processResults(current *vcs.Commit, results []error) (bad, good int, rep *report.Report) {
+func (env *env) processResults(co *checkout, current *vcs.Commit, results []error) (
+	bad, good int, rep *report.Report) {
 	var verdicts []string
 	for i, res := range results {
 		if res == nil {
@@ -541,10 +715,10 @@ func (env *env) processResults(current *vcs.Commit, results []error) (bad, good
 		unique[verdict] = true
 	}
 	if len(unique) == 1 {
-		env.log("all runs: %v", verdicts[0])
+		co.log("all runs: %v", verdicts[0])
 	} else {
 		for i, verdict := range verdicts {
-			env.log("run #%v: %v", i, verdict)
+			co.log("run #%v: %v", i, verdict)
 		}
 	}
 	return
@@ -571,9 +745,43 @@ func checkConfig(cfg *Config) error {
 	if cfg.Kernel.Cmdline != "" && !osutil.IsExist(cfg.Kernel.Cmdline) {
 		return fmt.Errorf("cmdline file %v does not exist", cfg.Kernel.Cmdline)
 	}
+	if cfg.ParallelBisection > 1 && cfg.SkipPolicy != nil {
+		return fmt.Errorf("parallel bisection does not support skip policy")
+	}
+	for _, era := range cfg.CompilerEras {
+		if !osutil.IsExist(era.Compiler) {
+			return fmt.Errorf("compiler %v does not exist", era.Compiler)
+		}
+		if era.Linker != "" && !osutil.IsExist(era.Linker) {
+			return fmt.Errorf("linker %v does not exist", era.Linker)
+		}
+		if era.Linker != "" && !build.LinkerSupported(cfg.Manager.TargetOS, cfg.Manager.TargetVMArch,
+			cfg.Manager.Type) {
+			return fmt.Errorf("compiler era linker is not supported for %v/%v/%v",
+				cfg.Manager.TargetOS, cfg.Manager.TargetVMArch, cfg.Manager.Type)
+		}
+		if !era.Start.IsZero() && !era.End.IsZero() && !era.Start.Before(era.End) {
+			return fmt.Errorf("bad compiler era [%v, %v)", era.Start, era.End)
+		}
+	}
 	return nil
 }
 
 func (env *env) log(msg string, args ...interface{}) {
 	fmt.Fprintf(env.cfg.Trace, msg+"\n", args...)
 }
+
+func (co *checkout) log(msg string, args ...interface{}) {
+	fmt.Fprintf(co.trace, msg+"\n", args...)
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(data)
+}
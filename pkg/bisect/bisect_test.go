@@ -10,6 +10,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/pkg/instance"
@@ -32,7 +33,7 @@ func (env *testEnv) BuildSyzkaller(repo, commit string) error {
 	return nil
 }
 
-func (env *testEnv) BuildKernel(compilerBin, userspaceDir, cmdlineFile, sysctlFile string,
+func (env *testEnv) BuildKernel(compilerBin, linkerBin, userspaceDir, cmdlineFile, sysctlFile string,
 	kernelConfig []byte) (string, string, error) {
 	commit := env.headCommit()
 	configHash := hash.String(kernelConfig)
@@ -117,8 +118,9 @@ func runBisection(t *testing.T, baseDir string, test BisectionTest) (*Result, er
 	}
 	trace := new(bytes.Buffer)
 	cfg := &Config{
-		Fix:   test.fix,
-		Trace: trace,
+		Fix:               test.fix,
+		Trace:             trace,
+		ParallelBisection: test.parallel,
 		Manager: mgrconfig.Config{
 			TargetOS:     "test",
 			TargetVMArch: "64",
@@ -137,7 +139,18 @@ func runBisection(t *testing.T, baseDir string, test BisectionTest) (*Result, er
 		r:    r,
 		test: test,
 	}
-	res, err := runImpl(cfg, r, inst)
+	newInst := func(mgr *mgrconfig.Config) (instance.Env, error) {
+		r, err := vcs.NewRepo("test", "64", mgr.KernelSrc)
+		if err != nil {
+			return nil, err
+		}
+		return &testEnv{
+			t:    t,
+			r:    r,
+			test: test,
+		}, nil
+	}
+	res, err := runImpl(cfg, r, inst, newInst)
 	t.Log(trace.String())
 	return res, err
 }
@@ -149,6 +162,7 @@ type BisectionTest struct {
 	startCommit int
 	brokenStart int
 	brokenEnd   int
+	parallel    int // number of commits tested concurrently
 	// Range of commits that result in the same kernel binary signature.
 	sameBinaryStart int
 	sameBinaryEnd   int
@@ -420,6 +434,25 @@ var bisectionTests = []BisectionTest{
 
 func TestBisectionResults(t *testing.T) {
 	t.Parallel()
+	testBisectionResults(t, bisectionTests)
+}
+
+func TestBisectionResultsParallel(t *testing.T) {
+	t.Parallel()
+	var tests []BisectionTest
+	for _, test := range bisectionTests {
+		test.parallel = 3
+		if test.name == "cause-inconclusive" {
+			// Parallel bisection walks the first-parent chain,
+			// so commit 650 from the merged branch is not among the candidates.
+			test.commitLen--
+		}
+		tests = append(tests, test)
+	}
+	testBisectionResults(t, tests)
+}
+
+func testBisectionResults(t *testing.T, bisectionTests []BisectionTest) {
 	// Creating new repos takes majority of the test time,
 	// so we reuse them across tests.
 	repoCache := make(chan string, len(bisectionTests))
@@ -519,3 +552,68 @@ func crashErrors(num int, title string) []error {
 	}
 	return errors
 }
+
+func TestCompilerEra(t *testing.T) {
+	date := func(year int) time.Time {
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	env := &env{
+		cfg: &Config{
+			CompilerEras: []CompilerEra{
+				{End: date(2015), Compiler: "gcc-4.9"},
+				{Start: date(2015), End: date(2018), Compiler: "gcc-7", Linker: "ld.bfd"},
+				{Start: date(2020), Compiler: "clang"},
+			},
+		},
+	}
+	tests := []struct {
+		date     time.Time
+		compiler string
+	}{
+		{date(2010), "gcc-4.9"},
+		{date(2015).Add(-time.Second), "gcc-4.9"},
+		{date(2015), "gcc-7"},
+		{date(2017), "gcc-7"},
+		{date(2018), ""},
+		{date(2019), ""},
+		{date(2020), "clang"},
+		{date(2030), "clang"},
+	}
+	for _, test := range tests {
+		compiler := ""
+		if era := env.compilerEra(test.date); era != nil {
+			compiler = era.Compiler
+		}
+		if compiler != test.compiler {
+			t.Errorf("date %v: got compiler %q, want %q", test.date, compiler, test.compiler)
+		}
+	}
+}
+
+func TestCompilerEraLinker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-bisect-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg := &Config{
+		BinDir: dir,
+		CompilerEras: []CompilerEra{
+			{Compiler: dir, Linker: dir},
+		},
+	}
+	tests := []struct {
+		os, arch, vm string
+		ok           bool
+	}{
+		{"linux", "amd64", "qemu", true},
+		{"linux", "amd64", "gvisor", false},
+		{"freebsd", "amd64", "qemu", false},
+	}
+	for _, test := range tests {
+		cfg.Manager.TargetOS, cfg.Manager.TargetVMArch, cfg.Manager.Type = test.os, test.arch, test.vm
+		if err := checkConfig(cfg); (err == nil) != test.ok {
+			t.Errorf("%v/%v/%v: got error %v, want ok=%v", test.os, test.arch, test.vm, err, test.ok)
+		}
+	}
+}
@@ -26,6 +26,7 @@ type Params struct {
 	KernelDir    string
 	OutputDir    string
 	Compiler     string
+	Linker       string // optional, if empty the default linker is used
 	UserspaceDir string
 	CmdlineFile  string
 	SysctlFile   string
@@ -54,6 +55,10 @@ func Image(params *Params) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if params.Linker != "" && !linkerSupported(builder) {
+		return "", fmt.Errorf("custom linker is not supported for %v/%v/%v",
+			params.TargetOS, params.TargetArch, params.VMType)
+	}
 	if err := osutil.MkdirAll(filepath.Join(params.OutputDir, "obj")); err != nil {
 		return "", err
 	}
@@ -110,6 +115,17 @@ type signer interface {
 	sign(params *Params) (string, error)
 }
 
+// LinkerSupported says if the kernel builder for the target honors Params.Linker.
+func LinkerSupported(targetOS, targetArch, vmType string) bool {
+	builder, err := getBuilder(targetOS, targetArch, vmType)
+	return err == nil && linkerSupported(builder)
+}
+
+func linkerSupported(b builder) bool {
+	_, ok := b.(linux)
+	return ok
+}
+
 func getBuilder(targetOS, targetArch, vmType string) (builder, error) {
 	var supported = []struct {
 		OS   string
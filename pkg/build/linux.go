@@ -53,7 +53,11 @@ func (linux) buildKernel(params *Params) error {
 	// One would expect olddefconfig here, but olddefconfig is not present in v3.6 and below.
 	// oldconfig is the same as olddefconfig if stdin is not set.
 	// Note: passing in compiler is important since 4.17 (at the very least it's noted in the config).
-	if err := runMake(params.KernelDir, "oldconfig", "CC="+params.Compiler); err != nil {
+	makeArgs := []string{"CC=" + params.Compiler}
+	if params.Linker != "" {
+		makeArgs = append(makeArgs, "LD="+params.Linker)
+	}
+	if err := runMake(params.KernelDir, append([]string{"oldconfig"}, makeArgs...)...); err != nil {
 		return err
 	}
 	// Write updated kernel config early, so that it's captured on build failures.
@@ -69,7 +73,7 @@ func (linux) buildKernel(params *Params) error {
 	case "ppc64le":
 		target = "zImage"
 	}
-	if err := runMake(params.KernelDir, target, "CC="+params.Compiler); err != nil {
+	if err := runMake(params.KernelDir, append([]string{target}, makeArgs...)...); err != nil {
 		return err
 	}
 	vmlinux := filepath.Join(params.KernelDir, "vmlinux")
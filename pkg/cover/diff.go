@@ -0,0 +1,95 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Delta is the difference between two coverage snapshots.
+type Delta struct {
+	Added   []uint32     `json:",omitempty"` // PCs covered only in the new snapshot
+	Removed []uint32     `json:",omitempty"` // PCs covered only in the old snapshot
+	Files   []*FileDelta `json:",omitempty"` // filled by ReportGenerator.SummarizeDelta
+}
+
+// FileDelta is the difference in coverage of a single source file.
+type FileDelta struct {
+	File    string
+	Added   int
+	Removed int
+}
+
+// Diff returns coverage difference between snapshots a (old) and b (new).
+func Diff(a, b Cover) *Delta {
+	d := new(Delta)
+	for pc := range b {
+		if _, ok := a[pc]; !ok {
+			d.Added = append(d.Added, pc)
+		}
+	}
+	for pc := range a {
+		if _, ok := b[pc]; !ok {
+			d.Removed = append(d.Removed, pc)
+		}
+	}
+	sortPCs(d.Added)
+	sortPCs(d.Removed)
+	return d
+}
+
+func (d *Delta) Serialize() ([]byte, error) {
+	return json.MarshalIndent(d, "", "\t")
+}
+
+func DeserializeDelta(data []byte) (*Delta, error) {
+	d := new(Delta)
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// SummarizeDelta fills in per-file summary of d.
+// toPCs converts raw PCs in d to kernel PCs (e.g. restores base and takes the call instruction PC).
+func (rg *ReportGenerator) SummarizeDelta(d *Delta, toPCs func([]uint32) []uint64) {
+	files := make(map[string]*FileDelta)
+	count := func(raw []uint32, added bool) {
+		for _, pc := range toPCs(raw) {
+			seen := make(map[string]bool)
+			for _, frame := range rg.pcs[pc] {
+				if seen[frame.File] {
+					continue
+				}
+				seen[frame.File] = true
+				f := files[frame.File]
+				if f == nil {
+					f = &FileDelta{File: frame.File}
+					files[frame.File] = f
+				}
+				if added {
+					f.Added++
+				} else {
+					f.Removed++
+				}
+			}
+		}
+	}
+	count(d.Added, true)
+	count(d.Removed, false)
+	d.Files = nil
+	for _, f := range files {
+		d.Files = append(d.Files, f)
+	}
+	sort.Slice(d.Files, func(i, j int) bool {
+		return d.Files[i].File < d.Files[j].File
+	})
+}
+
+func sortPCs(pcs []uint32) {
+	sort.Slice(pcs, func(i, j int) bool {
+		return pcs[i] < pcs[j]
+	})
+}
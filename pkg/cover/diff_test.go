@@ -0,0 +1,69 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/symbolizer"
+)
+
+func TestDiff(t *testing.T) {
+	var a, b Cover
+	a.Merge([]uint32{5, 1, 2, 3})
+	b.Merge([]uint32{3, 4, 2, 6})
+	d := Diff(a, b)
+	want := &Delta{
+		Added:   []uint32{4, 6},
+		Removed: []uint32{1, 5},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("got %+v, want %+v", d, want)
+	}
+	data, err := d.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1, err := DeserializeDelta(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(d1, want) {
+		t.Fatalf("got %+v after deserialization, want %+v", d1, want)
+	}
+	if d := Diff(a, a); len(d.Added) != 0 || len(d.Removed) != 0 {
+		t.Fatalf("non-empty diff of the same cover: %+v", d)
+	}
+}
+
+func TestSummarizeDelta(t *testing.T) {
+	rg := &ReportGenerator{
+		pcs: map[uint64][]symbolizer.Frame{
+			0x101: {{File: "a.c"}},
+			0x102: {{File: "a.c"}, {File: "b.h"}}, // inlined frame
+			0x103: {{File: "b.h"}, {File: "b.h"}}, // same file counted once
+			0x104: {{File: "c.c"}},
+		},
+	}
+	d := &Delta{
+		Added:   []uint32{1, 2, 5},
+		Removed: []uint32{3, 4},
+	}
+	rg.SummarizeDelta(d, func(raw []uint32) []uint64 {
+		var pcs []uint64
+		for _, pc := range raw {
+			pcs = append(pcs, 0x100+uint64(pc))
+		}
+		return pcs
+	})
+	want := []*FileDelta{
+		{File: "a.c", Added: 2},
+		{File: "b.h", Added: 1, Removed: 1},
+		{File: "c.c", Removed: 1},
+	}
+	if !reflect.DeepEqual(d.Files, want) {
+		t.Fatalf("bad summary:\ngot:  %+v\nwant: %+v", d.Files, want)
+	}
+}
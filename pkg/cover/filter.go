@@ -0,0 +1,54 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// MatchPath says if the source file path (relative to the kernel source dir) matches any of patterns.
+// Patterns are filepath.Match globs that are matched against the path itself and all its parent dirs,
+// so both "net/sctp" and "drivers/staging/*" match "drivers/staging/foo/bar.c".
+func MatchPath(patterns []string, path string) bool {
+	path = filepath.Clean(path)
+	for _, pattern := range patterns {
+		pattern = filepath.Clean(pattern)
+		for prefix := path; prefix != "." && prefix != string(filepath.Separator); prefix = filepath.Dir(prefix) {
+			if ok, _ := filepath.Match(pattern, prefix); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PathPCs returns the set of coverage PCs that belong to source files matching patterns (see MatchPath).
+// A PC is attributed to the file of its outermost (non-inlined) frame, so that code inlined
+// from common headers is accounted to the function it is inlined into.
+func (rg *ReportGenerator) PathPCs(patterns []string) map[uint64]bool {
+	res := make(map[uint64]bool)
+	if len(patterns) == 0 {
+		return res
+	}
+	matched := make(map[string]bool)
+	for pc, frames := range rg.pcs {
+		for _, frame := range frames {
+			if frame.Inline {
+				continue
+			}
+			match, ok := matched[frame.File]
+			if !ok {
+				_, remain, err := rg.sourcePath(frame.File)
+				match = err == nil && MatchPath(patterns, strings.TrimPrefix(remain, string(filepath.Separator)))
+				matched[frame.File] = match
+			}
+			if match {
+				res[pc] = true
+			}
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"testing"
+)
+
+func TestMatchPath(t *testing.T) {
+	patterns := []string{"drivers/staging/*", "net/sctp", "fs/*.c"}
+	tests := []struct {
+		path  string
+		match bool
+	}{
+		{"drivers/staging/foo/bar.c", true},
+		{"drivers/staging/bar.c", true},
+		{"drivers/staging", false},
+		{"drivers/net/foo.c", false},
+		{"net/sctp/socket.c", true},
+		{"net/sctp.c", false},
+		{"fs/open.c", true},
+		{"fs/ext4/inode.c", false},
+		{"./net/sctp/input.c", true},
+		{"kernel/fork.c", false},
+	}
+	for _, test := range tests {
+		if got := MatchPath(patterns, test.path); got != test.match {
+			t.Errorf("MatchPath(%q): got %v, want %v", test.path, got, test.match)
+		}
+	}
+	if MatchPath(nil, "net/sctp/socket.c") {
+		t.Errorf("empty patterns matched")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"sort"
+)
+
+// FuncCover holds coverage of a single kernel function.
+type FuncCover struct {
+	Name    string
+	PCs     int // total number of coverage callbacks in the function
+	Covered int // number of covered coverage callbacks
+	Progs   int // number of programs that cover the function
+}
+
+// FuncCoverage aggregates coverage of progs per function.
+// Returns only covered functions sorted by name.
+func (rg *ReportGenerator) FuncCoverage(progs []Prog) []*FuncCover {
+	rg.funcPCsOnce.Do(rg.countFuncPCs)
+	funcs := make(map[*symbol]*FuncCover)
+	coveredPCs := make(map[uint64]bool)
+	for _, prog := range progs {
+		progFuncs := make(map[*symbol]bool)
+		for _, pc := range prog.PCs {
+			if _, ok := rg.pcs[pc]; !ok {
+				continue
+			}
+			s := rg.lookupSymbol(pc)
+			if s == nil {
+				continue
+			}
+			fc := funcs[s]
+			if fc == nil {
+				fc = &FuncCover{
+					Name: s.name,
+					PCs:  rg.funcPCs[s.start],
+				}
+				funcs[s] = fc
+			}
+			if !coveredPCs[pc] {
+				coveredPCs[pc] = true
+				fc.Covered++
+			}
+			if !progFuncs[s] {
+				progFuncs[s] = true
+				fc.Progs++
+			}
+		}
+	}
+	res := make([]*FuncCover, 0, len(funcs))
+	for _, fc := range funcs {
+		res = append(res, fc)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// NewlyCoveredFuncs returns functions from cur that are not present in base.
+func NewlyCoveredFuncs(base, cur []*FuncCover) []*FuncCover {
+	old := make(map[string]bool)
+	for _, fc := range base {
+		old[fc.Name] = true
+	}
+	var res []*FuncCover
+	for _, fc := range cur {
+		if !old[fc.Name] {
+			res = append(res, fc)
+		}
+	}
+	return res
+}
+
+func (rg *ReportGenerator) countFuncPCs() {
+	rg.funcPCs = make(map[uint64]int)
+	for pc := range rg.pcs {
+		if s := rg.lookupSymbol(pc); s != nil {
+			rg.funcPCs[s.start]++
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/symbolizer"
+)
+
+func TestFuncCoverage(t *testing.T) {
+	rg := &ReportGenerator{
+		symbols: []symbol{
+			{name: "foo", start: 0x100, end: 0x110},
+			{name: "bar", start: 0x200, end: 0x210},
+			{name: "baz", start: 0x300, end: 0x310},
+		},
+		pcs: map[uint64][]symbolizer.Frame{
+			0x101: {{Func: "foo"}},
+			0x102: {{Func: "foo"}},
+			0x103: {{Func: "foo"}},
+			0x201: {{Func: "bar"}},
+			0x202: {{Func: "bar"}},
+			0x301: {{Func: "baz"}},
+		},
+	}
+	progs := []Prog{
+		{PCs: []uint64{0x101, 0x102, 0x201}},
+		// 0x400 is not a coverage callback and must be ignored.
+		{PCs: []uint64{0x102, 0x400}},
+	}
+	funcs := rg.FuncCoverage(progs)
+	want := []*FuncCover{
+		{Name: "bar", PCs: 2, Covered: 1, Progs: 1},
+		{Name: "foo", PCs: 3, Covered: 2, Progs: 2},
+	}
+	if !reflect.DeepEqual(funcs, want) {
+		t.Fatalf("bad function coverage:\ngot:  %+v\nwant: %+v", funcs, want)
+	}
+	if newFuncs := NewlyCoveredFuncs(funcs, funcs); len(newFuncs) != 0 {
+		t.Fatalf("newly covered functions in the same coverage: %+v", newFuncs)
+	}
+	progs = append(progs, Prog{PCs: []uint64{0x301}})
+	newFuncs := NewlyCoveredFuncs(funcs, rg.FuncCoverage(progs))
+	if len(newFuncs) != 1 || newFuncs[0].Name != "baz" {
+		t.Fatalf("bad newly covered functions: %+v", newFuncs)
+	}
+}
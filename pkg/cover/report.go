@@ -16,6 +16,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/symbolizer"
@@ -29,6 +30,9 @@ type ReportGenerator struct {
 	objDir   string
 	symbols  []symbol
 	pcs      map[uint64][]symbolizer.Frame
+
+	funcPCsOnce sync.Once
+	funcPCs     map[uint64]int // symbol start -> number of coverage callbacks
 }
 
 type Prog struct {
@@ -37,6 +41,7 @@ type Prog struct {
 }
 
 type symbol struct {
+	name  string
 	start uint64
 	end   uint64
 }
@@ -166,18 +171,9 @@ func (rg *ReportGenerator) generate(w io.Writer, progs []Prog, files map[string]
 		Root: new(templateDir),
 	}
 	for fname, file := range files {
-		remain := ""
-		switch {
-		case strings.HasPrefix(fname, rg.objDir):
-			// Assume the file was built there.
-			remain = filepath.Clean(strings.TrimPrefix(fname, rg.objDir))
-		case strings.HasPrefix(fname, rg.buildDir):
-			// Assume the file was moved from buildDir to srcDir.
-			remain = filepath.Clean(strings.TrimPrefix(fname, rg.buildDir))
-			fname = filepath.Join(rg.srcDir, remain)
-		default:
-			return fmt.Errorf("path %q doesn't match build dir %q nor obj dir %q",
-				fname, rg.buildDir, rg.objDir)
+		fname, remain, err := rg.sourcePath(fname)
+		if err != nil {
+			return err
 		}
 		pos := d.Root
 		path := ""
@@ -304,17 +300,25 @@ func percent(covered, total int) int {
 }
 
 func (rg *ReportGenerator) findSymbol(pc uint64) uint64 {
+	s := rg.lookupSymbol(pc)
+	if s == nil {
+		return 0
+	}
+	return s.start
+}
+
+func (rg *ReportGenerator) lookupSymbol(pc uint64) *symbol {
 	idx := sort.Search(len(rg.symbols), func(i int) bool {
 		return pc < rg.symbols[i].end
 	})
 	if idx == len(rg.symbols) {
-		return 0
+		return nil
 	}
-	s := rg.symbols[idx]
+	s := &rg.symbols[idx]
 	if pc < s.start || pc > s.end {
-		return 0
+		return nil
 	}
-	return s.start
+	return s
 }
 
 func readSymbols(target *targets.Target, obj string) ([]symbol, error) {
@@ -324,9 +328,10 @@ func readSymbols(target *targets.Target, obj string) ([]symbol, error) {
 		return nil, fmt.Errorf("failed to run nm on %v: %v", obj, err)
 	}
 	var symbols []symbol
-	for _, ss := range raw {
+	for name, ss := range raw {
 		for _, s := range ss {
 			symbols = append(symbols, symbol{
+				name:  name,
 				start: s.Addr,
 				end:   s.Addr + uint64(s.Size),
 			})
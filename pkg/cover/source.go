@@ -0,0 +1,177 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// FuncSource returns source code of the function fn annotated with coverage of progs
+// (e.g. to show how the fuzzer reached a crashing function). Covered lines are marked with "+",
+// uncovered lines with "-", lines without coverage callbacks are not marked.
+// context is the number of additional lines to show around the function.
+// The source is followed by coverage summary of up to nearby functions located before
+// and after fn in the binary (usually these are functions from the same source file).
+func (rg *ReportGenerator) FuncSource(progs []Prog, fn string, context, nearby int) ([]byte, error) {
+	idx := -1
+	for i := range rg.symbols {
+		if rg.symbols[i].name == fn {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		return nil, fmt.Errorf("no function %v", fn)
+	}
+	sym := &rg.symbols[idx]
+	first, last := idx-nearby, idx+nearby
+	if first < 0 {
+		first = 0
+	}
+	if last >= len(rg.symbols) {
+		last = len(rg.symbols) - 1
+	}
+	coveredPCs := make(map[uint64]bool)
+	for _, prog := range progs {
+		for _, pc := range prog.PCs {
+			coveredPCs[pc] = true
+		}
+	}
+	// Function's own lines are non-inlined frames, there may be several files if it includes other files.
+	fileLines := make(map[string]map[int]bool)
+	funcs := make(map[*symbol]*FuncCover)
+	for pc, frames := range rg.pcs {
+		if pc < rg.symbols[first].start || pc >= rg.symbols[last].end {
+			continue
+		}
+		s := rg.lookupSymbol(pc)
+		if s == nil {
+			continue
+		}
+		fc := funcs[s]
+		if fc == nil {
+			fc = &FuncCover{Name: s.name}
+			funcs[s] = fc
+		}
+		fc.PCs++
+		if coveredPCs[pc] {
+			fc.Covered++
+		}
+		if s != sym {
+			continue
+		}
+		for _, frame := range frames {
+			if frame.Inline {
+				continue
+			}
+			lines := fileLines[frame.File]
+			if lines == nil {
+				lines = make(map[int]bool)
+				fileLines[frame.File] = lines
+			}
+			lines[frame.Line] = lines[frame.Line] || coveredPCs[pc]
+		}
+	}
+	fname := ""
+	for f, lines := range fileLines {
+		if fname == "" || len(lines) > len(fileLines[fname]) || len(lines) == len(fileLines[fname]) && f < fname {
+			fname = f
+		}
+	}
+	if fname == "" {
+		return nil, fmt.Errorf("function %v does not have any coverage", fn)
+	}
+	path, remain, err := rg.sourcePath(fname)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "%v (%v): %v out of %v coverage points covered\n",
+		fn, remain, funcs[sym].Covered, funcs[sym].PCs)
+	formatFuncSource(buf, strings.Split(string(data), "\n"), fileLines[fname], context)
+	var around []*FuncCover
+	for i := first; i <= last; i++ {
+		// Functions without coverage callbacks are not interesting (and not present in funcs).
+		if fc := funcs[&rg.symbols[i]]; fc != nil {
+			around = append(around, fc)
+		}
+	}
+	formatNearbyFuncs(buf, around, fn)
+	return buf.Bytes(), nil
+}
+
+// sourcePath returns path of the source file fname (as present in debug info) on this machine
+// and path relative to the kernel source dir.
+func (rg *ReportGenerator) sourcePath(fname string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(fname, rg.objDir):
+		// Assume the file was built there.
+		return fname, filepath.Clean(strings.TrimPrefix(fname, rg.objDir)), nil
+	case strings.HasPrefix(fname, rg.buildDir):
+		// Assume the file was moved from buildDir to srcDir.
+		remain := filepath.Clean(strings.TrimPrefix(fname, rg.buildDir))
+		return filepath.Join(rg.srcDir, remain), remain, nil
+	default:
+		return "", "", fmt.Errorf("path %q doesn't match build dir %q nor obj dir %q",
+			fname, rg.buildDir, rg.objDir)
+	}
+}
+
+func formatFuncSource(buf *bytes.Buffer, src []string, lines map[int]bool, context int) {
+	first, last := 0, 0
+	for line := range lines {
+		if first == 0 || line < first {
+			first = line
+		}
+		if line > last {
+			last = line
+		}
+	}
+	first -= context
+	if first < 1 {
+		first = 1
+	}
+	last += context
+	if last > len(src) {
+		last = len(src)
+	}
+	for line := first; line <= last; line++ {
+		mark := " "
+		if cov, ok := lines[line]; ok {
+			mark = "-"
+			if cov {
+				mark = "+"
+			}
+		}
+		text := fmt.Sprintf("%v %5v %v", mark, line, strings.Replace(src[line-1], "\t", "        ", -1))
+		buf.WriteString(strings.TrimRight(text, " "))
+		buf.WriteByte('\n')
+	}
+}
+
+func formatNearbyFuncs(buf *bytes.Buffer, funcs []*FuncCover, fn string) {
+	if len(funcs) <= 1 {
+		return
+	}
+	buf.WriteString("\nnearby functions (covered/total coverage points):\n")
+	for _, fc := range funcs {
+		mark := "-"
+		if fc.Covered != 0 {
+			mark = "+"
+		}
+		crashed := ""
+		if fc.Name == fn {
+			crashed = " <- crash"
+		}
+		fmt.Fprintf(buf, "%v %v %v/%v%v\n", mark, fc.Name, fc.Covered, fc.PCs, crashed)
+	}
+}
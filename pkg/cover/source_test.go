@@ -0,0 +1,64 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package cover
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestFormatFuncSource(t *testing.T) {
+	src := strings.Split(`#include <foo.h>
+
+int foo(int x)
+{
+	if (x)
+		return bar(x);
+	return 0;
+}
+`, "\n")
+	lines := map[int]bool{
+		5: true,
+		6: false,
+		7: true,
+	}
+	buf := new(bytes.Buffer)
+	formatFuncSource(buf, src, lines, 2)
+	want := `      3 int foo(int x)
+      4 {
++     5         if (x)
+-     6                 return bar(x);
++     7         return 0;
+      8 }
+      9
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFormatNearbyFuncs(t *testing.T) {
+	buf := new(bytes.Buffer)
+	formatNearbyFuncs(buf, []*FuncCover{
+		{Name: "foo_init", PCs: 4},
+		{Name: "foo", PCs: 10, Covered: 3},
+		{Name: "foo_exit", PCs: 2, Covered: 2},
+	}, "foo")
+	want := `
+nearby functions (covered/total coverage points):
+- foo_init 0/4
++ foo 3/10 <- crash
++ foo_exit 2/2
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+	// Nothing to show if there are no other functions.
+	buf.Reset()
+	formatNearbyFuncs(buf, []*FuncCover{{Name: "foo", PCs: 10}}, "foo")
+	if buf.Len() != 0 {
+		t.Fatalf("got:\n%s\nwant nothing", buf.String())
+	}
+}
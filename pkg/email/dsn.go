@@ -0,0 +1,116 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+)
+
+// Bounce describes a delivery status notification (RFC 3464).
+type Bounce struct {
+	Recipients []string // recipients the delivery failed for
+	Status     string   // status of the first failed recipient (e.g. 5.1.1)
+	MessageID  string   // Message-ID of the undelivered email, if included
+	BugID      string   // bug ID extracted from addresses of the undelivered email
+}
+
+// isDSN checks if the email is a multipart/report delivery status notification.
+func isDSN(hdr mail.Header) bool {
+	mediaType, params, err := mime.ParseMediaType(hdr.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/report" &&
+		strings.EqualFold(params["report-type"], "delivery-status")
+}
+
+func parseDSN(body []byte, hdr mail.Header, ownAddrs map[string]bool) (*Bounce, error) {
+	_, params, err := mime.ParseMediaType(hdr.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email header 'Content-Type': %v", err)
+	}
+	bounce := new(Bounce)
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse MIME parts: %v", err)
+		}
+		data, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MIME part: %v", err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		switch mediaType {
+		case "message/delivery-status":
+			if err := parseDeliveryStatus(bounce, data); err != nil {
+				return nil, err
+			}
+		case "message/rfc822", "text/rfc822-headers":
+			// The headers may be not terminated by an empty line.
+			orig, err := mail.ReadMessage(bytes.NewReader(append(data, "\r\n\r\n"...)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse undelivered email: %v", err)
+			}
+			bounce.MessageID = orig.Header.Get("Message-ID")
+			for _, field := range []string{"From", "To", "Cc"} {
+				addrs, _ := orig.Header.AddressList(field)
+				for _, addr := range addrs {
+					cleaned, context, _ := RemoveAddrContext(addr.Address)
+					if addr, err := mail.ParseAddress(cleaned); err == nil {
+						cleaned = addr.Address
+					}
+					if bounce.BugID == "" && ownAddrs[cleaned] {
+						bounce.BugID = context
+					}
+				}
+			}
+		}
+	}
+	return bounce, nil
+}
+
+// parseDeliveryStatus parses message/delivery-status content: a per-message block of fields
+// followed by per-recipient blocks, all separated by empty lines.
+func parseDeliveryStatus(bounce *Bounce, data []byte) error {
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	for first := true; ; first = false {
+		fields, err := r.ReadMIMEHeader()
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to parse delivery status: %v", err)
+		}
+		if !first && len(fields) != 0 {
+			action := strings.ToLower(fields.Get("Action"))
+			status := strings.TrimSpace(fields.Get("Status"))
+			if action == "failed" || action == "" && strings.HasPrefix(status, "5.") {
+				recipient := fields.Get("Final-Recipient")
+				if recipient == "" {
+					recipient = fields.Get("Original-Recipient")
+				}
+				// The field has form "address-type; address".
+				if pos := strings.IndexByte(recipient, ';'); pos != -1 {
+					recipient = recipient[pos+1:]
+				}
+				if recipient = strings.TrimSpace(recipient); recipient != "" {
+					bounce.Recipients = append(bounce.Recipients, recipient)
+					if bounce.Status == "" {
+						bounce.Status = status
+					}
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
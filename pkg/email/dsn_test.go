@@ -0,0 +1,127 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package email
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDSN(t *testing.T) {
+	text := `Date: Sun, 7 May 2017 19:54:00 -0700
+From: Mail Delivery Subsystem <mailer-daemon@googlemail.com>
+To: syzbot+4564456@syzkaller.com
+Subject: Delivery Status Notification (Failure)
+Message-ID: <dsn123@mail.gmail.com>
+Content-Type: multipart/report; report-type=delivery-status; boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Type: text/plain; charset="UTF-8"
+
+** Address not found **
+
+Your message wasn't delivered to gone@foo.com.
+
+#syz invalid
+--BOUNDARY
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; googlemail.com
+Arrival-Date: Sun, 7 May 2017 19:53:58 -0700
+
+Final-Recipient: rfc822; gone@foo.com
+Action: failed
+Status: 5.1.1
+Diagnostic-Code: smtp; 550 5.1.1 User unknown
+
+Final-Recipient: rfc822; ok@bar.com
+Action: delayed
+Status: 4.4.1
+
+Original-Recipient: rfc822;gone2@foo.com
+Status: 5.0.0
+--BOUNDARY
+Content-Type: text/rfc822-headers
+
+From: syzbot <syzbot+4564456@syzkaller.com>
+To: gone@foo.com, ok@bar.com
+Subject: KASAN: use-after-free Read in foo
+Message-ID: <000000000000abcdef@google.com>
+--BOUNDARY--
+`
+	for _, eol := range []string{"\n", "\r\n"} {
+		msg, err := Parse(strings.NewReader(strings.Replace(text, "\n", eol, -1)),
+			[]string{"syzbot@syzkaller.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := &Bounce{
+			Recipients: []string{"gone@foo.com", "gone2@foo.com"},
+			Status:     "5.1.1",
+			MessageID:  "<000000000000abcdef@google.com>",
+			BugID:      "4564456",
+		}
+		if !reflect.DeepEqual(msg.Bounce, want) {
+			t.Fatalf("bad bounce:\nwant: %+v\ngot:  %+v", want, msg.Bounce)
+		}
+		if msg.Command != CmdNone {
+			t.Fatalf("bounce has command %v", msg.Command)
+		}
+	}
+}
+
+func TestParseNotDSN(t *testing.T) {
+	text := `From: foo@bar.com
+To: syzbot+4564456@syzkaller.com
+Subject: Re: BUG
+Content-Type: multipart/report; report-type=disposition-notification; boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Type: text/plain
+
+Message was read.
+--BOUNDARY--
+`
+	msg, err := Parse(strings.NewReader(text), []string{"syzbot@syzkaller.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Bounce != nil || msg.BounceError != nil {
+		t.Fatalf("unexpected bounce: %+v, %v", msg.Bounce, msg.BounceError)
+	}
+}
+
+func TestParseBrokenDSN(t *testing.T) {
+	text := `From: Mail Delivery Subsystem <mailer-daemon@googlemail.com>
+To: syzbot+4564456@syzkaller.com
+Subject: Delivery Status Notification (Failure)
+Content-Type: multipart/report; report-type=delivery-status; boundary="BOUNDARY"
+
+--BOUNDARY
+Content-Type: text/plain
+
+Your message wasn't delivered.
+--BOUNDARY
+Content-Type: message/delivery-status
+
+Reporting-MTA: dns; googlemail.com
+
+this is not a header
+--BOUNDARY--
+`
+	msg, err := Parse(strings.NewReader(text), []string{"syzbot@syzkaller.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Bounce != nil {
+		t.Fatalf("unexpected bounce: %+v", msg.Bounce)
+	}
+	if msg.BounceError == nil {
+		t.Fatalf("no bounce parsing error")
+	}
+	if msg.BugID != "4564456" {
+		t.Fatalf("bad bug ID: %q", msg.BugID)
+	}
+}
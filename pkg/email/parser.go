@@ -4,6 +4,7 @@
 package email
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"io"
@@ -29,6 +30,14 @@ type Email struct {
 	Command     Command // command to bot
 	CommandStr  string  // string representation of the command
 	CommandArgs string  // arguments for the command
+	// AutoSubmitted is set if the email was sent by an autoresponder/bot
+	// (out-of-office replies, delivery notifications, etc).
+	AutoSubmitted bool
+	// Bounce is set if the email is a delivery status notification about a failed delivery.
+	Bounce *Bounce
+	// BounceError is set if the email looks like a delivery status notification,
+	// but can't be parsed as one. Such emails are handled as normal emails.
+	BounceError error
 }
 
 type Command int
@@ -43,10 +52,17 @@ const (
 	CmdTest
 	CmdInvalid
 	CmdUnCC
+	CmdBisectCorrect
+	CmdBisectWrong
+	CmdHelp
 
 	cmdTest5
 )
 
+// DryRunFlag is an optional first argument of the test command ("#syz test: --dry-run repo branch").
+// With it the request is only validated, but no testing job is created.
+const DryRunFlag = "--dry-run"
+
 var groupsLinkRe = regexp.MustCompile("\nTo view this discussion on the web visit" +
 	" (https://groups\\.google\\.com/.*?)\\.(?:\r)?\n")
 
@@ -96,15 +112,27 @@ func Parse(r io.Reader, ownEmails []string) (*Email, error) {
 		}
 	}
 	ccList = MergeEmailLists(ccList)
-	body, attachments, err := parseBody(msg.Body, msg.Header)
+	rawBody, err := ioutil.ReadAll(msg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email body: %v", err)
+	}
+	body, attachments, err := parseBody(bytes.NewReader(rawBody), msg.Header)
 	if err != nil {
 		return nil, err
 	}
+	var bounce *Bounce
+	var bounceErr error
+	if isDSN(msg.Header) {
+		// Malformed notifications are handled as normal emails rather than dropped.
+		if bounce, bounceErr = parseDSN(rawBody, msg.Header, ownAddrs); bounceErr != nil {
+			bounce = nil
+		}
+	}
 	bodyStr := string(body)
 	subject := msg.Header.Get("Subject")
 	cmd := CmdNone
 	patch, cmdStr, cmdArgs := "", "", ""
-	if !fromMe {
+	if !fromMe && bounce == nil {
 		for _, a := range attachments {
 			_, patch, _ = ParsePatch(string(a))
 			if patch != "" {
@@ -132,10 +160,24 @@ func Parse(r io.Reader, ownEmails []string) (*Email, error) {
 		Command:     cmd,
 		CommandStr:  cmdStr,
 		CommandArgs: cmdArgs,
+
+		AutoSubmitted: isAutoSubmitted(msg.Header),
+		Bounce:        bounce,
+		BounceError:   bounceErr,
 	}
 	return email, nil
 }
 
+// isAutoSubmitted checks if the email was generated automatically as described in RFC 3834.
+// It also checks some non-standard headers set by popular autoresponders.
+func isAutoSubmitted(hdr mail.Header) bool {
+	if val := strings.ToLower(strings.TrimSpace(hdr.Get("Auto-Submitted"))); val != "" && val != "no" {
+		return true
+	}
+	return hdr.Get("X-Autoreply") != "" || hdr.Get("X-Autorespond") != "" ||
+		strings.EqualFold(strings.TrimSpace(hdr.Get("Precedence")), "auto_reply")
+}
+
 // AddAddrContext embeds context into local part of the provided email address using '+'.
 // Returns the resulting email address.
 func AddAddrContext(email, context string) (string, error) {
@@ -242,20 +284,34 @@ func extractCommand(body string) (cmd Command, str, args string) {
 		cmd = CmdInvalid
 	case "uncc", "uncc:":
 		cmd = CmdUnCC
+	case "bisect-correct", "bisect-correct:":
+		cmd = CmdBisectCorrect
+	case "bisect-wrong", "bisect-wrong:":
+		cmd = CmdBisectWrong
+	case "help", "help:":
+		cmd = CmdHelp
 	case "test_5_arg_cmd":
 		cmd = cmdTest5
 	}
 	// Some email clients split text emails at 80 columns are the transformation is irrevesible.
 	// We try hard to restore what was there before.
-	// For "test:" command we know that there must be 2 tokens without spaces.
+	// For "test:" command we know that there must be 2 tokens without spaces
+	// (plus the optional dry run flag).
 	// For "fix:"/"dup:" we need a whole non-empty line of text.
 	switch cmd {
 	case CmdTest:
 		args = extractArgsTokens(body[cmdPos+cmdEnd:], 2)
+		if args == DryRunFlag || strings.HasPrefix(args, DryRunFlag+" ") {
+			args = extractArgsTokens(body[cmdPos+cmdEnd:], 3)
+		}
 	case cmdTest5:
 		args = extractArgsTokens(body[cmdPos+cmdEnd:], 5)
 	case CmdFix, CmdDup:
 		args = extractArgsLine(body[cmdPos+cmdEnd:])
+	case CmdBisectCorrect, CmdBisectWrong:
+		// The optional bisection type (cause/fix) must be on the same line,
+		// otherwise we would take random quoted text as the argument.
+		args = extractArgsSameLine(body[cmdPos+cmdEnd:])
 	}
 	return
 }
@@ -296,6 +352,14 @@ func extractArgsLine(body string) string {
 	return strings.TrimSpace(body[pos : pos+lineEnd])
 }
 
+func extractArgsSameLine(body string) string {
+	lineEnd := strings.IndexByte(body, '\n')
+	if lineEnd == -1 {
+		lineEnd = len(body)
+	}
+	return strings.TrimSpace(body[:lineEnd])
+}
+
 func parseBody(r io.Reader, headers mail.Header) ([]byte, [][]byte, error) {
 	// git-send-email sends emails without Content-Type, let's assume it's text.
 	mediaType := "text/plain"
@@ -222,6 +222,51 @@ locking/core
 	},
 	{
 		body: `
+#syz test --dry-run
+git://git.kernel.org/pub/scm/linux/kernel/git/tip/tip.git
+locking/core
+locking/core
+`,
+		cmd:  CmdTest,
+		str:  "test",
+		args: "--dry-run git://git.kernel.org/pub/scm/linux/kernel/git/tip/tip.git locking/core",
+	},
+	{
+		body: `
+#syz test: --dry-run git://git.kernel.org/pub/scm/linux/kernel/git/tip/tip.git master
+`,
+		cmd:  CmdTest,
+		str:  "test:",
+		args: "--dry-run git://git.kernel.org/pub/scm/linux/kernel/git/tip/tip.git master",
+	},
+	{
+		body: `
+#syz help
+> quoted text
+`,
+		cmd:  CmdHelp,
+		str:  "help",
+		args: "",
+	},
+	{
+		body: `
+#syz bisect-correct
+> quoted text
+`,
+		cmd:  CmdBisectCorrect,
+		str:  "bisect-correct",
+		args: "",
+	},
+	{
+		body: `
+#syz bisect-wrong fix
+`,
+		cmd:  CmdBisectWrong,
+		str:  "bisect-wrong",
+		args: "fix",
+	},
+	{
+		body: `
 #syz test_5_arg_cmd arg1
 
  arg2  arg3
@@ -693,4 +738,42 @@ nothing to see here`,
 			CommandStr:  "test:",
 			CommandArgs: "git://git.kernel.org/pub/scm/linux/kernel/git/tip/tip.git master",
 		}},
+
+	{`Date: Sun, 7 May 2017 19:54:00 -0700
+Message-ID: <123>
+Subject: Re: test subject
+From: bob@example.com
+To: syzbot <foo+4564456@bar.com>
+Auto-Submitted: auto-replied
+
+I am out of office.`,
+		Email{
+			BugID:         "4564456",
+			MessageID:     "<123>",
+			Subject:       "Re: test subject",
+			From:          "<bob@example.com>",
+			Cc:            []string{"bob@example.com"},
+			Body:          `I am out of office.`,
+			Command:       CmdNone,
+			AutoSubmitted: true,
+		}},
+
+	{`Date: Sun, 7 May 2017 19:54:00 -0700
+Message-ID: <123>
+Subject: Re: test subject
+From: bob@example.com
+To: syzbot <foo+4564456@bar.com>
+Auto-Submitted: no
+
+#syz invalid`,
+		Email{
+			BugID:      "4564456",
+			MessageID:  "<123>",
+			Subject:    "Re: test subject",
+			From:       "<bob@example.com>",
+			Cc:         []string{"bob@example.com"},
+			Body:       `#syz invalid`,
+			Command:    CmdInvalid,
+			CommandStr: "invalid",
+		}},
 }
@@ -12,6 +12,13 @@ type Config struct {
 	Target string `json:"target"`
 	// URL that will display information about the running syz-manager process (e.g. "localhost:50000").
 	HTTP string `json:"http"`
+	// Token that must be passed in "Authorization: Bearer <token>" header to access
+	// the JSON API served under /api/v1/ on the HTTP address (optional).
+	// If not set, read-only API calls are accessible without authentication, same as the web UI,
+	// and calls that change the manager state (e.g. POST /api/v1/exclude) are rejected.
+	// Note: the token does not protect the web UI (including /file), which serves the same data,
+	// so restrict access to the HTTP address by other means if the data is sensitive.
+	HTTPAPIToken string `json:"http_api_token,omitempty"`
 	// TCP address to serve RPC for fuzzer processes (optional).
 	RPC string `json:"rpc,omitempty"`
 	// Location of a working directory for the syz-manager process. Outputs here include:
@@ -62,6 +69,10 @@ type Config struct {
 	DashboardClient string `json:"dashboard_client,omitempty"`
 	DashboardAddr   string `json:"dashboard_addr,omitempty"`
 	DashboardKey    string `json:"dashboard_key,omitempty"`
+	// Download reproducers of bugs in the dashboard namespace on start
+	// and use them as fuzzing candidates (optional).
+	// Only syz reproducers are downloaded, corpus is not shared via the dashboard.
+	DashboardSeed bool `json:"dashboard_seed,omitempty"`
 
 	// Location of the syzkaller checkout, syz-manager will look
 	// for binaries in bin subdir (does not have to be syzkaller checkout as
@@ -88,11 +99,33 @@ type Config struct {
 	// Reproduce, localize and minimize crashers (default: true).
 	Reproduce bool `json:"reproduce"`
 
+	// Strategy used to choose corpus programs for mutation:
+	// "prio": choose programs proportionally to their signal size, default
+	// "energy": AFL-style power schedule, program energy is derived from its signal size,
+	//	how many times it was already fuzzed, its execution speed and how many
+	//	new inputs its mutants produced recently.
+	Schedule string `json:"schedule,omitempty"`
+
+	// Bounds for the number of calls in generated and mutated programs (optional).
+	// If set, the fuzzer adapts the target number of calls within these bounds
+	// depending on which program lengths give the most new signal per second of execution.
+	// If not set, the fuzzer always targets a fixed number of calls.
+	MinCalls int `json:"min_calls,omitempty"`
+	MaxCalls int `json:"max_calls,omitempty"`
+
 	// List of syscalls to test (optional). For example:
 	//	"enable_syscalls": [ "mmap", "openat$ashmem", "ioctl$ASHMEM*" ]
 	EnabledSyscalls []string `json:"enable_syscalls,omitempty"`
 	// List of system calls that should be treated as disabled (optional).
 	DisabledSyscalls []string `json:"disable_syscalls,omitempty"`
+	// List of kernel source paths to exclude from fuzzing (optional), e.g. fragile subsystems.
+	// Patterns are relative to kernel_src and are matched against the file and all its parent dirs,
+	// for example: "exclude_paths": [ "drivers/staging/*", "net/sctp" ].
+	// Inputs with most of the coverage in these paths are chosen for mutation less often
+	// (they are still kept in the corpus).
+	// The list can be changed at runtime with the /api/v1/exclude API call (requires http_api_token).
+	// Requires kernel_obj.
+	ExcludePaths []string `json:"exclude_paths,omitempty"`
 	// List of regexps for known bugs.
 	// Don't save reports matching these regexps, but reboot VM after them,
 	// matched against whole report output.
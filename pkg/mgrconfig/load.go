@@ -60,6 +60,7 @@ func defaultValues() *Config {
 		SSHUser:   "root",
 		Cover:     true,
 		Reproduce: true,
+		Schedule:  "prio",
 		Sandbox:   "none",
 		RPC:       ":0",
 		Procs:     6,
@@ -112,9 +113,23 @@ func Complete(cfg *Config) error {
 	default:
 		return fmt.Errorf("config param sandbox must contain one of none/setuid/namespace/android")
 	}
+	switch cfg.Schedule {
+	case "prio", "energy":
+	default:
+		return fmt.Errorf("config param schedule must contain one of prio/energy")
+	}
+	if cfg.MinCalls != 0 || cfg.MaxCalls != 0 {
+		if cfg.MinCalls < 1 || cfg.MinCalls > cfg.MaxCalls || cfg.MaxCalls > prog.MaxCalls {
+			return fmt.Errorf("bad config params min_calls/max_calls: %v/%v, want 1 <= min_calls <= max_calls <= %v",
+				cfg.MinCalls, cfg.MaxCalls, prog.MaxCalls)
+		}
+	}
 	if err := checkSSHParams(cfg); err != nil {
 		return err
 	}
+	if err := checkExcludePaths(cfg); err != nil {
+		return err
+	}
 	cfg.CompleteKernelDirs()
 
 	if cfg.HubClient != "" {
@@ -126,6 +141,9 @@ func Complete(cfg *Config) error {
 			return err
 		}
 	}
+	if cfg.DashboardSeed && cfg.DashboardClient == "" {
+		return fmt.Errorf("dashboard_seed requires dashboard_client")
+	}
 	if cfg.DashboardClient != "" {
 		if err := checkNonEmpty(
 			cfg.Name, "name",
@@ -174,6 +192,21 @@ func checkSSHParams(cfg *Config) error {
 	return nil
 }
 
+func checkExcludePaths(cfg *Config) error {
+	if len(cfg.ExcludePaths) == 0 {
+		return nil
+	}
+	if cfg.KernelObj == "" {
+		return fmt.Errorf("exclude_paths requires kernel_obj")
+	}
+	for _, pattern := range cfg.ExcludePaths {
+		if _, err := filepath.Match(pattern, ""); err != nil || pattern == "" || filepath.IsAbs(pattern) {
+			return fmt.Errorf("bad config param exclude_paths: %q", pattern)
+		}
+	}
+	return nil
+}
+
 func completeBinaries(cfg *Config) error {
 	sysTarget := targets.Get(cfg.TargetOS, cfg.TargetArch)
 	if sysTarget == nil {
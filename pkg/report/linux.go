@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/symbolizer"
 	"github.com/google/syzkaller/pkg/vcs"
@@ -22,6 +23,7 @@ type linux struct {
 	*config
 	vmlinux               string
 	symbols               map[string][]symbolizer.Symbol
+	maintainers           []*maintainersEntry
 	consoleOutputRe       *regexp.Regexp
 	taskContext           *regexp.Regexp
 	cpuContext            *regexp.Regexp
@@ -44,10 +46,19 @@ func ctorLinux(cfg *config) (Reporter, []string, error) {
 			return nil, nil, err
 		}
 	}
+	var maintainers []*maintainersEntry
+	if cfg.kernelSrc != "" {
+		var err error
+		// Subsystems are optional, so don't fail if MAINTAINERS is broken.
+		if maintainers, err = loadLinuxMaintainers(cfg.kernelSrc); err != nil {
+			log.Logf(0, "failed to load MAINTAINERS: %v", err)
+		}
+	}
 	ctx := &linux{
-		config:  cfg,
-		vmlinux: vmlinux,
-		symbols: symbols,
+		config:      cfg,
+		vmlinux:     vmlinux,
+		symbols:     symbols,
+		maintainers: maintainers,
 	}
 	// nolint: lll
 	ctx.consoleOutputRe = regexp.MustCompile(`^(?:\*\* [0-9]+ printk messages dropped \*\* )?(?:.* login: )?(?:\<[0-9]+\>)?\[ *[0-9]+\.[0-9]+\](\[ *(?:C|T)[0-9]+\])? `)
@@ -347,6 +358,7 @@ func (ctx *linux) Symbolize(rep *Report) error {
 	// because tests pass in already symbolized input.
 	rep.guiltyFile = ctx.extractGuiltyFile(rep)
 	if rep.guiltyFile != "" {
+		rep.Subsystems = matchSubsystems(ctx.maintainers, rep.guiltyFile)
 		maintainers, err := ctx.getMaintainers(rep.guiltyFile)
 		if err != nil {
 			return err
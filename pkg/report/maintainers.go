@@ -0,0 +1,186 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+)
+
+// maintainersEntry is a single section of the Linux MAINTAINERS file.
+type maintainersEntry struct {
+	name     string
+	files    []*regexp.Regexp // F: patterns
+	excludes []*regexp.Regexp // X: patterns
+	regexps  []*regexp.Regexp // N: patterns
+}
+
+// loadLinuxMaintainers parses MAINTAINERS file in the kernel source dir.
+// Returns nil if the file does not exist. Parsed files are cached since reporters
+// are created frequently (e.g. for every build and bisection step).
+func loadLinuxMaintainers(kernelSrc string) ([]*maintainersEntry, error) {
+	file := filepath.Join(kernelSrc, "MAINTAINERS")
+	stat, err := os.Stat(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	maintainersCache.Lock()
+	defer maintainersCache.Unlock()
+	if cached := maintainersCache.files[file]; cached != nil &&
+		cached.modTime.Equal(stat.ModTime()) && cached.size == stat.Size() {
+		return cached.entries, nil
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := parseLinuxMaintainers(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MAINTAINERS: %v", err)
+	}
+	if maintainersCache.files == nil {
+		maintainersCache.files = make(map[string]*maintainersFile)
+	}
+	maintainersCache.files[file] = &maintainersFile{
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		entries: entries,
+	}
+	return entries, nil
+}
+
+type maintainersFile struct {
+	modTime time.Time
+	size    int64
+	entries []*maintainersEntry
+}
+
+var maintainersCache struct {
+	sync.Mutex
+	files map[string]*maintainersFile
+}
+
+func parseLinuxMaintainers(r io.Reader) ([]*maintainersEntry, error) {
+	var entries []*maintainersEntry
+	var cur *maintainersEntry
+	name := ""
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t")
+		if line == "" {
+			cur, name = nil, ""
+			continue
+		}
+		match := maintainersLineRe.FindStringSubmatch(line)
+		if match == nil {
+			// The first line of a section is its name. Other lines are part of the preamble.
+			if cur == nil && name == "" {
+				name = line
+			}
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		if cur == nil {
+			cur = &maintainersEntry{name: name}
+			entries = append(entries, cur)
+		}
+		switch match[1] {
+		case "F", "X":
+			re, err := maintainersPatternRe(match[2])
+			if err != nil {
+				log.Logf(0, "MAINTAINERS: section %q: ignoring bad %v: pattern: %v", cur.name, match[1], err)
+				continue
+			}
+			if match[1] == "F" {
+				cur.files = append(cur.files, re)
+			} else {
+				cur.excludes = append(cur.excludes, re)
+			}
+		case "N":
+			// N: patterns are Perl regexps, some of them are not supported by Go.
+			re, err := regexp.Compile(match[2])
+			if err != nil {
+				log.Logf(0, "MAINTAINERS: section %q: ignoring bad N: pattern: %v", cur.name, err)
+				continue
+			}
+			cur.regexps = append(cur.regexps, re)
+		}
+	}
+	return entries, s.Err()
+}
+
+var maintainersLineRe = regexp.MustCompile(`^([A-Z]):\s*(.*)$`)
+
+// maintainersPatternRe converts F:/X: glob pattern to a regexp.
+// Patterns ending with / match all files in the directory and subdirectories,
+// wildcard patterns match only files in the same directory, other patterns match
+// the file and, if the pattern denotes a directory, all files in it.
+func maintainersPatternRe(pattern string) (*regexp.Regexp, error) {
+	var buf strings.Builder
+	buf.WriteString("^")
+	for _, c := range pattern {
+		switch c {
+		case '*':
+			buf.WriteString("[^/]*")
+		case '?':
+			buf.WriteString("[^/]")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	switch {
+	case strings.HasSuffix(pattern, "/"):
+	case strings.ContainsAny(pattern, "*?"):
+		buf.WriteString("$")
+	default:
+		buf.WriteString("(?:/|$)")
+	}
+	return regexp.Compile(buf.String())
+}
+
+// matchSubsystems returns names of MAINTAINERS sections that cover the file.
+func matchSubsystems(entries []*maintainersEntry, file string) []string {
+	var res []string
+	for _, entry := range entries {
+		if entry.name == "THE REST" || !entry.match(file) {
+			continue
+		}
+		res = append(res, entry.name)
+	}
+	return res
+}
+
+func (entry *maintainersEntry) match(file string) bool {
+	for _, re := range entry.excludes {
+		if re.MatchString(file) {
+			return false
+		}
+	}
+	for _, re := range entry.files {
+		if re.MatchString(file) {
+			return true
+		}
+	}
+	for _, re := range entry.regexps {
+		if re.MatchString(file) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package report
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+func TestMatchSubsystems(t *testing.T) {
+	const maintainers = `
+List of maintainers and how to submit kernel changes
+====================================================
+
+Descriptions of section entries and preferred order
+---------------------------------------------------
+
+	M: *Mail* patches to: FullName <address@domain>
+	F: *Files* and directories wildcard patterns.
+
+Maintainers List
+----------------
+
+EXT4 FILE SYSTEM
+M:	"Theodore Ts'o" <tytso@mit.edu>
+L:	linux-ext4@vger.kernel.org
+S:	Maintained
+F:	Documentation/filesystems/ext4/
+F:	fs/ext4/
+
+KERNEL VIRTUAL MACHINE (KVM)
+M:	Paolo Bonzini <pbonzini@redhat.com>
+S:	Supported
+F:	virt/kvm/*
+F:	arch/*/kvm/
+X:	arch/arm64/kvm/
+
+NETWORKING [GENERAL]
+L:	netdev@vger.kernel.org
+S:	Odd Fixes
+F:	net/
+F:	include/net/
+X:	net/bluetooth/
+
+BLUETOOTH SUBSYSTEM
+L:	linux-bluetooth@vger.kernel.org
+F:	net/bluetooth/
+
+USB SUBSYSTEM
+L:	linux-usb@vger.kernel.org
+F:	drivers/usb
+N:	(?<!lib)usbfs
+N:	usb
+
+THE REST
+L:	linux-kernel@vger.kernel.org
+F:	*
+F:	*/
+`
+	entries, err := parseLinuxMaintainers(strings.NewReader(maintainers))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("parsed %v entries, want 6", len(entries))
+	}
+	tests := []struct {
+		file       string
+		subsystems []string
+	}{
+		{"fs/ext4/inode.c", []string{"EXT4 FILE SYSTEM"}},
+		{"fs/ext2/inode.c", nil},
+		{"virt/kvm/kvm_main.c", []string{"KERNEL VIRTUAL MACHINE (KVM)"}},
+		{"virt/kvm/arm/arm.c", nil},
+		{"arch/x86/kvm/x86.c", []string{"KERNEL VIRTUAL MACHINE (KVM)"}},
+		{"arch/arm64/kvm/arm.c", nil},
+		{"net/ipv4/tcp.c", []string{"NETWORKING [GENERAL]"}},
+		{"net/bluetooth/hci_core.c", []string{"BLUETOOTH SUBSYSTEM"}},
+		{"drivers/usb/core/hub.c", []string{"USB SUBSYSTEM"}},
+		{"drivers/usbip/stub_dev.c", []string{"USB SUBSYSTEM"}},
+		{"drivers/net/tun.c", nil},
+	}
+	for _, test := range tests {
+		got := matchSubsystems(entries, test.file)
+		if !reflect.DeepEqual(got, test.subsystems) {
+			t.Errorf("file %v: got subsystems %q, want %q", test.file, got, test.subsystems)
+		}
+	}
+}
+
+func TestLoadLinuxMaintainers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "syz-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	entries, err := loadLinuxMaintainers(dir)
+	if err != nil || entries != nil {
+		t.Fatalf("missing MAINTAINERS: got %v, %v", entries, err)
+	}
+	file := filepath.Join(dir, "MAINTAINERS")
+	if err := osutil.WriteFile(file, []byte("FOO\nF:\tfoo/\nN:\t(?<!lib)foo\n")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = loadLinuxMaintainers(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].files) != 1 || len(entries[0].regexps) != 0 {
+		t.Fatalf("bad entries: %+v", entries)
+	}
+	cached, err := loadLinuxMaintainers(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 1 || cached[0] != entries[0] {
+		t.Fatalf("MAINTAINERS is parsed again")
+	}
+	if err := osutil.WriteFile(file, []byte("BAR\nF:\tbar/\n\nBAZ\nF:\tbaz/\n")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err = loadLinuxMaintainers(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].name != "BAR" {
+		t.Fatalf("changed MAINTAINERS is not reloaded: %+v", entries)
+	}
+}
@@ -25,7 +25,7 @@ type Reporter interface {
 	// Returns nil if no oops found.
 	Parse(output []byte) *Report
 
-	// Symbolize symbolizes rep.Report and fills in Maintainers.
+	// Symbolize symbolizes rep.Report and fills in Maintainers and Subsystems.
 	Symbolize(rep *Report) error
 }
 
@@ -53,6 +53,8 @@ type Report struct {
 	CorruptedReason string
 	// Recipients is a list of RecipientInfo with Email, Display Name, and type.
 	Recipients vcs.Recipients
+	// Subsystems are names of MAINTAINERS sections that cover the guilty file (filled in by Symbolize).
+	Subsystems []string
 	// guiltyFile is the source file that we think is to blame for the crash  (filled in by Symbolize).
 	guiltyFile string
 	// reportPrefixLen is length of additional prefix lines that we added before actual crash report.
@@ -238,6 +240,26 @@ func ParseAll(reporter Reporter, output []byte) (reports []*Report) {
 	}
 }
 
+// SplitCrashes returns all distinct crashes in output in the order of their first appearance.
+// Crashes with the same title are reported once, a corrupted report is replaced
+// with a later non-corrupted one for the same crash.
+func SplitCrashes(reporter Reporter, output []byte) []*Report {
+	var reports []*Report
+	seen := make(map[string]int)
+	for _, rep := range ParseAll(reporter, output) {
+		idx, ok := seen[rep.Title]
+		if !ok {
+			seen[rep.Title] = len(reports)
+			reports = append(reports, rep)
+			continue
+		}
+		if reports[idx].Corrupted && !rep.Corrupted {
+			reports[idx] = rep
+		}
+	}
+	return reports
+}
+
 // GCE console connection sometimes fails with this message.
 // The message frequently happens right after a kernel panic.
 // So if we see it in output where we recognized a crash, we mark the report as corrupted
@@ -11,6 +11,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -380,6 +381,41 @@ func TestReplace(t *testing.T) {
 	}
 }
 
+func TestSplitCrashes(t *testing.T) {
+	reporter, err := NewReporter(&mgrconfig.Config{TargetOS: "linux", TargetArch: "amd64"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := `
+[   10.000000] WARNING: CPU: 1 PID: 100 at net/core/dev.c:100 dev_foo+0x10/0x20
+[   10.000002] Call Trace:
+[   10.000003]  dev_foo+0x10/0x20 net/core/dev.c:100
+[   10.000004]  dev_bar+0x10/0x20 net/core/dev.c:200
+[   10.000005]  do_syscall_64+0x10/0x20 arch/x86/entry/common.c:290
+[   20.000000] ==================================================================
+[   20.000001] BUG: KASAN: use-after-free in sock_baz+0x10/0x20 net/core/sock.c:300
+[   20.000002] Read of size 8 at addr ffff88800000 by task syz-executor/100
+[   20.000003] Call Trace:
+[   20.000004]  sock_baz+0x10/0x20 net/core/sock.c:300
+[   20.000005]  sock_qux+0x10/0x20 net/core/sock.c:400
+[   20.000006]  do_syscall_64+0x10/0x20 arch/x86/entry/common.c:290
+[   20.000007] ==================================================================
+[   30.000000] WARNING: CPU: 0 PID: 101 at net/core/dev.c:100 dev_foo+0x10/0x20
+[   30.000001] Call Trace:
+[   30.000002]  dev_foo+0x10/0x20 net/core/dev.c:100
+[   30.000003]  dev_bar+0x10/0x20 net/core/dev.c:200
+[   30.000004]  do_syscall_64+0x10/0x20 arch/x86/entry/common.c:290
+`
+	var titles []string
+	for _, rep := range SplitCrashes(reporter, []byte(log)) {
+		titles = append(titles, rep.Title)
+	}
+	want := []string{"WARNING in dev_foo", "KASAN: use-after-free Read in sock_baz"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Fatalf("got titles %q, want %q", titles, want)
+	}
+}
+
 func TestFuzz(t *testing.T) {
 	for _, data := range []string{
 		"kernel panicType 'help' for a list of commands",
@@ -33,6 +33,9 @@ type ConnectRes struct {
 	GitRevision      string
 	TargetRevision   string
 	AllSandboxes     bool
+	Schedule         string
+	MinCalls         int
+	MaxCalls         int
 	CheckResult      *CheckArgs
 	MemoryLeakFrames []string
 	DataRaceFrames   []string
@@ -61,12 +64,35 @@ type PollArgs struct {
 	NeedCandidates bool
 	MaxSignal      signal.Serial
 	Stats          map[string]uint64
+	TriageStats    map[string]CallTriageStats
+}
+
+// CallTriageStats holds outcomes of triage of inputs with new signal for a single syscall.
+type CallTriageStats struct {
+	NewSignal    uint64 // inputs with stable new signal that were added to corpus
+	Flaky        uint64 // inputs with new signal that did not reproduce
+	NotExecuted  uint64 // inputs that repeatedly failed to execute the call during triage
+	Minimized    uint64 // inputs that were shrunk by minimization
+	RemovedCalls uint64 // total number of calls removed by minimization
+}
+
+func (stats *CallTriageStats) Merge(other CallTriageStats) {
+	stats.NewSignal += other.NewSignal
+	stats.Flaky += other.Flaky
+	stats.NotExecuted += other.NotExecuted
+	stats.Minimized += other.Minimized
+	stats.RemovedCalls += other.RemovedCalls
 }
 
 type PollRes struct {
 	Candidates []RPCCandidate
 	NewInputs  []RPCInput
 	MaxSignal  signal.Serial
+	// Coverage in excluded kernel paths (see syz-manager exclude_paths),
+	// the fuzzer drops inputs that have most of their coverage there.
+	// Sent only if UpdateExcludedCover is set.
+	ExcludedCover       []uint32
+	UpdateExcludedCover bool
 }
 
 type HubConnectArgs struct {
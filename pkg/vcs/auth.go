@@ -0,0 +1,132 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// RepoAuth describes credentials for accessing private repositories.
+// Note: git commands run sandboxed, so the key file must be readable by the sandbox user.
+type RepoAuth struct {
+	// Path to a private SSH key used for ssh:// and scp-like remotes.
+	SSHKey string `json:"ssh_key,omitempty"`
+	// Name of the environment variable that holds an HTTP access token.
+	// The token itself is never passed on command lines.
+	TokenEnv string `json:"token_env,omitempty"`
+	// User name sent along with the token (some hosts require a particular one).
+	TokenUser string `json:"token_user,omitempty"`
+	// Git credential helpers (see gitcredentials(7)), tried in order after the token.
+	CredentialHelpers []string `json:"credential_helpers,omitempty"`
+}
+
+// Authenticator may be optionally implemented by Repo.
+type Authenticator interface {
+	// SetAuth sets credentials for remote operations (Poll, CheckoutBranch, CheckoutCommit, etc)
+	// with the repo at the given address. Credentials are never sent to other remotes
+	// (e.g. to repos provided in patch testing requests).
+	SetAuth(repo string, auth *RepoAuth) error
+}
+
+func (git *git) SetAuth(repo string, auth *RepoAuth) error {
+	credArgs, sshArgs, err := auth.gitArgs(repo)
+	if err != nil {
+		return err
+	}
+	git.authRepo = repo
+	git.authArgs = credArgs
+	git.sshArgs = sshArgs
+	return nil
+}
+
+// SetRepoAuth sets credentials for the repo at the address repoURL, if any are given.
+func SetRepoAuth(repo Repo, repoURL string, auth *RepoAuth) error {
+	if auth == nil {
+		return nil
+	}
+	authenticator, ok := repo.(Authenticator)
+	if !ok {
+		return fmt.Errorf("repo does not support authentication")
+	}
+	return authenticator.SetAuth(repoURL, auth)
+}
+
+// gitRemote runs a git command that accesses the remote repo (address).
+// SSH key can't be scoped to a remote in git config, so it's passed only for the authenticated repo.
+func (git *git) gitRemote(repo string, args ...string) ([]byte, error) {
+	if len(git.sshArgs) != 0 && repo == git.authRepo {
+		args = append(append([]string{}, git.sshArgs...), args...)
+	}
+	return git.git(args...)
+}
+
+// remoteURL returns address of the remote with the given name.
+func (git *git) remoteURL(remote string) (string, error) {
+	output, err := git.git("remote", "get-url", remote)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+var envNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// gitArgs returns git config arguments (-c key=value) that need to be passed before git subcommands.
+// Credential helpers are scoped to the repo address, so they can be passed to all commands.
+// SSH arguments must be passed only to commands that access the repo.
+func (auth *RepoAuth) gitArgs(repo string) (credArgs, sshArgs []string, err error) {
+	if auth == nil {
+		return nil, nil, nil
+	}
+	if repo == "" || strings.ContainsAny(repo, "=\n") {
+		return nil, nil, fmt.Errorf("bad authenticated repo address %q", repo)
+	}
+	if auth.SSHKey != "" {
+		if !osutil.IsExist(auth.SSHKey) {
+			return nil, nil, fmt.Errorf("ssh key %v does not exist", auth.SSHKey)
+		}
+		if strings.ContainsAny(auth.SSHKey, "'\n") {
+			return nil, nil, fmt.Errorf("bad ssh key path %q", auth.SSHKey)
+		}
+		sshArgs = []string{"-c", fmt.Sprintf("core.sshCommand=ssh -i '%v' -o IdentitiesOnly=yes -o BatchMode=yes",
+			auth.SSHKey)}
+	}
+	var helpers []string
+	if auth.TokenEnv != "" {
+		if !envNameRe.MatchString(auth.TokenEnv) {
+			return nil, nil, fmt.Errorf("bad token env var name %q", auth.TokenEnv)
+		}
+		user := auth.TokenUser
+		if user == "" {
+			user = "oauth2"
+		}
+		if strings.ContainsAny(user, "'\n") {
+			return nil, nil, fmt.Errorf("bad token user %q", user)
+		}
+		// The helper is evaluated by shell, so the token is read from the environment at that point.
+		helpers = append(helpers, fmt.Sprintf("!f() { test \"$1\" = get || exit 0; "+
+			"echo username='%v'; echo \"password=$%v\"; }; f", user, auth.TokenEnv))
+	}
+	helpers = append(helpers, auth.CredentialHelpers...)
+	if len(helpers) != 0 {
+		// An empty helper resets the list of helpers inherited from the user/system config.
+		credArgs = append(credArgs, "-c", "credential.helper=")
+		// Helpers are used only for URLs with the repo path (e.g. not for other repos on the same host).
+		for _, helper := range helpers {
+			credArgs = append(credArgs, "-c", fmt.Sprintf("credential.%v.helper=%v", repo, helper))
+		}
+		// Make git-lfs send the path too, otherwise the scoped helpers don't match LFS requests.
+		if u, err := url.Parse(repo); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			credArgs = append(credArgs, "-c", fmt.Sprintf("credential.%v://%v.useHttpPath=true", u.Scheme, u.Host))
+		}
+		// Never prompt for credentials, sandboxed commands don't have a terminal anyway.
+		credArgs = append(credArgs, "-c", "core.askPass=true")
+	}
+	return credArgs, sshArgs, nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// ParallelBisecter may be optionally implemented by Repo.
+type ParallelBisecter interface {
+	// BisectParallel is like Bisecter.Bisect, but it tests up to k commits from the remaining
+	// range concurrently, which reduces the wall time roughly by the parallelism factor.
+	// Results are consumed as they arrive: the range is narrowed after every test and a free
+	// worker immediately gets the middle of the largest untested part of the remaining range.
+	// pred is called concurrently for different commits, each call receives a separate
+	// worktree dir with the commit checked out.
+	// The predicate must be monotonic: all descendants of a bad commit must be bad.
+	BisectParallel(bad, good string, k int, trace io.Writer,
+		pred func(com *Commit, dir string) (BisectResult, error)) ([]*Commit, error)
+}
+
+func (git *git) BisectParallel(bad, good string, k int, trace io.Writer,
+	pred func(com *Commit, dir string) (BisectResult, error)) ([]*Commit, error) {
+	if k < 1 {
+		k = 1
+	}
+	git.reset()
+	if err := git.ensureHistory(historyFull); err != nil {
+		return nil, err
+	}
+	bp := &parallelBisect{
+		git:   git,
+		k:     k,
+		trace: trace,
+		pred:  pred,
+	}
+	defer bp.close()
+	res, err := bp.bisect(bad, good, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("bisection did not find any bad commits")
+	}
+	return res, nil
+}
+
+type parallelBisect struct {
+	git       *git
+	k         int
+	trace     io.Writer
+	pred      func(com *Commit, dir string) (BisectResult, error)
+	worktrees []*git
+	free      []*git
+}
+
+type parallelResult struct {
+	idx int
+	wt  *git
+	res BisectResult
+	err error
+}
+
+// bisect searches for the first bad commit on the first-parent chain good..bad.
+// If badKnown is not set, bad itself is not known to be bad and nil is returned if the whole chain is good.
+// If the first bad commit is a merge, the search continues in the merged branch.
+func (bp *parallelBisect) bisect(bad, good string, badKnown bool) ([]*Commit, error) {
+	output, err := bp.git.git("rev-list", "--first-parent", "--reverse", bad, "^"+good)
+	if err != nil {
+		return nil, err
+	}
+	hashes := strings.Fields(string(output))
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("no commits between %v and %v", good, bad)
+	}
+	// hashes[lo] is the last known good commit (-1 is good itself),
+	// hashes[hi] is the first known bad commit (len(hashes) if none is known).
+	lo, hi := -1, len(hashes)
+	if badKnown {
+		hi = len(hashes) - 1
+	}
+	skipped := make(map[int]bool)
+	running := make(map[int]bool)
+	results := make(chan parallelResult)
+	var firstErr error
+	for {
+		// Don't start new tests after an error, but wait for the running ones.
+		for firstErr == nil {
+			idx := bisectCandidate(lo, hi, skipped, running)
+			if idx == -1 {
+				break
+			}
+			wt, err := bp.worktree()
+			if err != nil {
+				firstErr = err
+				break
+			}
+			if wt == nil {
+				break
+			}
+			running[idx] = true
+			fmt.Fprintf(bp.trace, "# testing %v: %v commits left, %v tests running\n",
+				hashes[idx], hi-lo-1, len(running))
+			go func(idx int, wt *git) {
+				res, err := bp.test(wt, hashes[idx])
+				results <- parallelResult{idx, wt, res, err}
+			}(idx, wt)
+		}
+		if len(running) == 0 {
+			break
+		}
+		r := <-results
+		delete(running, r.idx)
+		bp.free = append(bp.free, r.wt)
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		fmt.Fprintf(bp.trace, "# %v %v\n", bisectTerms[r.res], hashes[r.idx])
+		if r.idx <= lo || r.idx >= hi {
+			// The range was narrowed while the commit was tested.
+			continue
+		}
+		switch r.res {
+		case BisectBad:
+			hi = r.idx
+		case BisectGood:
+			lo = r.idx
+		case BisectSkip:
+			skipped[r.idx] = true
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if hi == lo+1 {
+		if hi == len(hashes) {
+			return nil, nil
+		}
+		com, err := bp.git.getCommit(hashes[hi])
+		if err != nil {
+			return nil, err
+		}
+		if len(com.Parents) < 2 {
+			return []*Commit{com}, nil
+		}
+		// The culprit is a merge: either one of the merged commits or the merge itself is to blame.
+		fmt.Fprintf(bp.trace, "# first bad commit %v is a merge, bisecting merged branch\n", com.Hash)
+		res, err := bp.bisect(com.Parents[1], com.Parents[0], false)
+		if err != nil || len(res) != 0 {
+			return res, err
+		}
+		return []*Commit{com}, nil
+	}
+	// Only skipped commits are left, the first bad commit is one of them or the first known bad.
+	var res []*Commit
+	for idx := lo + 1; idx <= hi && idx < len(hashes); idx++ {
+		com, err := bp.git.getCommit(hashes[idx])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, com)
+	}
+	return res, nil
+}
+
+// bisectCandidate selects the next commit to test in lo..hi: the untested commit closest
+// to the middle of the largest part of the range that is not split by running tests.
+// Returns -1 if there is nothing to test.
+func bisectCandidate(lo, hi int, skipped, running map[int]bool) int {
+	bounds := []int{lo}
+	for idx := lo + 1; idx < hi; idx++ {
+		if running[idx] {
+			bounds = append(bounds, idx)
+		}
+	}
+	bounds = append(bounds, hi)
+	best, bestGap := -1, 0
+	for i := 0; i < len(bounds)-1; i++ {
+		from, to := bounds[i], bounds[i+1]
+		if to-from <= bestGap {
+			continue
+		}
+		mid := from + (to-from)/2
+		for delta := 0; delta < to-from; delta++ {
+			if idx := mid - delta; idx > from && idx < to && !skipped[idx] {
+				best, bestGap = idx, to-from
+				break
+			}
+			if idx := mid + delta; idx > from && idx < to && !skipped[idx] {
+				best, bestGap = idx, to-from
+				break
+			}
+		}
+	}
+	return best
+}
+
+// worktree returns a free worktree, creates a new one if there are less than k,
+// or returns nil if all k worktrees are busy.
+func (bp *parallelBisect) worktree() (*git, error) {
+	if len(bp.free) != 0 {
+		wt := bp.free[len(bp.free)-1]
+		bp.free = bp.free[:len(bp.free)-1]
+		return wt, nil
+	}
+	if len(bp.worktrees) >= bp.k {
+		return nil, nil
+	}
+	if err := bp.createWorktrees(len(bp.worktrees) + 1); err != nil {
+		return nil, err
+	}
+	return bp.worktrees[len(bp.worktrees)-1], nil
+}
+
+func (bp *parallelBisect) test(wt *git, hash string) (BisectResult, error) {
+	wt.git("reset", "--hard")
+	wt.git("clean", "-fdx")
+	if _, err := wt.git("checkout", "--detach", hash); err != nil {
+		return 0, err
+	}
+	if err := wt.lfsPull(); err != nil {
+		return 0, err
+	}
+	com, err := wt.HeadCommit()
+	if err != nil {
+		return 0, err
+	}
+	return bp.pred(com, wt.dir)
+}
+
+// createWorktrees creates n worktrees next to the repo dir, they are reused across rounds.
+func (bp *parallelBisect) createWorktrees(n int) error {
+	for len(bp.worktrees) < n {
+		dir := filepath.Join(bp.git.dir+"-bisect", fmt.Sprint(len(bp.worktrees)))
+		os.RemoveAll(dir)
+		bp.git.git("worktree", "prune")
+		if err := osutil.MkdirAll(filepath.Dir(dir)); err != nil {
+			return err
+		}
+		if bp.git.sandbox {
+			if err := osutil.SandboxChown(filepath.Dir(dir)); err != nil {
+				return err
+			}
+		}
+		if _, err := bp.git.git("worktree", "add", "--detach", dir, "HEAD"); err != nil {
+			return fmt.Errorf("failed to create worktree: %v", err)
+		}
+		bp.worktrees = append(bp.worktrees, &git{
+			dir:       dir,
+			sandbox:   bp.git.sandbox,
+			ignoreCC:  bp.git.ignoreCC,
+			authRepo:  bp.git.authRepo,
+			authArgs:  bp.git.authArgs,
+			sshArgs:   bp.git.sshArgs,
+			lfs:       bp.git.lfs,
+			lfsRemote: bp.git.lfsRemote,
+		})
+	}
+	return nil
+}
+
+func (bp *parallelBisect) close() {
+	for _, wt := range bp.worktrees {
+		bp.git.git("worktree", "remove", "--force", wt.dir)
+	}
+	bp.git.git("worktree", "prune")
+	os.RemoveAll(bp.git.dir + "-bisect")
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Blamer may be optionally implemented by Repo.
+type Blamer interface {
+	// Blame returns the commit that introduced the line (1-based) of the file
+	// as of the given commit.
+	Blame(file string, line int, commit string) (*Commit, error)
+}
+
+func (git *git) Blame(file string, line int, commit string) (*Commit, error) {
+	if line <= 0 {
+		return nil, fmt.Errorf("bad line number %v", line)
+	}
+	hash, boundary, err := git.blame(file, line, commit)
+	if err != nil {
+		return nil, err
+	}
+	if boundary && git.depth != 0 {
+		// In shallow repos lines older than the fetched history are attributed
+		// to the oldest fetched commit, so fetch the rest of history and retry.
+		if err := git.ensureHistory(historyFull); err != nil {
+			return nil, err
+		}
+		if hash, _, err = git.blame(file, line, commit); err != nil {
+			return nil, err
+		}
+	}
+	return git.getCommit(hash)
+}
+
+// blame returns hash of the commit that introduced the line and whether it is a boundary commit
+// (a commit without parents in the local history).
+func (git *git) blame(file string, line int, commit string) (string, bool, error) {
+	output, err := git.git("blame", "--porcelain", fmt.Sprintf("-L%v,%v", line, line), commit, "--", file)
+	if err != nil {
+		return "", false, err
+	}
+	// The first line has form "hash orig-line final-line num-lines".
+	fields := bytes.Fields(output)
+	if len(fields) == 0 {
+		return "", false, fmt.Errorf("unexpected git blame output: %q", output)
+	}
+	boundary := bytes.Contains(output, []byte("\nboundary\n"))
+	return string(fields[0]), boundary, nil
+}
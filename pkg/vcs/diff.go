@@ -0,0 +1,151 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CommitDiffer may be optionally implemented by Repo.
+type CommitDiffer interface {
+	// CommitDiff returns diffstat of the commit and its patch truncated to maxSize bytes
+	// (at a line boundary). Merge commits are diffed against the first parent.
+	CommitDiff(commit string, maxSize int) (diffstat string, patch []byte, err error)
+}
+
+func (git *git) CommitDiff(commit string, maxSize int) (string, []byte, error) {
+	com, err := git.getCommit(commit)
+	if err != nil {
+		return "", nil, err
+	}
+	var args []string
+	if len(com.Parents) == 0 || com.Parents[0] == "" {
+		args = []string{"show", "--format=", com.Hash}
+	} else {
+		args = []string{"diff", com.Parents[0], com.Hash}
+	}
+	diffstat, err := git.git(append(args, "--stat=100")...)
+	if err != nil {
+		return "", nil, err
+	}
+	patch, err := git.git(args...)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(bytes.Trim(diffstat, "\n")), truncatePatch(patch, maxSize), nil
+}
+
+func truncatePatch(patch []byte, maxSize int) []byte {
+	if len(patch) <= maxSize {
+		return patch
+	}
+	patch = patch[:maxSize]
+	if pos := bytes.LastIndexByte(patch, '\n'); pos != -1 {
+		patch = patch[:pos+1]
+	} else {
+		patch = append(patch, '\n')
+	}
+	return append(patch, "[truncated]\n"...)
+}
+
+// FileDiff describes changes to a single file in a git diff.
+type FileDiff struct {
+	Name      string
+	Added     int
+	Removed   int
+	Hunks     []DiffHunk
+	Functions []string // names of the touched functions (as reported in hunk headers)
+}
+
+type DiffHunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Function string // the section heading, e.g. the enclosing function declaration
+}
+
+var (
+	diffFileRe = regexp.MustCompile(`^diff --git a/(.+) b/(.+)$`)
+	diffHunkRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+	diffFuncRe = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*\(`)
+)
+
+// ParseGitDiff parses output of git diff and returns per-file line counts and hunks.
+func ParseGitDiff(patch []byte) ([]*FileDiff, error) {
+	var files []*FileDiff
+	var file *FileDiff
+	oldLeft, newLeft := 0, 0
+	s := bufio.NewScanner(bytes.NewReader(patch))
+	// Generated files and minified sources may have very long lines.
+	s.Buffer(nil, len(patch)+1)
+	for s.Scan() {
+		ln := s.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(ln, "+"):
+				file.Added++
+				newLeft--
+			case strings.HasPrefix(ln, "-"):
+				file.Removed++
+				oldLeft--
+			case strings.HasPrefix(ln, "\\"):
+				// "\ No newline at end of file".
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+		if match := diffFileRe.FindStringSubmatch(ln); match != nil {
+			file = &FileDiff{Name: match[2]}
+			files = append(files, file)
+			continue
+		}
+		if file == nil {
+			continue
+		}
+		if strings.HasPrefix(ln, "+++ b/") {
+			file.Name = ln[len("+++ b/"):]
+			continue
+		}
+		match := diffHunkRe.FindStringSubmatch(ln)
+		if match == nil {
+			continue
+		}
+		hunk := DiffHunk{
+			OldStart: atoiDefault(match[1], 0),
+			OldLines: atoiDefault(match[2], 1),
+			NewStart: atoiDefault(match[3], 0),
+			NewLines: atoiDefault(match[4], 1),
+			Function: strings.TrimSpace(match[5]),
+		}
+		file.Hunks = append(file.Hunks, hunk)
+		oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+		if fn := diffFuncRe.FindStringSubmatch(hunk.Function); fn != nil &&
+			!containsString(file.Functions, fn[1]) {
+			file.Functions = append(file.Functions, fn[1])
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse git diff: %v", err)
+	}
+	return files, nil
+}
+
+func atoiDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGitDiff(t *testing.T) {
+	diff := `diff --git a/mm/slab.c b/mm/slab.c
+index 1111111..2222222 100644
+--- a/mm/slab.c
++++ b/mm/slab.c
+@@ -10,6 +10,7 @@ static int kmem_foo(struct kmem_cache *cache)
+ 	int x;
+-	x = 1;
++	x = 2;
++	y = 3;
+ 	return x;
+ }
+ 
+ 
+@@ -100 +101 @@ void kfree(const void *p)
+-	bar();
++	baz();
+@@ -200,3 +201,2 @@ void kfree(const void *p)
+ a
+-b
+ c
+diff --git a/include/linux/old.h b/include/linux/old.h
+deleted file mode 100644
+index 3333333..0000000
+--- a/include/linux/old.h
++++ /dev/null
+@@ -1,2 +0,0 @@
+-#define FOO
+-#define BAR
+\ No newline at end of file
+`
+	want := []*FileDiff{
+		{
+			Name:    "mm/slab.c",
+			Added:   3,
+			Removed: 3,
+			Hunks: []DiffHunk{
+				{10, 6, 10, 7, "static int kmem_foo(struct kmem_cache *cache)"},
+				{100, 1, 101, 1, "void kfree(const void *p)"},
+				{200, 3, 201, 2, "void kfree(const void *p)"},
+			},
+			Functions: []string{"kmem_foo", "kfree"},
+		},
+		{
+			Name:    "include/linux/old.h",
+			Removed: 2,
+			Hunks: []DiffHunk{
+				{1, 2, 0, 0, ""},
+			},
+		},
+	}
+	got, err := ParseGitDiff([]byte(diff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+func TestParseGitDiffLongLines(t *testing.T) {
+	long := strings.Repeat("x", 1<<20)
+	diff := "diff --git a/data.c b/data.c\n" +
+		"--- a/data.c\n" +
+		"+++ b/data.c\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		"-" + long + "\n" +
+		"+" + long + "y\n" +
+		" int x;\n"
+	got, err := ParseGitDiff([]byte(diff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Added != 1 || got[0].Removed != 1 {
+		t.Fatalf("bad diff: %+v", got)
+	}
+}
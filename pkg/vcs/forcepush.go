@@ -0,0 +1,60 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"strings"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
+)
+
+// ForcePushNotifier may be optionally implemented by Repo.
+type ForcePushNotifier interface {
+	// OnForcePush registers a callback that is invoked when Poll or CheckoutBranch
+	// observe that the branch head does not descend from the head observed previously.
+	// In shallow repos detection is best-effort: if more commits than the fetch depth
+	// were added between two polls, a fast-forward may be reported as a force push.
+	OnForcePush(fn func(ev *ForcePushEvent))
+}
+
+type ForcePushEvent struct {
+	Repo    string
+	Branch  string
+	OldHead string
+	NewHead string
+}
+
+func (git *git) OnForcePush(fn func(ev *ForcePushEvent)) {
+	git.forcePushHooks = append(git.forcePushHooks, fn)
+}
+
+// checkForcePush compares the new head of repo/branch with the head recorded on the previous call.
+// Heads are recorded as refs, so that the old head is not garbage collected and is available for comparison.
+func (git *git) checkForcePush(repo, branch, head string) {
+	ref := "refs/syzkaller/heads/" + hash.String([]byte(repo+"|"+branch))
+	output, err := git.git("rev-parse", "--verify", "--quiet", ref)
+	if old := strings.TrimSpace(string(output)); err == nil && old != head {
+		if _, err := git.git("merge-base", "--is-ancestor", old, head); err != nil {
+			git.forcePushed(&ForcePushEvent{
+				Repo:    repo,
+				Branch:  branch,
+				OldHead: old,
+				NewHead: head,
+			})
+		}
+	}
+	if _, err := git.git("update-ref", ref, head); err != nil {
+		log.Logf(0, "git: failed to record head of %v/%v: %v", repo, branch, err)
+	}
+}
+
+func (git *git) forcePushed(ev *ForcePushEvent) {
+	log.Logf(0, "git: %v/%v was force-pushed: %v -> %v", ev.Repo, ev.Branch, ev.OldHead, ev.NewHead)
+	// Found commits are not reachable anymore, don't wait for the cache to notice this.
+	git.dropTitleCache(ev.Repo, ev.Branch)
+	for _, fn := range git.forcePushHooks {
+		fn(ev)
+	}
+}
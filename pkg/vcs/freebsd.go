@@ -7,8 +7,8 @@ type freebsd struct {
 	*git
 }
 
-func newFreeBSD(vm, dir string) *freebsd {
+func newFreeBSD(vm, dir string, opts []RepoOpt) *freebsd {
 	return &freebsd{
-		git: newGit(dir, nil),
+		git: newGit(dir, nil, opts),
 	}
 }
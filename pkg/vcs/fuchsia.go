@@ -17,11 +17,11 @@ type fuchsia struct {
 	repo *git
 }
 
-func newFuchsia(vm, dir string) *fuchsia {
+func newFuchsia(vm, dir string, opts []RepoOpt) *fuchsia {
 	return &fuchsia{
 		vm:   vm,
 		dir:  dir,
-		repo: newGit(dir, nil),
+		repo: newGit(dir, nil, opts),
 	}
 }
 
@@ -86,6 +86,6 @@ func (ctx *fuchsia) ListRecentCommits(baseCommit string) ([]string, error) {
 	return ctx.repo.ListRecentCommits(baseCommit)
 }
 
-func (ctx *fuchsia) ExtractFixTagsFromCommits(baseCommit, email string) ([]*Commit, error) {
-	return ctx.repo.ExtractFixTagsFromCommits(baseCommit, email)
+func (ctx *fuchsia) ExtractFixTagsFromCommits(baseCommit, email, dashboardURL string) ([]*Commit, error) {
+	return ctx.repo.ExtractFixTagsFromCommits(baseCommit, email, dashboardURL)
 }
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/mail"
+	"net/url"
 	"os"
 	"os/exec"
 	"regexp"
@@ -25,14 +26,54 @@ type git struct {
 	dir      string
 	sandbox  bool
 	ignoreCC map[string]bool
+	depth    int // number of commits to fetch for shallow repos, 0 for full repos
+	// Cache results of commit searches by title in the .git dir (see titlecache.go).
+	titleCache bool
+	// Key of the title cache entry for the current checkout (see titleCacheKey).
+	titleCacheBranch string
+	// Callbacks invoked on detected force pushes (see forcepush.go).
+	forcePushHooks []func(ev *ForcePushEvent)
+	// Oldest commit date for which history is known to be present in a shallow repo
+	// (zero if unknown, historyFull if the repo was unshallowed).
+	historySince time.Time
+	// Git config arguments with credentials for remote operations (see auth.go).
+	authArgs []string
+	// Address of the repo the credentials are for and SSH arguments that are used only for it.
+	authRepo string
+	sshArgs  []string
+	// Require good signatures on checked out commits (see signature.go).
+	verifySignatures bool
+	// Format of release tags (see release.go).
+	releases *ReleaseScheme
+	// Git LFS settings (see lfs.go), nil if LFS support is disabled.
+	lfs *LFSConfig
+	// Remote (name or address) of the last checkout to pull LFS objects from.
+	lfsRemote string
 }
 
-func newGit(dir string, ignoreCC map[string]bool) *git {
-	return &git{
+// shallowDepth is the number of commits fetched from remotes for shallow repos.
+const shallowDepth = 1000
+
+var historyFull = time.Unix(0, 0)
+
+func newGit(dir string, ignoreCC map[string]bool, opts []RepoOpt) *git {
+	git := &git{
 		dir:      dir,
 		sandbox:  true,
 		ignoreCC: ignoreCC,
+		releases: linuxReleases,
+	}
+	for _, opt := range opts {
+		switch opt {
+		case OptShallow:
+			git.depth = shallowDepth
+		case OptTitleCache:
+			git.titleCache = true
+		case OptVerifySignatures:
+			git.verifySignatures = true
+		}
 	}
+	return git
 }
 
 func filterEnv() []string {
@@ -57,6 +98,8 @@ func filterEnv() []string {
 
 func (git *git) Poll(repo, branch string) (*Commit, error) {
 	git.reset()
+	git.lfsRemote = "origin"
+	git.titleCacheBranch = titleCacheKey(repo, branch)
 	origin, err := git.git("remote", "get-url", "origin")
 	if err != nil || strings.TrimSpace(string(origin)) != repo {
 		// The repo is here, but it has wrong origin (e.g. repo in config has changed), re-clone.
@@ -73,37 +116,69 @@ func (git *git) Poll(repo, branch string) (*Commit, error) {
 			return nil, err
 		}
 	}
-	if _, err := git.git("fetch"); err != nil {
+	if _, err := git.gitRemote(repo, "fetch"); err != nil {
 		// Something else is wrong, re-clone.
 		if err := git.clone(repo, branch); err != nil {
 			return nil, err
 		}
 	}
+	signature, err := git.verifySignature("origin/" + branch)
+	if err != nil {
+		return nil, err
+	}
 	if _, err := git.git("checkout", "origin/"+branch); err != nil {
 		return nil, err
 	}
-	return git.HeadCommit()
+	if err := git.lfsPull(); err != nil {
+		return nil, err
+	}
+	com, err := git.HeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	com.Signature = signature
+	git.checkForcePush(repo, branch, com.Hash)
+	return com, nil
 }
 
 func (git *git) CheckoutBranch(repo, branch string) (*Commit, error) {
 	git.reset()
+	git.lfsRemote = repo
+	git.titleCacheBranch = titleCacheKey(repo, branch)
 	if _, err := git.git("reset", "--hard"); err != nil {
 		if err := git.initRepo(err); err != nil {
 			return nil, err
 		}
 	}
-	_, err := git.git("fetch", repo, branch)
+	_, err := git.gitRemote(repo, git.fetchArgs("fetch", repo, branch)...)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := git.verifySignature("FETCH_HEAD")
 	if err != nil {
 		return nil, err
 	}
 	if _, err := git.git("checkout", "FETCH_HEAD"); err != nil {
 		return nil, err
 	}
-	return git.HeadCommit()
+	if err := git.lfsPull(); err != nil {
+		return nil, err
+	}
+	com, err := git.HeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	com.Signature = signature
+	git.checkForcePush(repo, branch, com.Hash)
+	return com, nil
 }
 
 func (git *git) CheckoutCommit(repo, commit string) (*Commit, error) {
 	git.reset()
+	// Set it before anything can fail, so that subsequent SwitchCommit/Bisect calls
+	// don't pull LFS objects from a previously checked out repo.
+	git.lfsRemote = hash.String([]byte(repo))
+	git.titleCacheBranch = ""
 	if _, err := git.git("reset", "--hard"); err != nil {
 		if err := git.initRepo(err); err != nil {
 			return nil, err
@@ -112,26 +187,146 @@ func (git *git) CheckoutCommit(repo, commit string) (*Commit, error) {
 	if err := git.fetchRemote(repo); err != nil {
 		return nil, err
 	}
-	return git.SwitchCommit(commit)
+	commit, err := git.resolveCommit(commit)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := git.verifySignature(commit)
+	if err != nil {
+		return nil, err
+	}
+	com, err := git.SwitchCommit(commit)
+	if err != nil {
+		return nil, err
+	}
+	com.Signature = signature
+	return com, nil
+}
+
+// resolveCommit expands an abbreviated commit hash (as often pasted into requests) to the full one
+// among all fetched objects. Other commit references are returned as is.
+func (git *git) resolveCommit(commit string) (string, error) {
+	if len(commit) == 40 || !CheckCommitHash(commit) {
+		return commit, nil
+	}
+	candidates, err := git.abbrevCommitCandidates(commit)
+	if err != nil {
+		return "", err
+	}
+	if len(candidates) == 0 && git.depth != 0 {
+		// The commit may be older than the fetched part of history.
+		if err := git.ensureHistory(historyFull); err != nil {
+			return "", err
+		}
+		if candidates, err = git.abbrevCommitCandidates(commit); err != nil {
+			return "", err
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf("commit %v is not found", commit)
+	case 1:
+		return candidates[0], nil
+	default:
+		return "", fmt.Errorf("abbreviated commit hash %v is ambiguous, candidates: %v",
+			commit, strings.Join(candidates, ", "))
+	}
+}
+
+func (git *git) abbrevCommitCandidates(commit string) ([]string, error) {
+	// rev-parse fails if there are no objects with the prefix.
+	output, _ := git.git("rev-parse", "--disambiguate="+commit)
+	var res []string
+	for _, hash := range strings.Fields(string(output)) {
+		typ, err := git.git("cat-file", "-t", hash)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(string(typ)) == "commit" {
+			res = append(res, hash)
+		}
+	}
+	return res, nil
 }
 
 func (git *git) fetchRemote(repo string) error {
 	repoHash := hash.String([]byte(repo))
 	// Ignore error as we can double add the same remote and that will fail.
 	git.git("remote", "add", repoHash, repo)
-	_, err := git.git("fetch", "--tags", repoHash)
-	return err
+	if _, err := git.gitRemote(repo, git.fetchArgs("fetch", "--tags", repoHash)...); err != nil {
+		return err
+	}
+	return git.touchRemote(repoHash)
 }
 
 func (git *git) SwitchCommit(commit string) (*Commit, error) {
 	git.git("reset", "--hard")
 	git.git("clean", "-fdx")
 	if _, err := git.git("checkout", commit); err != nil {
+		// The commit may be older than the fetched part of history.
+		if git.depth == 0 {
+			return nil, err
+		}
+		if err1 := git.ensureHistory(historyFull); err1 != nil {
+			return nil, err1
+		}
+		if _, err := git.git("checkout", commit); err != nil {
+			return nil, err
+		}
+	}
+	if err := git.lfsPull(); err != nil {
 		return nil, err
 	}
 	return git.HeadCommit()
 }
 
+// fetchArgs adds --depth to the fetch command if the repo is shallow
+// and the history was not requested to be deeper.
+func (git *git) fetchArgs(args ...string) []string {
+	if git.depth != 0 && git.historySince.IsZero() {
+		args = append(args[:1:1], append([]string{fmt.Sprintf("--depth=%v", git.depth)}, args[1:]...)...)
+	}
+	return args
+}
+
+// ensureHistory fetches history of all remotes since the given time if the repo is shallow.
+// historyFull fetches the whole history.
+func (git *git) ensureHistory(since time.Time) error {
+	if git.depth == 0 || !git.historySince.IsZero() && !git.historySince.After(since) {
+		return nil
+	}
+	output, err := git.git("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(output)) != "true" {
+		git.historySince = historyFull
+		return nil
+	}
+	// Note: --unshallow fails on a repo that is already complete, this can happen
+	// if the repo has several remotes, so we use the max depth instead.
+	deepen := "--depth=2147483647"
+	if since != historyFull {
+		deepen = "--shallow-since=" + since.Format("2006-01-02")
+	}
+	output, err = git.git("remote")
+	if err != nil {
+		return err
+	}
+	// Remotes are fetched one-by-one since they may need different credentials.
+	for _, remote := range strings.Fields(string(output)) {
+		repo, err := git.remoteURL(remote)
+		if err != nil {
+			return err
+		}
+		if _, err := git.gitRemote(repo, "fetch", "--tags", deepen, remote); err != nil {
+			return fmt.Errorf("failed to deepen shallow repo: %v", err)
+		}
+	}
+	git.historySince = since
+	return nil
+}
+
 func (git *git) clone(repo, branch string) error {
 	if err := git.initRepo(nil); err != nil {
 		return err
@@ -139,7 +334,8 @@ func (git *git) clone(repo, branch string) error {
 	if _, err := git.git("remote", "add", "origin", repo); err != nil {
 		return err
 	}
-	if _, err := git.git("fetch", "origin", branch); err != nil {
+	git.historySince = time.Time{}
+	if _, err := git.gitRemote(repo, git.fetchArgs("fetch", "origin", branch)...); err != nil {
 		return err
 	}
 	return nil
@@ -183,10 +379,29 @@ func (git *git) getCommit(commit string) (*Commit, error) {
 	if err != nil {
 		return nil, err
 	}
-	return gitParseCommit(output, nil, nil, git.ignoreCC)
+	return gitParseCommit(output, nil, nil, nil, git.ignoreCC)
 }
 
-func gitParseCommit(output, user, domain []byte, ignoreCC map[string]bool) (*Commit, error) {
+// bugLinkMatchers returns a regexp that extracts the extid from Closes:/Link: trailers
+// referencing bugs on the given dashboard, and a looser pattern to preselect such commits with git log --grep.
+func bugLinkMatchers(dashboardURL string) (*regexp.Regexp, string, error) {
+	u, err := url.Parse(dashboardURL)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.Host == "" {
+		return nil, "", fmt.Errorf("no host in dashboard URL")
+	}
+	host := regexp.QuoteMeta(u.Host)
+	re, err := regexp.Compile(`^\s*(?:Closes|Link):\s*<?https?://` + host + `/bug\?(?:[^\s>]*&)?extid=([0-9a-f]+)`)
+	if err != nil {
+		return nil, "", err
+	}
+	grep := "^[[:space:]]*\\(Closes\\|Link\\):.*//" + host + "/bug?.*extid="
+	return re, grep, nil
+}
+
+func gitParseCommit(output, user, domain []byte, bugLinkRe *regexp.Regexp, ignoreCC map[string]bool) (*Commit, error) {
 	lines := bytes.Split(output, []byte{'\n'})
 	if len(lines) < 4 || len(lines[0]) != 40 {
 		return nil, fmt.Errorf("unexpected git log output: %q", output)
@@ -209,19 +424,21 @@ func gitParseCommit(output, user, domain []byte, ignoreCC map[string]bool) (*Com
 					startPos := userPos + len(user)
 					endPos := userPos + len(user) + domainPos + 1
 					tag := string(line[startPos:endPos])
-					present := false
-					for _, tag1 := range tags {
-						if tag1 == tag {
-							present = true
-							break
-						}
-					}
-					if !present {
+					if !containsString(tags, tag) {
 						tags = append(tags, tag)
 					}
 				}
 			}
 		}
+		// Closes:/Link: trailers may reference the bug by the dashboard URL
+		// instead of the email address, the extid in the URL is the same tag.
+		if bugLinkRe != nil {
+			if match := bugLinkRe.FindSubmatch(line); match != nil {
+				if tag := string(match[1]); !containsString(tags, tag) {
+					tags = append(tags, tag)
+				}
+			}
+		}
 		for _, re := range ccRes {
 			matches := re.FindSubmatchIndex(line)
 			if matches == nil {
@@ -267,6 +484,16 @@ func (git *git) GetCommitByTitle(title string) (*Commit, error) {
 }
 
 func (git *git) GetCommitsByTitles(titles []string) ([]*Commit, []string, error) {
+	if git.titleCache {
+		return git.cachedCommitsByTitles(titles)
+	}
+	return git.searchCommitsByTitles("HEAD", titles)
+}
+
+// searchCommitsByTitles searches for commits with the given titles reachable from base
+// (which can also be a revision range) committed during the last 2 years
+// (shallowSearchPeriod for shallow repos).
+func (git *git) searchCommitsByTitles(base string, titles []string) ([]*Commit, []string, error) {
 	var greps []string
 	m := make(map[string]string)
 	for _, title := range titles {
@@ -274,8 +501,11 @@ func (git *git) GetCommitsByTitles(titles []string) ([]*Commit, []string, error)
 		greps = append(greps, canonical)
 		m[canonical] = title
 	}
-	since := time.Now().Add(-time.Hour * 24 * 365 * 2).Format("01-02-2006")
-	commits, err := git.fetchCommits(since, "HEAD", "", "", greps, true)
+	since, err := git.searchSince(time.Hour * 24 * 365 * 2)
+	if err != nil {
+		return nil, nil, err
+	}
+	commits, err := git.fetchCommits(since, base, "", "", nil, greps, true)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -306,17 +536,48 @@ func (git *git) ListRecentCommits(baseCommit string) ([]string, error) {
 	return strings.Split(string(output), "\n"), nil
 }
 
-func (git *git) ExtractFixTagsFromCommits(baseCommit, email string) ([]*Commit, error) {
+func (git *git) ExtractFixTagsFromCommits(baseCommit, email, dashboardURL string) ([]*Commit, error) {
 	user, domain, err := splitEmail(email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse email %q: %v", email, err)
 	}
-	grep := user + "+.*" + domain
-	since := time.Now().Add(-time.Hour * 24 * 365).Format("01-02-2006")
-	return git.fetchCommits(since, baseCommit, user, domain, []string{grep}, false)
+	greps := []string{user + "+.*" + domain}
+	var bugLinkRe *regexp.Regexp
+	if dashboardURL != "" {
+		var bugLinkGrep string
+		bugLinkRe, bugLinkGrep, err = bugLinkMatchers(dashboardURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse dashboard URL %q: %v", dashboardURL, err)
+		}
+		greps = append(greps, bugLinkGrep)
+	}
+	since, err := git.searchSince(time.Hour * 24 * 365)
+	if err != nil {
+		return nil, err
+	}
+	return git.fetchCommits(since, baseCommit, user, domain, bugLinkRe, greps, false)
+}
+
+// shallowSearchPeriod bounds commit searches (fix tags, titles) in shallow repos.
+// Searching the full period would deepen the shallow clone by years of history and undo its savings.
+// Both searches are repeated on every poll, so recent commits are not missed.
+const shallowSearchPeriod = time.Hour * 24 * 90
+
+// searchSince ensures history for a commit search over the given period is present
+// and returns the start of the period in the git log --since format.
+func (git *git) searchSince(period time.Duration) (string, error) {
+	if git.depth != 0 && period > shallowSearchPeriod {
+		period = shallowSearchPeriod
+	}
+	sinceTime := time.Now().Add(-period)
+	if err := git.ensureHistory(sinceTime); err != nil {
+		return "", err
+	}
+	return sinceTime.Format("01-02-2006"), nil
 }
 
-func (git *git) fetchCommits(since, base, user, domain string, greps []string, fixedStrings bool) ([]*Commit, error) {
+func (git *git) fetchCommits(since, base, user, domain string, bugLinkRe *regexp.Regexp, greps []string,
+	fixedStrings bool) ([]*Commit, error) {
 	const commitSeparator = "---===syzkaller-commit-separator===---"
 	args := []string{"log", "--since", since, "--format=%H%n%s%n%ae%n%an%n%ad%n%P%n%b%n" + commitSeparator}
 	if fixedStrings {
@@ -326,25 +587,42 @@ func (git *git) fetchCommits(since, base, user, domain string, greps []string, f
 		args = append(args, "--grep", grep)
 	}
 	args = append(args, base)
+	var commits []*Commit
+	err := git.gitStream(args, func(r io.Reader) error {
+		var err error
+		commits, err = git.parseCommits(r, commitSeparator, user, domain, bugLinkRe)
+		return err
+	})
+	return commits, err
+}
+
+// gitStream runs git with the args and passes its output to parse as it is produced,
+// so that huge outputs (e.g. git log of the whole kernel history) are not loaded into memory.
+func (git *git) gitStream(args []string, parse func(r io.Reader) error) error {
 	cmd := exec.Command("git", args...)
 	cmd.Dir = git.dir
 	cmd.Env = filterEnv()
 	if git.sandbox {
 		if err := osutil.Sandbox(cmd, true, false); err != nil {
-			return nil, err
+			return err
 		}
 	}
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if err := cmd.Start(); err != nil {
-		return nil, err
+		return err
 	}
 	defer cmd.Wait()
 	defer cmd.Process.Kill()
+	return parse(stdout)
+}
+
+func (git *git) parseCommits(r io.Reader, commitSeparator, user, domain string,
+	bugLinkRe *regexp.Regexp) ([]*Commit, error) {
 	var (
-		s           = bufio.NewScanner(stdout)
+		s           = bufio.NewScanner(r)
 		buf         = new(bytes.Buffer)
 		separator   = []byte(commitSeparator)
 		commits     []*Commit
@@ -362,7 +640,7 @@ func (git *git) fetchCommits(since, base, user, domain string, greps []string, f
 			buf.WriteByte('\n')
 			continue
 		}
-		com, err := gitParseCommit(buf.Bytes(), userBytes, domainBytes, git.ignoreCC)
+		com, err := gitParseCommit(buf.Bytes(), userBytes, domainBytes, bugLinkRe, git.ignoreCC)
 		if err != nil {
 			return nil, err
 		}
@@ -375,9 +653,16 @@ func (git *git) fetchCommits(since, base, user, domain string, greps []string, f
 }
 
 func (git *git) git(args ...string) ([]byte, error) {
+	if len(git.authArgs) != 0 {
+		args = append(append([]string{}, git.authArgs...), args...)
+	}
 	cmd := osutil.Command("git", args...)
 	cmd.Dir = git.dir
 	cmd.Env = filterEnv()
+	if git.lfs != nil {
+		// LFS objects are pulled explicitly only for the configured paths (see lfsPull).
+		cmd.Env = append(cmd.Env, "GIT_LFS_SKIP_SMUDGE=1")
+	}
 	if git.sandbox {
 		if err := osutil.Sandbox(cmd, true, false); err != nil {
 			return nil, err
@@ -403,8 +688,21 @@ func splitEmail(email string) (user, domain string, err error) {
 	return
 }
 
-func (git *git) Bisect(bad, good string, trace io.Writer, pred func() (BisectResult, error)) ([]*Commit, error) {
+var bisectTerms = [...]string{
+	BisectBad:  "bad",
+	BisectGood: "good",
+	BisectSkip: "skip",
+}
+
+func (git *git) Bisect(bad, good string, policy *BisectPolicy, trace io.Writer,
+	pred func() (BisectResult, error)) ([]*Commit, error) {
+	if policy == nil {
+		policy = new(BisectPolicy)
+	}
 	git.reset()
+	if err := git.ensureHistory(historyFull); err != nil {
+		return nil, err
+	}
 	firstBad, err := git.getCommit(bad)
 	if err != nil {
 		return nil, err
@@ -415,15 +713,24 @@ func (git *git) Bisect(bad, good string, trace io.Writer, pred func() (BisectRes
 	}
 	defer git.reset()
 	fmt.Fprintf(trace, "# git bisect start %v %v\n%s", bad, good, output)
+	for _, broken := range policy.BrokenRanges {
+		output, err = git.git("bisect", "skip", broken)
+		fmt.Fprintf(trace, "# git bisect skip %v (known broken)\n%s", broken, output)
+		if err != nil {
+			if bytes.Contains(output, []byte("There are only 'skip'ped commits left to test")) {
+				return git.bisectInconclusive(output)
+			}
+			return nil, err
+		}
+	}
+	if err := git.lfsPull(); err != nil {
+		return nil, err
+	}
 	current, err := git.HeadCommit()
 	if err != nil {
 		return nil, err
 	}
-	var bisectTerms = [...]string{
-		BisectBad:  "bad",
-		BisectGood: "good",
-		BisectSkip: "skip",
-	}
+	skips := 0
 	for {
 		res, err := pred()
 		// Linux EnvForCommit may cherry-pick some fixes, reset these before the next step.
@@ -431,11 +738,28 @@ func (git *git) Bisect(bad, good string, trace io.Writer, pred func() (BisectRes
 		if err != nil {
 			return nil, err
 		}
-		if res == BisectBad {
+		args := []string{"bisect", bisectTerms[res]}
+		switch res {
+		case BisectBad:
 			firstBad = current
+		case BisectSkip:
+			skips++
+			if policy.MaxSkips != 0 && skips >= policy.MaxSkips {
+				fmt.Fprintf(trace, "# %v commits skipped, giving up\n", skips)
+				return git.bisectRemaining()
+			}
+			if policy.SkipExpansion != 0 {
+				// Note: "hash~N..hash" would also skip whole merged branches if hash is a merge.
+				output, err := git.git("rev-list", "--first-parent", "-n", fmt.Sprint(policy.SkipExpansion+1),
+					current.Hash)
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, strings.Fields(string(output))...)
+			}
 		}
-		output, err = git.git("bisect", bisectTerms[res])
-		fmt.Fprintf(trace, "# git bisect %v %v\n%s", bisectTerms[res], current.Hash, output)
+		output, err = git.git(args...)
+		fmt.Fprintf(trace, "# git %v %v\n%s", strings.Join(args, " "), current.Hash, output)
 		if err != nil {
 			if bytes.Contains(output, []byte("There are only 'skip'ped commits left to test")) {
 				return git.bisectInconclusive(output)
@@ -449,10 +773,23 @@ func (git *git) Bisect(bad, good string, trace io.Writer, pred func() (BisectRes
 		if current.Hash == next.Hash {
 			return []*Commit{firstBad}, nil
 		}
+		if err := git.lfsPull(); err != nil {
+			return nil, err
+		}
 		current = next
 	}
 }
 
+// bisectRemaining returns all commits that are still candidates for the first bad commit
+// (including the current first known bad commit).
+func (git *git) bisectRemaining() ([]*Commit, error) {
+	output, err := git.git("bisect", "visualize", "--format=%H")
+	if err != nil {
+		return nil, err
+	}
+	return git.bisectInconclusive(output)
+}
+
 func (git *git) bisectInconclusive(output []byte) ([]*Commit, error) {
 	// For inconclusive bisection git prints the following message:
 	//
@@ -478,27 +815,36 @@ func (git *git) bisectInconclusive(output []byte) ([]*Commit, error) {
 }
 
 func (git *git) previousReleaseTags(commit string, self bool) ([]string, error) {
+	if err := git.ensureHistory(historyFull); err != nil {
+		return nil, err
+	}
 	var tags []string
 	if self {
-		output, err := git.git("tag", "--list", "--points-at", commit, "--merged", commit, "v*.*")
-		if err != nil {
-			return nil, err
-		}
-		tags, err = gitParseReleaseTags(output)
+		output, err := git.git("tag", "--list", "--points-at", commit, "--merged", commit, git.releases.Glob)
 		if err != nil {
 			return nil, err
 		}
+		tags = git.releases.parseTags(output)
 	}
-	output, err := git.git("tag", "--no-contains", commit, "--merged", commit, "v*.*")
+	output, err := git.git("tag", "--no-contains", commit, "--merged", commit, git.releases.Glob)
 	if err != nil {
 		return nil, err
 	}
-	tags1, err := gitParseReleaseTags(output)
+	tags = append(tags, git.releases.parseTags(output)...)
+	return tags, nil
+}
+
+func (git *git) ReleaseTag(commit string) (string, error) {
+	// Note: this does not use previousReleaseTags because it fetches the full history in shallow repos.
+	output, err := git.git("tag", "--list", "--merged", commit, git.releases.Glob)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	tags = append(tags, tags1...)
-	return tags, nil
+	tags := git.releases.parseTags(output)
+	if len(tags) == 0 {
+		return "", nil
+	}
+	return tags[0], nil
 }
 
 func (git *git) IsRelease(commit string) (bool, error) {
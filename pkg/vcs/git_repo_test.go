@@ -4,14 +4,23 @@
 package vcs
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/osutil"
 )
 
 func init() {
@@ -28,7 +37,7 @@ func TestGitRepo(t *testing.T) {
 	defer os.RemoveAll(baseDir)
 	repo1 := CreateTestRepo(t, baseDir, "repo1")
 	repo2 := CreateTestRepo(t, baseDir, "repo2")
-	repo := newGit(filepath.Join(baseDir, "repo"), nil)
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, nil)
 	{
 		com, err := repo.Poll(repo1.Dir, "master")
 		if err != nil {
@@ -108,6 +117,30 @@ func TestGitRepo(t *testing.T) {
 	}
 }
 
+func TestCheckoutAbbrevCommit(t *testing.T) {
+	t.Parallel()
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	repo1 := CreateTestRepo(t, baseDir, "repo1")
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, nil)
+	for _, want := range []*Commit{repo1.Commits["branch1"]["0"], repo1.Commits["branch2"]["1"]} {
+		com, err := repo.CheckoutCommit(repo1.Dir, want.Hash[:12])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(com, want); diff != "" {
+			t.Fatal(diff)
+		}
+	}
+	if _, err := repo.CheckoutCommit(repo1.Dir, "00000000000"); err == nil ||
+		!strings.Contains(err.Error(), "is not found") {
+		t.Fatalf("checkout of missing commit: got %v", err)
+	}
+}
+
 func TestMetadata(t *testing.T) {
 	t.Parallel()
 	repoDir, err := ioutil.TempDir("", "syz-git-test")
@@ -129,7 +162,7 @@ func TestMetadata(t *testing.T) {
 		}
 		prevHash = com.Hash
 	}
-	commits, err := repo.repo.ExtractFixTagsFromCommits("HEAD", extractFixTagsEmail)
+	commits, err := repo.repo.ExtractFixTagsFromCommits("HEAD", extractFixTagsEmail, extractFixTagsLink)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -154,6 +187,773 @@ func TestMetadata(t *testing.T) {
 	}
 }
 
+func TestListLinkTags(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	repo.CommitChange("no links")
+	com1 := repo.CommitChange("first\n\nLink: https://lore.kernel.org/r/1-foo@bar.com\n")
+	com2 := repo.CommitChange("second\n\nLink: https://lore.kernel.org/all/1-foo@bar.com/\n" +
+		"Link: https://lore.kernel.org/r/2-foo@bar.com\n")
+	idx, err := repo.repo.ListLinkTags("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{com2.Hash, com1.Hash}, idx.Commits("1-foo@bar.com")); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff([]string{"1-foo@bar.com", "2-foo@bar.com"}, idx.MessageIDs(com2.Hash)); diff != "" {
+		t.Error(diff)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("want 2 indexed ids, got %v", idx.Len())
+	}
+}
+
+func TestQueryCommits(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	com1 := repo.CommitChange("first")
+	com2 := repo.CommitChange("second")
+	cached, err := NewCachedCommitQuerier(repo.repo, filepath.Join(repoDir, "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, querier := range []CommitQuerier{repo.repo, cached, cached} {
+		hashes := []string{com2.Hash, com1.Hash[:12], com2.Hash}
+		commits, err := querier.QueryCommits(hashes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commits) != len(hashes) {
+			t.Fatalf("want %v commits, got %v", len(hashes), len(commits))
+		}
+		for i, want := range []*Commit{com2, com1, com2} {
+			if commits[i] == nil || commits[i].Hash != want.Hash || commits[i].Title != want.Title {
+				t.Fatalf("#%v: want %+v, got %+v", i, want, commits[i])
+			}
+		}
+		// Missing commits make git log fail, check that the rest is still found.
+		commits, err = querier.QueryCommits([]string{"1111111111111111111111111111111111111111",
+			"111111111111", com1.Hash})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if commits[0] != nil || commits[1] != nil || commits[2] == nil || commits[2].Hash != com1.Hash {
+			t.Fatalf("bad result for missing commit: %+v", commits)
+		}
+	}
+	// Blob hashes of these two files share the b31b4df1 prefix.
+	for i, data := range []string{"syzkaller 25668\n", "syzkaller 110657\n"} {
+		file := filepath.Join(repoDir, fmt.Sprintf("blob%v", i))
+		if err := osutil.WriteFile(file, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		repo.Git("hash-object", "-w", file)
+	}
+	for _, querier := range []CommitQuerier{repo.repo, cached} {
+		// An ambiguous hash is reported separately and does not affect the rest.
+		commits, err := querier.QueryCommits([]string{com1.Hash, "b31b4df1", com2.Hash[:12]})
+		errs, ok := err.(QueryErrors)
+		if !ok {
+			t.Fatalf("want QueryErrors, got %v", err)
+		}
+		if errs[0] != nil || errs[1] != ErrAmbiguousCommit || errs[2] != nil {
+			t.Fatalf("bad errors for ambiguous commit: %v", []error(errs))
+		}
+		if commits[0] == nil || commits[0].Hash != com1.Hash || commits[1] != nil ||
+			commits[2] == nil || commits[2].Hash != com2.Hash {
+			t.Fatalf("bad result for ambiguous commit: %+v", commits)
+		}
+	}
+	// Other errors are not mistaken for missing commits.
+	brokenDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(brokenDir)
+	broken := newGit(brokenDir, nil, nil)
+	if _, err := broken.QueryCommits([]string{com1.Hash}); err == nil {
+		t.Fatalf("no error for a broken repo")
+	}
+}
+
+func TestTitleCache(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	com1 := repo.CommitChange("first")
+	cached := newGit(repoDir, nil, []RepoOpt{OptTitleCache})
+	check := func(wantFound []*Commit, wantMissing []string) {
+		t.Helper()
+		var titles []string
+		for _, com := range wantFound {
+			titles = append(titles, com.Title)
+		}
+		titles = append(titles, wantMissing...)
+		commits, missing, err := cached.GetCommitsByTitles(titles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var found []string
+		for _, com := range commits {
+			found = append(found, com.Hash)
+		}
+		var want []string
+		for _, com := range wantFound {
+			want = append(want, com.Hash)
+		}
+		sort.Strings(found)
+		sort.Strings(want)
+		sort.Strings(missing)
+		sort.Strings(wantMissing)
+		if diff := cmp.Diff(want, found); diff != "" {
+			t.Fatalf("bad found commits: %v", diff)
+		}
+		if diff := cmp.Diff(wantMissing, missing); diff != "" {
+			t.Fatalf("bad missing titles: %v", diff)
+		}
+	}
+	check([]*Commit{com1}, []string{"second"})
+	if !osutil.IsExist(cached.titleCacheFile()) {
+		t.Fatalf("title cache was not saved")
+	}
+	// Served from the cache.
+	check([]*Commit{com1}, []string{"second"})
+	if !osutil.IsExist(filepath.Join(repoDir, ".git", "syz-commit-cache", com1.Hash+".json")) {
+		t.Fatalf("commit info was not cached")
+	}
+	// The missing title is searched among new commits.
+	com2 := repo.CommitChange("second")
+	check([]*Commit{com1, com2}, nil)
+	// Force push invalidates the cache.
+	repo.Git("reset", "--hard", com1.Hash)
+	check([]*Commit{com1}, []string{"second"})
+}
+
+func TestTitleCacheBranches(t *testing.T) {
+	t.Parallel()
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	origin := MakeTestRepo(t, filepath.Join(baseDir, "origin"))
+	com0 := origin.CommitChange("base")
+	comMaster := origin.CommitChange("master commit")
+	origin.Git("checkout", "-b", "other", com0.Hash)
+	comOther := origin.CommitChange("other commit")
+	origin.Git("checkout", "master")
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, []RepoOpt{OptTitleCache})
+	checkout := func(branch, title string, want *Commit) {
+		t.Helper()
+		if _, err := repo.CheckoutBranch(origin.Dir, branch); err != nil {
+			t.Fatal(err)
+		}
+		commits, missing, err := repo.GetCommitsByTitles([]string{title})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			if len(commits) != 0 || len(missing) != 1 {
+				t.Fatalf("%v: found %+v, missing %q", branch, commits, missing)
+			}
+			return
+		}
+		if len(commits) != 1 || commits[0].Hash != want.Hash {
+			t.Fatalf("%v: found %+v, want %v", branch, commits, want.Hash)
+		}
+	}
+	checkout("master", "master commit", comMaster)
+	checkout("other", "master commit", nil)
+	checkout("other", "other commit", comOther)
+	// Switching to a non-descendant branch must not discard results for other branches.
+	cache := repo.loadTitleCache()
+	masterEntry := cache.Branches[titleCacheKey(origin.Dir, "master")]
+	otherEntry := cache.Branches[titleCacheKey(origin.Dir, "other")]
+	if masterEntry == nil || masterEntry.Tip != comMaster.Hash ||
+		masterEntry.Found[CanonicalizeCommit("master commit")] == nil {
+		t.Fatalf("bad master cache entry: %+v", masterEntry)
+	}
+	if otherEntry == nil || otherEntry.Tip != comOther.Hash ||
+		otherEntry.Found[CanonicalizeCommit("other commit")] == nil ||
+		!otherEntry.Missing[CanonicalizeCommit("master commit")] {
+		t.Fatalf("bad other cache entry: %+v", otherEntry)
+	}
+	// Cache hits return the stored title rather than the requested one.
+	checkout("master", "UPSTREAM: master commit", comMaster)
+	commits, _, err := repo.GetCommitsByTitles([]string{"FROMGIT: master commit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].Title != "master commit" {
+		t.Fatalf("bad cached commits: %+v", commits)
+	}
+}
+
+func TestForcePush(t *testing.T) {
+	t.Parallel()
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	origin := MakeTestRepo(t, filepath.Join(baseDir, "origin"))
+	com0 := origin.CommitChange("commit 0")
+	com1 := origin.CommitChange("commit 1")
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, nil)
+	var events []*ForcePushEvent
+	repo.OnForcePush(func(ev *ForcePushEvent) {
+		events = append(events, ev)
+	})
+	poll := func(want *Commit) {
+		t.Helper()
+		com, err := repo.Poll(origin.Dir, "master")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if com.Hash != want.Hash {
+			t.Fatalf("polled %v, want %v", com.Hash, want.Hash)
+		}
+	}
+	poll(com1)
+	com2 := origin.CommitChange("commit 2")
+	poll(com2)
+	if len(events) != 0 {
+		t.Fatalf("fast-forward was reported as force push: %+v", events[0])
+	}
+	origin.Git("reset", "--hard", com0.Hash)
+	com3 := origin.CommitChange("commit 3")
+	poll(com3)
+	want := []*ForcePushEvent{{
+		Repo:    origin.Dir,
+		Branch:  "master",
+		OldHead: com2.Hash,
+		NewHead: com3.Hash,
+	}}
+	if diff := cmp.Diff(want, events); diff != "" {
+		t.Fatal(diff)
+	}
+	// The same rewrite must not be reported again.
+	poll(com3)
+	if len(events) != 1 {
+		t.Fatalf("force push was reported %v times", len(events))
+	}
+}
+
+func TestCommitDiff(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	repo.CommitFileChange("master", "0")
+	repo.CommitFileChange("master", "1")
+	for _, com := range []*Commit{repo.Commits["master"]["0"], repo.Commits["master"]["1"]} {
+		diffstat, patch, err := repo.repo.CommitDiff(com.Hash, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(diffstat, "file | 2") && !strings.Contains(diffstat, "file | 1") {
+			t.Errorf("bad diffstat for %v:\n%v", com.Title, diffstat)
+		}
+		if !bytes.Contains(patch, []byte("+"+com.Title)) {
+			t.Errorf("bad patch for %v:\n%s", com.Title, patch)
+		}
+		_, short, err := repo.repo.CommitDiff(com.Hash, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasSuffix(short, []byte("\n[truncated]\n")) || len(short) > 21+len("[truncated]\n") {
+			t.Errorf("bad truncated patch:\n%s", short)
+		}
+	}
+}
+
+func TestPatch3Way(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	file := filepath.Join(repoDir, "file")
+	writeLines := func(lines map[int]string) {
+		data := new(bytes.Buffer)
+		for i := 0; i < 20; i++ {
+			line := fmt.Sprintf("line %v", i)
+			if lines[i] != "" {
+				line = lines[i]
+			}
+			fmt.Fprintf(data, "%v\n", line)
+		}
+		if err := osutil.WriteFile(file, data.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeLines(nil)
+	repo.Git("add", file)
+	repo.Git("commit", "-m", "base")
+	base, err := repo.repo.HeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLines(map[int]string{5: "patched 5", 9: "patched 9"})
+	patch, err := repo.repo.git("diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.Git("checkout", "file")
+	// Line 7 is inner context of the patch hunk, so plain patch can't apply it,
+	// but it does not overlap with the patch changes.
+	writeLines(map[int]string{7: "upstream 7"})
+	repo.Git("commit", "-a", "-m", "drift")
+	if err := Patch(repoDir, patch); err != nil {
+		t.Fatalf("3-way patch failed: %v", err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"patched 5", "upstream 7", "patched 9"} {
+		if !bytes.Contains(data, []byte(line)) {
+			t.Fatalf("patched file misses %q:\n%s", line, data)
+		}
+	}
+	// Now a real conflict.
+	repo.Git("reset", "--hard", base.Hash)
+	writeLines(map[int]string{5: "upstream 5"})
+	other := filepath.Join(repoDir, "other")
+	if err := osutil.WriteFile(other, []byte("committed")); err != nil {
+		t.Fatal(err)
+	}
+	repo.Git("add", other)
+	repo.Git("commit", "-a", "-m", "conflict")
+	// Uncommitted changes must survive a failed patch.
+	if err := osutil.WriteFile(other, []byte("uncommitted")); err != nil {
+		t.Fatal(err)
+	}
+	err = Patch(repoDir, patch)
+	conflict, ok := err.(*PatchConflictError)
+	if !ok {
+		t.Fatalf("want PatchConflictError, got %v", err)
+	}
+	if len(conflict.Files) != 1 || conflict.Files[0] != "file" {
+		t.Fatalf("bad conflicting files %q", conflict.Files)
+	}
+	if status, err := repo.repo.git("status", "--porcelain"); err != nil || string(status) != " M other\n" {
+		t.Fatalf("repo is modified after conflict: %s (%v)", status, err)
+	}
+	if data, err := ioutil.ReadFile(other); err != nil || string(data) != "uncommitted" {
+		t.Fatalf("uncommitted file is lost: %q (%v)", data, err)
+	}
+}
+
+func TestBlame(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	file := filepath.Join(repoDir, "file")
+	var commits []*Commit
+	for i, data := range []string{"a\nb\nc\n", "a\nB\nc\n", "a\nB\nc\nd\n"} {
+		if err := osutil.WriteFile(file, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		repo.Git("add", file)
+		commits = append(commits, repo.CommitChange(fmt.Sprintf("commit %v", i)))
+	}
+	tests := []struct {
+		line   int
+		commit string
+		want   *Commit
+	}{
+		{1, "HEAD", commits[0]},
+		{2, "HEAD", commits[1]},
+		{2, commits[0].Hash, commits[0]},
+		{4, "HEAD", commits[2]},
+	}
+	for _, test := range tests {
+		com, err := repo.repo.Blame("file", test.line, test.commit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if com.Hash != test.want.Hash || com.Author != userEmail {
+			t.Errorf("line %v at %v: got %v (%v), want %v", test.line, test.commit,
+				com.Title, com.Author, test.want.Title)
+		}
+	}
+	if _, err := repo.repo.Blame("file", 5, "HEAD"); err == nil {
+		t.Errorf("blame of a missing line succeeded")
+	}
+	// Shallow repos fetch the history needed to attribute old lines.
+	shallow := newGit(filepath.Join(repoDir, "shallow"), nil, []RepoOpt{OptShallow})
+	shallow.depth = 1
+	if _, err := shallow.Poll("file://"+repoDir, "master"); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests[:2] {
+		com, err := shallow.Blame("file", test.line, "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if com.Hash != test.want.Hash {
+			t.Errorf("shallow: line %v: got %v, want %v", test.line, com.Title, test.want.Title)
+		}
+	}
+}
+
+func TestRepoAuth(t *testing.T) {
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	const tokenEnv = "SYZ_TEST_GIT_TOKEN"
+	os.Setenv(tokenEnv, "secret-token")
+	defer os.Unsetenv(tokenEnv)
+	const authRepo = "https://example.com/private.git"
+	if err := repo.repo.SetAuth(authRepo, &RepoAuth{TokenEnv: tokenEnv, TokenUser: "bot"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, arg := range repo.repo.authArgs {
+		if strings.Contains(arg, "secret-token") {
+			t.Fatalf("token is passed on command line: %q", repo.repo.authArgs)
+		}
+	}
+	fill := func(url string) []byte {
+		cmd := osutil.Command("git", append(repo.repo.authArgs, "credential", "fill")...)
+		cmd.Dir = repoDir
+		cmd.Env = filterEnv()
+		cmd.Stdin = strings.NewReader("url=" + url + "\n\n")
+		output, _ := cmd.CombinedOutput()
+		return output
+	}
+	if output := fill(authRepo); !bytes.Contains(output, []byte("username=bot\n")) ||
+		!bytes.Contains(output, []byte("password=secret-token\n")) {
+		t.Fatalf("bad credentials:\n%s", output)
+	}
+	// Credentials must not be sent to other repos (e.g. provided in test requests).
+	for _, url := range []string{"https://attacker.com/private.git", "https://example.com/other.git"} {
+		if output := fill(url); bytes.Contains(output, []byte("secret-token")) {
+			t.Fatalf("credentials are sent to %v:\n%s", url, output)
+		}
+	}
+	keyFile := filepath.Join(repoDir, "key")
+	if err := ioutil.WriteFile(keyFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.repo.SetAuth(authRepo, &RepoAuth{SSHKey: keyFile}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.repo.sshArgs) == 0 {
+		t.Fatalf("no ssh args")
+	}
+	for _, arg := range repo.repo.authArgs {
+		if strings.Contains(arg, keyFile) {
+			t.Fatalf("ssh key is passed to all commands: %q", repo.repo.authArgs)
+		}
+	}
+	// Auth must not break local operations.
+	repo.CommitFileChange("master", "0")
+	if _, err := repo.repo.HeadCommit(); err != nil {
+		t.Fatal(err)
+	}
+	for _, auth := range []*RepoAuth{
+		{SSHKey: filepath.Join(repoDir, "no-such-key")},
+		{TokenEnv: "FOO; rm -rf /"},
+	} {
+		if err := repo.repo.SetAuth(authRepo, auth); err == nil {
+			t.Errorf("bad auth %+v was accepted", auth)
+		}
+	}
+}
+
+func TestReleaseTag(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	com0 := repo.CommitChange("0")
+	repo.SetTag("v1.0")
+	com1 := repo.CommitChange("1")
+	repo.SetTag("v1.1-rc1")
+	com2 := repo.CommitChange("2")
+	repo.SetTag("v1.1")
+	com3 := repo.CommitChange("3")
+	repo.SetTag("other-tag")
+	tests := map[string]string{
+		com0.Hash: "v1.0",
+		com1.Hash: "v1.0",
+		com2.Hash: "v1.1",
+		com3.Hash: "v1.1",
+	}
+	for commit, want := range tests {
+		tag, err := repo.repo.ReleaseTag(commit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tag != want {
+			t.Errorf("commit %v: got tag %q, want %q", commit, tag, want)
+		}
+	}
+	// With release candidates v1.1-rc1 becomes a release as well.
+	err = repo.repo.SetReleaseScheme(&ReleaseScheme{
+		Glob:   "v*",
+		Regexp: `^v([0-9]+)\.([0-9]+)$`,
+		RCs:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tag, err := repo.repo.ReleaseTag(com1.Hash); err != nil || tag != "v1.1-rc1" {
+		t.Errorf("commit %v: got tag %q (%v), want v1.1-rc1", com1.Hash, tag, err)
+	}
+	if tags, err := repo.repo.previousReleaseTags(com3.Hash, false); err != nil ||
+		!reflect.DeepEqual(tags, []string{"v1.1", "v1.1-rc1", "v1.0"}) {
+		t.Errorf("commit %v: got previous tags %q (%v)", com3.Hash, tags, err)
+	}
+	repo.Git("checkout", "--orphan", "untagged")
+	untagged := repo.CommitChange("untagged")
+	tag, err := repo.repo.ReleaseTag(untagged.Hash)
+	if err != nil || tag != "" {
+		t.Errorf("untagged commit: got tag %q (%v)", tag, err)
+	}
+}
+
+func TestVerifySignatures(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	gpgHome := filepath.Join(baseDir, "gnupg")
+	if err := os.Mkdir(gpgHome, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// Not parallel because of the env var.
+	os.Setenv("GNUPGHOME", gpgHome)
+	defer os.Unsetenv("GNUPGHOME")
+	if _, err := osutil.RunCmd(time.Minute, "", "gpg", "--batch", "--passphrase", "",
+		"--quick-gen-key", userEmail, "ed25519", "sign", "never"); err != nil {
+		t.Skipf("failed to generate gpg key: %v", err)
+	}
+	origin := MakeTestRepo(t, filepath.Join(baseDir, "origin"))
+	origin.Git("config", "user.signingkey", userEmail)
+	origin.Git("commit", "--allow-empty", "-S", "-m", "signed")
+	signed, err := origin.repo.HeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := osutil.WriteFile(filepath.Join(origin.Dir, "source.c"), []byte("int main() {}")); err != nil {
+		t.Fatal(err)
+	}
+	origin.Git("add", "source.c")
+	unsigned := origin.CommitChange("unsigned")
+	origin.Git("tag", "-s", "-m", "release", "v1.0")
+	untagged := origin.CommitChange("untagged")
+
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, []RepoOpt{OptVerifySignatures})
+	for _, test := range []struct {
+		commit string
+		ok     bool
+	}{
+		{signed.Hash, true},
+		{unsigned.Hash, true}, // signed with the tag
+		{untagged.Hash, false},
+	} {
+		com, err := repo.CheckoutCommit(origin.Dir, test.commit)
+		if test.ok != (err == nil) {
+			t.Errorf("commit %v: want ok=%v, got %v", test.commit, test.ok, err)
+			continue
+		}
+		if err == nil && com.Signature != SignatureGood {
+			t.Errorf("commit %v: bad signature status %v", test.commit, com.Signature)
+		}
+		if err != nil && osutil.IsExist(filepath.Join(repo.dir, "source.c")) {
+			t.Errorf("commit %v: unverified sources are left in the work tree", test.commit)
+		}
+	}
+	// Without the public key signatures can't be checked.
+	os.Setenv("GNUPGHOME", baseDir)
+	if _, err := repo.CheckoutCommit(origin.Dir, signed.Hash); err == nil {
+		t.Errorf("checkout succeeded without the public key")
+	}
+}
+
+func TestCollectRemotes(t *testing.T) {
+	t.Parallel()
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	repo1 := CreateTestRepo(t, baseDir, "repo1")
+	repo2 := CreateTestRepo(t, baseDir, "repo2")
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, nil)
+	if _, err := repo.Poll(repo1.Dir, "master"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CheckoutCommit(repo1.Dir, repo1.Commits["branch1"]["0"].Hash); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CheckoutCommit(repo2.Dir, repo2.Commits["branch1"]["0"].Hash); err != nil {
+		t.Fatal(err)
+	}
+	remote1, remote2 := hash.String([]byte(repo1.Dir)), hash.String([]byte(repo2.Dir))
+	// Pretend that repo1 was last fetched long time ago.
+	old := strconv.FormatInt(time.Now().Add(-10*24*time.Hour).Unix(), 10)
+	if _, err := repo.git("config", "remote."+remote1+"."+remoteLastUsedKey, old); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := repo.CollectRemotes(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{remote1}, removed); diff != "" {
+		t.Fatal(diff)
+	}
+	output, err := repo.git("remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remotes := strings.Fields(string(output))
+	sort.Strings(remotes)
+	if diff := cmp.Diff([]string{remote2, "origin"}, remotes); diff != "" {
+		t.Fatal(diff)
+	}
+	// The remote is re-added on the next use.
+	if _, err := repo.CheckoutCommit(repo1.Dir, repo1.Commits["branch1"]["1"].Hash); err != nil {
+		t.Fatal(err)
+	}
+	removed, err = repo.CollectRemotes(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("unexpectedly removed remotes %v", removed)
+	}
+}
+
+func TestShallowRepo(t *testing.T) {
+	t.Parallel()
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	origin := MakeTestRepo(t, filepath.Join(baseDir, "origin"))
+	var commits []*Commit
+	for i := 0; i < 5; i++ {
+		commits = append(commits, origin.CommitChange(fmt.Sprintf("commit %v", i)))
+	}
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, []RepoOpt{OptShallow})
+	repo.depth = 2
+	url := "file://" + origin.Dir
+	head, err := repo.Poll(url, "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Hash != commits[4].Hash {
+		t.Fatalf("bad head commit %v, want %v", head.Hash, commits[4].Hash)
+	}
+	titles, err := repo.ListRecentCommits("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != 2 {
+		t.Fatalf("shallow repo has %v commits: %q", len(titles), titles)
+	}
+	// Switching to an old commit must fetch the rest of history.
+	com, err := repo.SwitchCommit(commits[0].Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if com.Hash != commits[0].Hash {
+		t.Fatalf("bad commit %v, want %v", com.Hash, commits[0].Hash)
+	}
+	titles, err = repo.ListRecentCommits(commits[4].Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(titles) != 5 {
+		t.Fatalf("deepened repo has %v commits: %q", len(titles), titles)
+	}
+}
+
+func TestShallowRepoSearch(t *testing.T) {
+	t.Parallel()
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	origin := MakeTestRepo(t, filepath.Join(baseDir, "origin"))
+	commitAt := func(title string, age time.Duration) *Commit {
+		date := time.Now().Add(-age).Format(time.RFC3339)
+		cmd := osutil.Command("git", "commit", "--allow-empty", "-m", title)
+		cmd.Dir = origin.Dir
+		cmd.Env = append(filterEnv(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+		if _, err := osutil.Run(time.Minute, cmd); err != nil {
+			t.Fatal(err)
+		}
+		com, err := origin.repo.HeadCommit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return com
+	}
+	old := commitAt("old commit", shallowSearchPeriod+30*24*time.Hour)
+	recent := commitAt("recent commit", 10*24*time.Hour)
+	commitAt("commit 1", 0)
+	commitAt("commit 2", 0)
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, []RepoOpt{OptShallow})
+	repo.depth = 2
+	if _, err := repo.Poll("file://"+origin.Dir, "master"); err != nil {
+		t.Fatal(err)
+	}
+	commits, missing, err := repo.GetCommitsByTitles([]string{"old commit", "recent commit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].Hash != recent.Hash {
+		t.Fatalf("found %+v, want %v", commits, recent.Hash)
+	}
+	if len(missing) != 1 || missing[0] != "old commit" {
+		t.Fatalf("bad missing titles %q", missing)
+	}
+	// The search must not fetch history beyond shallowSearchPeriod.
+	if _, err := repo.git("cat-file", "-e", old.Hash); err == nil {
+		t.Fatalf("the old commit was fetched")
+	}
+}
+
 func checkCommit(t *testing.T, idx int, test testCommit, com *Commit, checkTags bool) {
 	if !checkTags {
 		return
@@ -295,6 +1095,30 @@ Comment out an assertion that's now bogus and add a comment.
 		cc:     []string{userEmail},
 		tags:   []string{"3e3c7cfa8093f8de047e"},
 	},
+	{
+		description: `net: fix use-after-free in sk_destruct
+
+Closes: https://syzkaller.appspot.com/bug?extid=5be4e6e5a5e86bdc4f84
+`,
+		title:  "net: fix use-after-free in sk_destruct",
+		author: userEmail,
+		cc:     []string{userEmail},
+		tags:   []string{"5be4e6e5a5e86bdc4f84"},
+	},
+	{
+		description: `fs: fix deadlock in rename
+
+Reported-and-tested-by: syzbot+4a2ba4c0dd9ee4f88d8a@my.mail.com
+Link: https://syzkaller.appspot.com/bug?extid=4a2ba4c0dd9ee4f88d8a
+Link: <https://syzkaller.appspot.com/bug?extid=e4d2f4bb1ddcb0cd0f27>
+Link: https://syzkaller.example.com/bug?extid=0123456789abcdef0123
+Link: https://syzkaller.appspot.com.example.com/bug?extid=3210fedcba9876543210
+`,
+		title:  "fs: fix deadlock in rename",
+		author: userEmail,
+		cc:     []string{"syzbot+4a2ba4c0dd9ee4f88d8a@my.mail.com", userEmail},
+		tags:   []string{"4a2ba4c0dd9ee4f88d8a", "e4d2f4bb1ddcb0cd0f27"},
+	},
 }
 
 func TestBisect(t *testing.T) {
@@ -378,7 +1202,7 @@ func TestBisect(t *testing.T) {
 	}
 	for i, test := range tests {
 		t.Logf("TEST %v", i)
-		result, err := repo.repo.Bisect(commits[4], commits[0], (*testWriter)(t), test.pred)
+		result, err := repo.repo.Bisect(commits[4], commits[0], nil, (*testWriter)(t), test.pred)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -395,6 +1219,351 @@ func TestBisect(t *testing.T) {
 	}
 }
 
+func TestBisectLFS(t *testing.T) {
+	baseDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(baseDir)
+	// Fake git-lfs that logs the commit it was invoked on.
+	binDir := filepath.Join(baseDir, "bin")
+	lfsLog := filepath.Join(baseDir, "lfs.log")
+	script := fmt.Sprintf("#!/bin/sh\necho $(git rev-parse HEAD) \"$@\" >> %v\n", lfsLog)
+	if err := osutil.MkdirAll(binDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := osutil.WriteExecFile(filepath.Join(binDir, "git-lfs"), []byte(script)); err != nil {
+		t.Fatal(err)
+	}
+	// Not parallel because of the env var.
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", binDir+string(filepath.ListSeparator)+os.Getenv("PATH"))
+	origin := MakeTestRepo(t, filepath.Join(baseDir, "origin"))
+	var commits []*Commit
+	for i := 0; i < 5; i++ {
+		commits = append(commits, origin.CommitChange(fmt.Sprintf("commit %v", i)))
+	}
+	repo := newGit(filepath.Join(baseDir, "repo"), nil, nil)
+	if _, err := repo.CheckoutCommit(origin.Dir, commits[4].Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetLFS(&LFSConfig{Paths: []string{"firmware/**"}}); err != nil {
+		t.Fatal(err)
+	}
+	remote := hash.String([]byte(origin.Dir))
+	lastPull := func() string {
+		data, err := ioutil.ReadFile(lfsLog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		return lines[len(lines)-1]
+	}
+	steps := 0
+	_, err = repo.Bisect(commits[4].Hash, commits[0].Hash, nil, (*testWriter)(t), func() (BisectResult, error) {
+		steps++
+		current, err := repo.HeadCommit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := fmt.Sprintf("%v pull --include=firmware/** --exclude= %v", current.Hash, remote)
+		if got := lastPull(); got != want {
+			t.Errorf("LFS objects are not pulled for the bisection step:\nwant: %v\ngot:  %v", want, got)
+		}
+		return BisectBad, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if steps == 0 {
+		t.Fatalf("no bisection steps")
+	}
+}
+
+func TestBisectPolicy(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	var commits []*Commit
+	for i := 0; i < 10; i++ {
+		commits = append(commits, repo.CommitChange(fmt.Sprintf("commit %v", i)))
+	}
+	// Commit 7 is the culprit, commits 2-4 don't build.
+	index := func(hash string) int {
+		for i, com := range commits {
+			if com.Hash == hash {
+				return i
+			}
+		}
+		t.Fatalf("unknown commit %v", hash)
+		return -1
+	}
+	tests := []struct {
+		policy *BisectPolicy
+		broken func(idx int) bool
+		result []int
+		// Commits that must not be tested.
+		untested []int
+	}{
+		{
+			policy:   &BisectPolicy{BrokenRanges: []string{commits[1].Hash + ".." + commits[4].Hash}},
+			broken:   func(idx int) bool { return idx >= 2 && idx <= 4 },
+			result:   []int{7},
+			untested: []int{2, 3, 4},
+		},
+		{
+			policy: &BisectPolicy{SkipExpansion: 3},
+			broken: func(idx int) bool { return idx >= 2 && idx <= 4 },
+			result: []int{7},
+		},
+		{
+			policy: &BisectPolicy{MaxSkips: 2},
+			broken: func(idx int) bool { return true },
+			result: []int{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+	}
+	for i, test := range tests {
+		tested := make(map[int]bool)
+		pred := func() (BisectResult, error) {
+			current, err := repo.repo.HeadCommit()
+			if err != nil {
+				return 0, err
+			}
+			idx := index(current.Hash)
+			tested[idx] = true
+			switch {
+			case test.broken(idx):
+				return BisectSkip, nil
+			case idx >= 7:
+				return BisectBad, nil
+			default:
+				return BisectGood, nil
+			}
+		}
+		res, err := repo.repo.Bisect(commits[9].Hash, commits[0].Hash, test.policy, (*testWriter)(t), pred)
+		if err != nil {
+			t.Fatalf("test #%v: %v", i, err)
+		}
+		var got []int
+		for _, com := range res {
+			got = append(got, index(com.Hash))
+		}
+		sort.Ints(got)
+		if diff := cmp.Diff(test.result, got); diff != "" {
+			t.Fatalf("test #%v: %v", i, diff)
+		}
+		for _, idx := range test.untested {
+			if tested[idx] {
+				t.Fatalf("test #%v: commit %v was tested", i, idx)
+			}
+		}
+	}
+}
+
+func TestBisectSkipExpansionMerge(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, repoDir)
+	// master: c0 c1 c2 merge c3 ... c7, the merged branch forks at c1 and has b0 b1.
+	// The merge is the middle of the range, so it's tested first and it does not build.
+	var master, branch []*Commit
+	for i := 0; i <= 2; i++ {
+		master = append(master, repo.CommitChange(fmt.Sprintf("c%v", i)))
+		if i == 1 {
+			repo.Git("branch", "side")
+		}
+	}
+	repo.Git("checkout", "side")
+	for i := 0; i < 2; i++ {
+		branch = append(branch, repo.CommitChange(fmt.Sprintf("b%v", i)))
+	}
+	repo.Git("checkout", "master")
+	repo.Git("merge", "--no-ff", "-m", "merge", "side")
+	merge, err := repo.repo.HeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 3; i < 8; i++ {
+		master = append(master, repo.CommitChange(fmt.Sprintf("c%v", i)))
+	}
+	// b1 is the culprit. Skipping the merge must skip only its first-parent ancestors (c2),
+	// but not the merged branch, so the culprit can still be identified.
+	bad := map[string]bool{branch[1].Hash: true, merge.Hash: true}
+	for _, com := range master[3:] {
+		bad[com.Hash] = true
+	}
+	mergeTested := false
+	pred := func() (BisectResult, error) {
+		current, err := repo.repo.HeadCommit()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case current.Hash == merge.Hash:
+			mergeTested = true
+			return BisectSkip, nil
+		case current.Hash == master[2].Hash:
+			t.Errorf("first-parent ancestor of the skipped merge was tested")
+			return BisectSkip, nil
+		case bad[current.Hash]:
+			return BisectBad, nil
+		default:
+			return BisectGood, nil
+		}
+	}
+	policy := &BisectPolicy{SkipExpansion: 1}
+	res, err := repo.repo.Bisect(master[7].Hash, master[0].Hash, policy, (*testWriter)(t), pred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mergeTested {
+		t.Fatalf("the merge commit was not tested")
+	}
+	if len(res) != 1 || res[0].Hash != branch[1].Hash {
+		t.Fatalf("bisection result %+v, want %v", res, branch[1].Hash)
+	}
+}
+
+func TestBisectParallel(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, filepath.Join(repoDir, "repo"))
+	// master: c0 ... c10 merge c11 ... c19, the merged branch forks at c5 and has b0 ... b4.
+	var master, branch []*Commit
+	for i := 0; i <= 10; i++ {
+		master = append(master, repo.CommitChange(fmt.Sprintf("c%v", i)))
+		if i == 5 {
+			repo.Git("branch", "side")
+		}
+	}
+	repo.Git("checkout", "side")
+	for i := 0; i < 5; i++ {
+		branch = append(branch, repo.CommitChange(fmt.Sprintf("b%v", i)))
+	}
+	repo.Git("checkout", "master")
+	repo.Git("merge", "--no-ff", "-m", "merge", "side")
+	merge, err := repo.repo.HeadCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 11; i < 20; i++ {
+		master = append(master, repo.CommitChange(fmt.Sprintf("c%v", i)))
+	}
+	tests := []struct {
+		culprit *Commit
+		skip    []*Commit
+		result  []*Commit
+	}{
+		{culprit: master[14], result: []*Commit{master[14]}},
+		{culprit: master[1], result: []*Commit{master[1]}},
+		{culprit: master[19], result: []*Commit{master[19]}},
+		{culprit: branch[2], result: []*Commit{branch[2]}},
+		{culprit: merge, result: []*Commit{merge}},
+		{
+			culprit: master[14],
+			skip:    []*Commit{master[13], master[14]},
+			result:  []*Commit{master[13], master[14], master[15]},
+		},
+	}
+	for _, k := range []int{1, 3} {
+		for i, test := range tests {
+			pred := func(com *Commit, dir string) (BisectResult, error) {
+				head, err := newGit(dir, nil, nil).HeadCommit()
+				if err != nil {
+					return 0, err
+				}
+				if head.Hash != com.Hash {
+					return 0, fmt.Errorf("worktree is at %v, want %v", head.Hash, com.Hash)
+				}
+				for _, skip := range test.skip {
+					if com.Hash == skip.Hash {
+						return BisectSkip, nil
+					}
+				}
+				if _, err := repo.repo.git("merge-base", "--is-ancestor", test.culprit.Hash, com.Hash); err == nil {
+					return BisectBad, nil
+				}
+				return BisectGood, nil
+			}
+			res, err := repo.repo.BisectParallel(master[19].Hash, master[0].Hash, k, (*testWriter)(t), pred)
+			if err != nil {
+				t.Fatalf("k=%v test #%v: %v", k, i, err)
+			}
+			var got, want []string
+			for _, com := range res {
+				got = append(got, com.Title)
+			}
+			for _, com := range test.result {
+				want = append(want, com.Title)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("k=%v test #%v: %v", k, i, diff)
+			}
+		}
+	}
+	if osutil.IsExist(repo.Dir + "-bisect") {
+		t.Fatalf("bisection worktrees were not removed")
+	}
+}
+
+func TestBisectParallelStreaming(t *testing.T) {
+	t.Parallel()
+	repoDir, err := ioutil.TempDir("", "syz-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoDir)
+	repo := MakeTestRepo(t, filepath.Join(repoDir, "repo"))
+	var commits []*Commit
+	for i := 0; i < 64; i++ {
+		commits = append(commits, repo.CommitChange(fmt.Sprintf("c%v", i)))
+	}
+	culprit := commits[40]
+	// The first test is slow, the other worker must go on narrowing the range meanwhile.
+	var mu sync.Mutex
+	calls := 0
+	otherDone := make(chan bool)
+	pred := func(com *Commit, dir string) (BisectResult, error) {
+		mu.Lock()
+		calls++
+		first := calls == 1
+		if calls == 4 {
+			close(otherDone)
+		}
+		mu.Unlock()
+		if first {
+			select {
+			case <-otherDone:
+			case <-time.After(time.Minute):
+				return 0, fmt.Errorf("other tests did not proceed while the first one is running")
+			}
+		}
+		if _, err := repo.repo.git("merge-base", "--is-ancestor", culprit.Hash, com.Hash); err == nil {
+			return BisectBad, nil
+		}
+		return BisectGood, nil
+	}
+	res, err := repo.repo.BisectParallel(commits[63].Hash, commits[0].Hash, 2, (*testWriter)(t), pred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Hash != culprit.Hash {
+		t.Fatalf("bad result: %+v", res)
+	}
+}
+
 type testWriter testing.T
 
 func (t *testWriter) Write(data []byte) (int, error) {
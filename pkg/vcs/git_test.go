@@ -51,7 +51,7 @@ Signed-off-by: Linux Master <linux@linux-foundation.org>
 		},
 	}
 	for input, com := range tests {
-		res, err := gitParseCommit([]byte(input), nil, nil, nil)
+		res, err := gitParseCommit([]byte(input), nil, nil, nil, nil)
 		if err != nil && com != nil {
 			t.Fatalf("want %+v, got error: %v", com, err)
 		}
@@ -115,11 +115,163 @@ v1.
 		"v2.6.13",
 		"v2.6.12",
 	}
-	got, err := gitParseReleaseTags([]byte(input))
-	if err != nil {
+	got := linuxReleases.parseTags([]byte(input))
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got bad tags\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestReleaseTagLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		less bool
+	}{
+		{"v4.9", "v4.10", true},
+		{"v4.10", "v4.9", false},
+		{"v2.6.39", "v3.0", true},
+		{"v5.6", "v5.6", false},
+		{"", "v2.6.12", true},
+		{"v5.6-rc1", "v4.0", true},
+		{"v4.0", "", false},
+	}
+	for _, test := range tests {
+		if less := ReleaseTagLess(test.a, test.b); less != test.less {
+			t.Errorf("ReleaseTagLess(%q, %q) = %v, want %v", test.a, test.b, less, test.less)
+		}
+	}
+}
+
+func TestReleaseScheme(t *testing.T) {
+	android := &ReleaseScheme{
+		Glob:   "android-*",
+		Regexp: `^android-([0-9]+)\.([0-9]+)\.([0-9]+)_r([0-9]+)$`,
+	}
+	if err := android.compile(); err != nil {
 		t.Fatal(err)
 	}
+	got := android.parseTags([]byte(`
+android-13.0.0_r2
+android-12.1.0_r10
+android-13.0.0_r10
+android-12.1.0_r9
+android-cts-13.0_r1
+v5.6
+`))
+	want := []string{
+		"android-13.0.0_r10",
+		"android-13.0.0_r2",
+		"android-12.1.0_r10",
+		"android-12.1.0_r9",
+	}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("got bad tags\ngot:  %+v\nwant: %+v", got, want)
 	}
+	rcs := &ReleaseScheme{
+		Glob:   "v*.*",
+		Regexp: `^v([0-9]+)\.([0-9]+)$`,
+		RCs:    true,
+	}
+	if err := rcs.compile(); err != nil {
+		t.Fatal(err)
+	}
+	got = rcs.parseTags([]byte("v5.6-rc2\nv5.5\nv5.6\nv5.6-rc10\nv5.6-rc1\nv5.6-foo\n"))
+	want = []string{"v5.6", "v5.6-rc10", "v5.6-rc2", "v5.6-rc1", "v5.5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got bad tags\ngot:  %+v\nwant: %+v", got, want)
+	}
+	for _, bad := range []*ReleaseScheme{
+		{Glob: "", Regexp: `^v([0-9]+)$`},
+		{Glob: "v*", Regexp: `^v[0-9]+$`},
+		{Glob: "v*", Regexp: `^v([0-9]+$`},
+	} {
+		if err := bad.compile(); err == nil {
+			t.Errorf("bad scheme %+v compiled", bad)
+		}
+	}
+}
+
+func TestParseLinkTags(t *testing.T) {
+	input := `
+Some description mentioning https://lore.kernel.org/r/not-a-tag@example.com.
+
+Link: http://lkml.kernel.org/r/20180504103159.19938-1-bigeasy@linutronix.de
+Link: https://lore.kernel.org/linux-mm/000000000000a1b2c3@google.com/
+Link: https://lore.kernel.org/r/20200101.1234%40host.org
+Link: https://patch.msgid.link/<20200102-2-foo@bar.com>
+Link: https://bugzilla.kernel.org/show_bug.cgi?id=1
+Link: https://lore.kernel.org/r/20180504103159.19938-1-bigeasy@linutronix.de
+Signed-off-by: Foo Bar <foo@bar.com>
+`
+	want := []string{
+		"000000000000a1b2c3@google.com",
+		"20180504103159.19938-1-bigeasy@linutronix.de",
+		"20200101.1234@host.org",
+		"20200102-2-foo@bar.com",
+	}
+	got := ParseLinkTags([]byte(input))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+	idx := NewLinkIndex()
+	idx.Add("1111", got[:2])
+	idx.Add("2222", []string{"<" + got[0] + ">"})
+	if diff := cmp.Diff([]string{"1111", "2222"}, idx.Commits("<000000000000a1b2c3@google.com>")); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(got[:2], idx.MessageIDs("1111")); diff != "" {
+		t.Fatal(diff)
+	}
+	if idx.Len() != 2 {
+		t.Fatalf("want 2 indexed ids, got %v", idx.Len())
+	}
+}
+
+func TestLFSPullArgs(t *testing.T) {
+	got := lfsPullArgs("origin", []string{"firmware/**", "*.bin"})
+	want := []string{"lfs", "pull", "--include=firmware/**,*.bin", "--exclude=", "origin"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatal(diff)
+	}
+	repo := newGit("", nil, nil)
+	for _, paths := range [][]string{{""}, {"a,b"}} {
+		if err := repo.SetLFS(&LFSConfig{Paths: paths}); err == nil {
+			t.Errorf("no error for bad LFS paths %q", paths)
+		}
+	}
+}
+
+func TestBisectCandidate(t *testing.T) {
+	set := func(idxs ...int) map[int]bool {
+		m := make(map[int]bool)
+		for _, idx := range idxs {
+			m[idx] = true
+		}
+		return m
+	}
+	tests := []struct {
+		lo, hi  int
+		skipped map[int]bool
+		running map[int]bool
+		want    int
+	}{
+		{-1, 9, nil, nil, 4},
+		{-1, 0, nil, nil, -1},
+		{3, 5, nil, nil, 4},
+		{3, 5, set(4), nil, -1},
+		{3, 5, nil, set(4), -1},
+		{-1, 9, set(4), nil, 3},
+		{-1, 9, set(3, 4), nil, 5},
+		// The largest part not split by running tests is 3..9.
+		{-1, 9, nil, set(3), 6},
+		{-1, 9, nil, set(2, 6), 4},
+		{-1, 9, set(4), set(2, 6), 3},
+		{-1, 9, set(3, 4, 5), set(2, 6), 0},
+		{-1, 9, set(0, 1, 3, 4, 5), set(2, 6), 7},
+	}
+	for i, test := range tests {
+		got := bisectCandidate(test.lo, test.hi, test.skipped, test.running)
+		if got != test.want {
+			t.Errorf("test #%v: got %v, want %v", i, got, test.want)
+		}
+	}
 }
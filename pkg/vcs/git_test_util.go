@@ -17,6 +17,7 @@ const (
 	userEmail           = `test@syzkaller.com`
 	userName            = `Test Syzkaller`
 	extractFixTagsEmail = `"syzbot" <syzbot@my.mail.com>`
+	extractFixTagsLink  = `https://syzkaller.appspot.com`
 )
 
 type TestRepo struct {
@@ -49,7 +50,7 @@ func MakeTestRepo(t *testing.T, dir string) *TestRepo {
 		Dir:     dir,
 		name:    filepath.Base(dir),
 		Commits: make(map[string]map[string]*Commit),
-		repo:    newGit(dir, ignoreCC),
+		repo:    newGit(dir, ignoreCC, nil),
 	}
 	repo.Git("init")
 	repo.Git("config", "--add", "user.email", userEmail)
@@ -123,7 +124,7 @@ func CloneTestRepo(t *testing.T, baseDir, name string, originRepo *TestRepo) *Te
 		Dir:     dir,
 		name:    filepath.Base(dir),
 		Commits: make(map[string]map[string]*Commit),
-		repo:    newGit(dir, ignoreCC),
+		repo:    newGit(dir, ignoreCC, nil),
 	}
 	repo.Git("clone", originRepo.Dir, repo.Dir)
 	return repo
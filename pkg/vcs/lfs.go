@@ -0,0 +1,74 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LFSConfig enables Git LFS support for repos that store large files (e.g. firmware blobs) in LFS.
+// With LFS enabled, checkouts don't download LFS objects and leave pointer files instead,
+// only files under Paths are explicitly pulled after each checkout. Requires git-lfs.
+type LFSConfig struct {
+	// Files to pull (in git lfs pull --include syntax, e.g. "firmware/**").
+	Paths []string `json:"paths,omitempty"`
+}
+
+// LFSSetter may be optionally implemented by Repo.
+type LFSSetter interface {
+	// SetLFS enables LFS support for all subsequent checkouts.
+	SetLFS(cfg *LFSConfig) error
+}
+
+// SetLFS enables LFS support for the repo, if it's configured.
+func SetLFS(repo Repo, cfg *LFSConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	setter, ok := repo.(LFSSetter)
+	if !ok {
+		return fmt.Errorf("repo does not support LFS")
+	}
+	return setter.SetLFS(cfg)
+}
+
+func (git *git) SetLFS(cfg *LFSConfig) error {
+	for _, path := range cfg.Paths {
+		if path == "" || strings.Contains(path, ",") {
+			return fmt.Errorf("bad LFS path %q", path)
+		}
+	}
+	if _, err := git.git("lfs", "version"); err != nil {
+		return fmt.Errorf("git-lfs is not available: %v", err)
+	}
+	git.lfs = cfg
+	return nil
+}
+
+// lfsPull downloads and checks out LFS objects under the configured paths from the remote
+// (name or address) the current commit was fetched from (see lfsRemote).
+func (git *git) lfsPull() error {
+	if git.lfs == nil || len(git.lfs.Paths) == 0 {
+		return nil
+	}
+	// Credentials are used only if the remote is the configured repo.
+	url, err := git.remoteURL(git.lfsRemote)
+	if err != nil {
+		// Not a remote name, but an address.
+		url = git.lfsRemote
+	}
+	if _, err := git.gitRemote(url, lfsPullArgs(git.lfsRemote, git.lfs.Paths)...); err != nil {
+		return fmt.Errorf("failed to pull LFS objects: %v", err)
+	}
+	return nil
+}
+
+func lfsPullArgs(remote string, paths []string) []string {
+	args := []string{"lfs", "pull", "--include=" + strings.Join(paths, ","), "--exclude="}
+	if remote != "" {
+		args = append(args, remote)
+	}
+	return args
+}
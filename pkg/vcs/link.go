@@ -0,0 +1,129 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// LinkIndexer may be optionally implemented by Repo.
+type LinkIndexer interface {
+	// ListLinkTags builds index of Link: tags referencing mailing list archives
+	// (lore.kernel.org, lkml.kernel.org, patch.msgid.link) for commits reachable from baseCommit.
+	ListLinkTags(baseCommit string) (*LinkIndex, error)
+}
+
+// LinkIndex maps Message-IDs referenced by Link: tags to commits and vice versa.
+// Message-IDs are stored without angle brackets.
+type LinkIndex struct {
+	commits map[string][]string // Message-ID -> commit hashes
+	links   map[string][]string // commit hash -> Message-IDs
+}
+
+func NewLinkIndex() *LinkIndex {
+	return &LinkIndex{
+		commits: make(map[string][]string),
+		links:   make(map[string][]string),
+	}
+}
+
+// Add records that commit hash has Link: tags referencing msgIDs.
+func (idx *LinkIndex) Add(hash string, msgIDs []string) {
+	for _, id := range msgIDs {
+		id = canonicalMessageID(id)
+		if containsString(idx.links[hash], id) {
+			continue
+		}
+		idx.links[hash] = append(idx.links[hash], id)
+		idx.commits[id] = append(idx.commits[id], hash)
+	}
+}
+
+// Commits returns hashes of commits that reference the Message-ID msgID.
+func (idx *LinkIndex) Commits(msgID string) []string {
+	return idx.commits[canonicalMessageID(msgID)]
+}
+
+// MessageIDs returns Message-IDs referenced by the commit hash.
+func (idx *LinkIndex) MessageIDs(hash string) []string {
+	return idx.links[hash]
+}
+
+// Len returns number of indexed Message-IDs.
+func (idx *LinkIndex) Len() int {
+	return len(idx.commits)
+}
+
+// ParseLinkTags extracts Message-IDs from Link: tags in the commit description.
+func ParseLinkTags(description []byte) []string {
+	var ids []string
+	s := bufio.NewScanner(bytes.NewReader(description))
+	for s.Scan() {
+		match := linkTagRe.FindSubmatch(s.Bytes())
+		if match == nil {
+			continue
+		}
+		id, err := url.PathUnescape(string(match[1]))
+		if err != nil {
+			continue
+		}
+		id = canonicalMessageID(id)
+		if !containsString(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+var linkTagRe = regexp.MustCompile(`^\s*Link:\s*<?https?://(?:(?:lore|lkml)\.kernel\.org/[^/\s]+|patch\.msgid\.link)/([^/\s>]+(?:@|%40)[^/\s>]+)/?`)
+
+func canonicalMessageID(id string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(id), "<"), ">")
+}
+
+func containsString(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func (git *git) ListLinkTags(baseCommit string) (*LinkIndex, error) {
+	const commitSeparator = "---===syzkaller-commit-separator===---"
+	args := []string{"log", "--grep", "^Link:", "--format=%H%n%b%n" + commitSeparator, baseCommit}
+	idx := NewLinkIndex()
+	err := git.gitStream(args, func(r io.Reader) error {
+		s := bufio.NewScanner(r)
+		hash := ""
+		body := new(bytes.Buffer)
+		for s.Scan() {
+			ln := s.Bytes()
+			switch {
+			case hash == "":
+				hash = strings.TrimSpace(string(ln))
+			case string(ln) == commitSeparator:
+				idx.Add(hash, ParseLinkTags(body.Bytes()))
+				hash = ""
+				body.Reset()
+			default:
+				body.Write(ln)
+				body.WriteByte('\n')
+			}
+		}
+		return s.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
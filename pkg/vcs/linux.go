@@ -14,7 +14,6 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -29,12 +28,12 @@ type linux struct {
 var _ Bisecter = new(linux)
 var _ ConfigMinimizer = new(linux)
 
-func newLinux(dir string) *linux {
+func newLinux(dir string, opts []RepoOpt) *linux {
 	ignoreCC := map[string]bool{
 		"stable@vger.kernel.org": true,
 	}
 	return &linux{
-		git: newGit(dir, ignoreCC),
+		git: newGit(dir, ignoreCC, opts),
 	}
 }
 
@@ -71,40 +70,21 @@ func (ctx *linux) PreviousReleaseTags(commit string) ([]string, error) {
 	return tags, nil
 }
 
-func gitParseReleaseTags(output []byte) ([]string, error) {
-	var tags []string
-	for _, tag := range bytes.Split(output, []byte{'\n'}) {
-		if releaseTagRe.Match(tag) && gitReleaseTagToInt(string(tag)) != 0 {
-			tags = append(tags, string(tag))
-		}
-	}
-	sort.Slice(tags, func(i, j int) bool {
-		return gitReleaseTagToInt(tags[i]) > gitReleaseTagToInt(tags[j])
-	})
-	return tags, nil
-}
-
-func gitReleaseTagToInt(tag string) uint64 {
-	matches := releaseTagRe.FindStringSubmatchIndex(tag)
-	v1, err := strconv.ParseUint(tag[matches[2]:matches[3]], 10, 64)
-	if err != nil {
-		return 0
-	}
-	v2, err := strconv.ParseUint(tag[matches[4]:matches[5]], 10, 64)
-	if err != nil {
-		return 0
-	}
-	var v3 uint64
-	if matches[6] != -1 {
-		v3, err = strconv.ParseUint(tag[matches[6]:matches[7]], 10, 64)
-		if err != nil {
-			return 0
-		}
-	}
-	return v1*1e6 + v2*1e3 + v3
+// ReleaseTagLess reports whether Linux release tag a (e.g. v5.6) is older than release tag b.
+// Tags that are not releases are considered older than any release.
+func ReleaseTagLess(a, b string) bool {
+	return linuxReleases.less(a, b)
 }
 
 func (ctx *linux) EnvForCommit(binDir, commit string, kernelConfig []byte) (*BisectEnv, error) {
+	if ctx.git.releases != linuxReleases {
+		// The adjustments below are based on mainline release tags, we can't map tags of
+		// other release schemes to mainline versions, so use settings for recent kernels.
+		return &BisectEnv{
+			Compiler:     filepath.Join(binDir, "gcc-"+linuxCompilerVersion(nil), "bin", "gcc"),
+			KernelConfig: linuxAlterConfigs(kernelConfig, nil),
+		}, nil
+	}
 	tagList, err := ctx.previousReleaseTags(commit, true)
 	if err != nil {
 		return nil, err
@@ -127,9 +107,11 @@ func (ctx *linux) EnvForCommit(binDir, commit string, kernelConfig []byte) (*Bis
 	return env, nil
 }
 
+// linuxCompilerVersion returns compiler version for the kernel with the given release tags,
+// nil tags mean a recent kernel.
 func linuxCompilerVersion(tags map[string]bool) string {
 	switch {
-	case tags["v4.12"]:
+	case tags == nil || tags["v4.12"]:
 		return "8.1.0"
 	case tags["v4.11"]:
 		return "7.3.0"
@@ -138,6 +120,8 @@ func linuxCompilerVersion(tags map[string]bool) string {
 	}
 }
 
+// linuxAlterConfigs disables configs that are broken for the kernel with the given release tags,
+// nil tags mean a recent kernel.
 func linuxAlterConfigs(config []byte, tags map[string]bool) []byte {
 	disable := map[string]string{
 		// 5.2 has CONFIG_SECURITY_TOMOYO_INSECURE_BUILTIN_SETTING which allows to test tomoyo better.
@@ -183,7 +167,7 @@ func linuxAlterConfigs(config []byte, tags map[string]bool) []byte {
 		"CONFIG_DEBUG_KOBJECT": "disable-always",
 	}
 	for cfg, tag := range disable {
-		if !tags[tag] {
+		if tag == "disable-always" || tags != nil && !tags[tag] {
 			config = bytes.Replace(config, []byte(cfg+"=y"), []byte("# "+cfg+" is not set"), -1)
 		}
 	}
@@ -197,7 +181,7 @@ func linuxAlterConfigs(config []byte, tags map[string]bool) []byte {
 		{"CONFIG_UNWINDER_ORC", "CONFIG_UNWINDER_FRAME_POINTER", "v5.4"},
 	}
 	for _, a := range alter {
-		if !tags[a.Tag] {
+		if tags != nil && !tags[a.Tag] {
 			config = bytes.Replace(config, []byte(a.From+"=y"), []byte("# "+a.From+" is not set"), -1)
 			config = bytes.Replace(config, []byte("# "+a.To+" is not set"), []byte(a.To+"=y"), -1)
 		}
@@ -205,8 +189,9 @@ func linuxAlterConfigs(config []byte, tags map[string]bool) []byte {
 	return config
 }
 
-func (ctx *linux) Bisect(bad, good string, trace io.Writer, pred func() (BisectResult, error)) ([]*Commit, error) {
-	commits, err := ctx.git.Bisect(bad, good, trace, pred)
+func (ctx *linux) Bisect(bad, good string, policy *BisectPolicy, trace io.Writer,
+	pred func() (BisectResult, error)) ([]*Commit, error) {
+	commits, err := ctx.git.Bisect(bad, good, policy, trace, pred)
 	if len(commits) == 1 {
 		ctx.addMaintainers(commits[0])
 	}
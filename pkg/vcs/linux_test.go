@@ -118,3 +118,15 @@ func createTestLinuxRepo(t *testing.T) string {
 
 	return baseDir
 }
+
+func TestLinuxEnvRecentKernel(t *testing.T) {
+	// Nil tags (used for non-mainline release schemes) mean a recent kernel.
+	if ver := linuxCompilerVersion(nil); ver != "8.1.0" {
+		t.Errorf("got compiler %v for a recent kernel", ver)
+	}
+	config := []byte("CONFIG_USBIP_VUDC=y\nCONFIG_UNWINDER_ORC=y\nCONFIG_KCOV=y\n")
+	want := "CONFIG_USBIP_VUDC=y\nCONFIG_UNWINDER_ORC=y\n# CONFIG_KCOV is not set\n"
+	if got := string(linuxAlterConfigs(config, nil)); got != want {
+		t.Errorf("bad config for a recent kernel:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -7,8 +7,8 @@ type netbsd struct {
 	*git
 }
 
-func newNetBSD(vm, dir string) *netbsd {
+func newNetBSD(vm, dir string, opts []RepoOpt) *netbsd {
 	return &netbsd{
-		git: newGit(dir, nil),
+		git: newGit(dir, nil, opts),
 	}
 }
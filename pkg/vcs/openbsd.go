@@ -7,8 +7,8 @@ type openbsd struct {
 	*git
 }
 
-func newOpenBSD(vm, dir string) *openbsd {
+func newOpenBSD(vm, dir string, opts []RepoOpt) *openbsd {
 	return &openbsd{
-		git: newGit(dir, nil),
+		git: newGit(dir, nil, opts),
 	}
 }
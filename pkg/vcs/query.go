@@ -0,0 +1,224 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// CommitQuerier may be optionally implemented by Repo.
+type CommitQuerier interface {
+	// QueryCommits returns info about the given commits (full or abbreviated hashes)
+	// using as few git invocations as possible. The result has the same length as hashes,
+	// commits that are not found are nil. Remote is not fetched.
+	// If some of the hashes can't be resolved (e.g. are ambiguous), the error is QueryErrors
+	// and the rest of the result is still valid.
+	QueryCommits(hashes []string) ([]*Commit, error)
+}
+
+// ErrAmbiguousCommit is reported for abbreviated hashes that match several objects.
+var ErrAmbiguousCommit = errors.New("ambiguous commit hash")
+
+// QueryErrors holds per-hash errors of QueryCommits, it has the same length as hashes.
+type QueryErrors []error
+
+func (errs QueryErrors) Error() string {
+	var first error
+	n := 0
+	for _, err := range errs {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			n++
+		}
+	}
+	switch n {
+	case 0:
+		return "(0 errors)"
+	case 1:
+		return first.Error()
+	}
+	return fmt.Sprintf("%v (and %v other errors)", first, n-1)
+}
+
+// queryCommitsBatch is the max number of hashes passed to a single git invocation.
+const queryCommitsBatch = 1000
+
+func (git *git) QueryCommits(hashes []string) ([]*Commit, error) {
+	res := make([]*Commit, len(hashes))
+	errs := make(QueryErrors, len(hashes))
+	for start := 0; start < len(hashes); start += queryCommitsBatch {
+		end := start + queryCommitsBatch
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		if err := git.queryCommits(hashes[start:end], res[start:end], errs[start:end]); err != nil {
+			return nil, err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return res, errs
+		}
+	}
+	return res, nil
+}
+
+func (git *git) queryCommits(hashes []string, res []*Commit, errs []error) error {
+	const commitSeparator = "---===syzkaller-commit-separator===---"
+	args := []string{"log", "--no-walk=unsorted", "--format=%H%n%s%n%ae%n%an%n%ad%n%P%n%b%n" + commitSeparator}
+	for _, hash := range hashes {
+		if !CheckCommitHash(hash) {
+			return fmt.Errorf("bad commit hash %q", hash)
+		}
+		args = append(args, hash)
+	}
+	output, err := git.git(args...)
+	if err != nil {
+		if !isUnknownRevision(err) {
+			return err
+		}
+		// git log fails if any of the commits does not exist or is ambiguous,
+		// fall back to querying the commits one-by-one.
+		for i, hash := range hashes {
+			com, err := git.getCommit(hash)
+			if err != nil {
+				if isAmbiguousRevision(err) {
+					errs[i] = ErrAmbiguousCommit
+					continue
+				}
+				if !isUnknownRevision(err) {
+					return err
+				}
+				continue
+			}
+			res[i] = com
+		}
+		return nil
+	}
+	commits, err := git.parseCommits(bytes.NewReader(output), commitSeparator, "", "", nil)
+	if err != nil {
+		return err
+	}
+	for i, hash := range hashes {
+		for _, com := range commits {
+			if strings.HasPrefix(com.Hash, hash) {
+				res[i] = com
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// isUnknownRevision checks if the git error is caused by a commit that does not exist in the repo.
+func isUnknownRevision(err error) bool {
+	verbose, ok := err.(*osutil.VerboseError)
+	if !ok {
+		return false
+	}
+	return bytes.Contains(verbose.Output, []byte("unknown revision")) ||
+		bytes.Contains(verbose.Output, []byte("bad object"))
+}
+
+// isAmbiguousRevision checks if the git error is caused by an abbreviated hash that matches several objects.
+func isAmbiguousRevision(err error) bool {
+	verbose, ok := err.(*osutil.VerboseError)
+	return ok && bytes.Contains(verbose.Output, []byte(" is ambiguous"))
+}
+
+type cachedCommitQuerier struct {
+	querier CommitQuerier
+	dir     string
+}
+
+// NewCachedCommitQuerier returns CommitQuerier that caches info about commits
+// queried by full hashes in dir. Commit info never changes for a given full hash,
+// so the cache never needs to be invalidated.
+func NewCachedCommitQuerier(querier CommitQuerier, dir string) (CommitQuerier, error) {
+	if err := osutil.MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	return &cachedCommitQuerier{
+		querier: querier,
+		dir:     dir,
+	}, nil
+}
+
+func (cq *cachedCommitQuerier) QueryCommits(hashes []string) ([]*Commit, error) {
+	res := make([]*Commit, len(hashes))
+	var missing []string
+	var missingIdx []int
+	for i, hash := range hashes {
+		if com := cq.load(hash); com != nil {
+			res[i] = com
+			continue
+		}
+		missing = append(missing, hash)
+		missingIdx = append(missingIdx, i)
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+	commits, err := cq.querier.QueryCommits(missing)
+	queryErrs, partial := err.(QueryErrors)
+	if err != nil && !partial {
+		return nil, err
+	}
+	var errs QueryErrors
+	for i, com := range commits {
+		res[missingIdx[i]] = com
+		if com != nil {
+			cq.store(com)
+		}
+		if partial && queryErrs[i] != nil {
+			if errs == nil {
+				errs = make(QueryErrors, len(hashes))
+			}
+			errs[missingIdx[i]] = queryErrs[i]
+		}
+	}
+	if errs != nil {
+		return res, errs
+	}
+	return res, nil
+}
+
+func (cq *cachedCommitQuerier) file(hash string) string {
+	return filepath.Join(cq.dir, hash+".json")
+}
+
+func (cq *cachedCommitQuerier) load(hash string) *Commit {
+	if len(hash) != 40 {
+		return nil
+	}
+	data, err := ioutil.ReadFile(cq.file(hash))
+	if err != nil {
+		return nil
+	}
+	com := new(Commit)
+	if err := json.Unmarshal(data, com); err != nil || com.Hash != hash {
+		os.Remove(cq.file(hash))
+		return nil
+	}
+	return com
+}
+
+func (cq *cachedCommitQuerier) store(com *Commit) {
+	data, err := json.Marshal(com)
+	if err != nil {
+		return
+	}
+	osutil.WriteFile(cq.file(com.Hash), data)
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// ReleaseScheme describes how release tags of a repo look like.
+type ReleaseScheme struct {
+	// Glob preselects candidate tags (in git tag --list syntax), e.g. "v*.*".
+	Glob string `json:"glob"`
+	// Regexp matches release tags. Its capture groups must match numbers, they define
+	// the order of releases starting from the most significant one. Unmatched optional groups are 0.
+	Regexp string `json:"regexp"`
+	// Treat release candidates ("<release>-rcN" tags) as releases too.
+	// A release candidate precedes the release, e.g. v5.6-rc1 < v5.6-rc2 < v5.6.
+	RCs bool `json:"rcs,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// ReleaseSchemeSetter may be optionally implemented by Repo.
+type ReleaseSchemeSetter interface {
+	// SetReleaseScheme sets the release tag scheme used by ReleaseTag, IsRelease
+	// and PreviousReleaseTags. Linux mainline scheme is used by default.
+	SetReleaseScheme(scheme *ReleaseScheme) error
+}
+
+// SetReleaseScheme sets the release tag scheme for the repo, if it's given.
+func SetReleaseScheme(repo Repo, scheme *ReleaseScheme) error {
+	if scheme == nil {
+		return nil
+	}
+	setter, ok := repo.(ReleaseSchemeSetter)
+	if !ok {
+		return fmt.Errorf("repo does not support release schemes")
+	}
+	return setter.SetReleaseScheme(scheme)
+}
+
+func (git *git) SetReleaseScheme(scheme *ReleaseScheme) error {
+	scheme1 := *scheme
+	if err := scheme1.compile(); err != nil {
+		return err
+	}
+	git.releases = &scheme1
+	return nil
+}
+
+var (
+	linuxReleases = &ReleaseScheme{
+		Glob: "v*.*",
+		re:   releaseTagRe,
+	}
+	rcSuffixRe = regexp.MustCompile(`-rc([0-9]+)$`)
+)
+
+func (scheme *ReleaseScheme) compile() error {
+	if scheme.Glob == "" {
+		return fmt.Errorf("empty release tag glob")
+	}
+	re, err := regexp.Compile(scheme.Regexp)
+	if err != nil {
+		return fmt.Errorf("bad release tag regexp: %v", err)
+	}
+	if re.NumSubexp() == 0 {
+		return fmt.Errorf("release tag regexp %q has no capture groups", scheme.Regexp)
+	}
+	scheme.re = re
+	return nil
+}
+
+// version returns the ordering key of the tag, or nil if the tag is not a release.
+func (scheme *ReleaseScheme) version(tag string) []uint64 {
+	rc := uint64(math.MaxUint64)
+	if scheme.RCs {
+		if match := rcSuffixRe.FindStringSubmatchIndex(tag); match != nil {
+			rc, _ = strconv.ParseUint(tag[match[2]:match[3]], 10, 64)
+			tag = tag[:match[0]]
+		}
+	}
+	match := scheme.re.FindStringSubmatch(tag)
+	if match == nil {
+		return nil
+	}
+	var res []uint64
+	nonZero := false
+	for _, group := range match[1:] {
+		var v uint64
+		if group != "" {
+			var err error
+			if v, err = strconv.ParseUint(group, 10, 64); err != nil {
+				return nil
+			}
+		}
+		nonZero = nonZero || v != 0
+		res = append(res, v)
+	}
+	if !nonZero {
+		return nil
+	}
+	return append(res, rc)
+}
+
+func (scheme *ReleaseScheme) less(a, b string) bool {
+	va, vb := scheme.version(a), scheme.version(b)
+	for i := 0; i < len(va) && i < len(vb); i++ {
+		if va[i] != vb[i] {
+			return va[i] < vb[i]
+		}
+	}
+	return len(va) < len(vb)
+}
+
+// parseTags returns release tags from git tag output sorted from the newest to the oldest.
+func (scheme *ReleaseScheme) parseTags(output []byte) []string {
+	var tags []string
+	for _, tag := range bytes.Split(output, []byte{'\n'}) {
+		if scheme.version(string(tag)) != nil {
+			tags = append(tags, string(tag))
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return scheme.less(tags[j], tags[i])
+	})
+	return tags
+}
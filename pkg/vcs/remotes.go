@@ -0,0 +1,88 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RemoteCollector may be optionally implemented by Repo.
+type RemoteCollector interface {
+	// CollectRemotes removes remotes added by CheckoutCommit that were not used for longer than maxAge,
+	// along with their refs. Returns names of the removed remotes.
+	CollectRemotes(maxAge time.Duration) ([]string, error)
+}
+
+// remoteLastUsedKey is the git config key (under remote.<name>) that holds the unix time of the last fetch.
+const remoteLastUsedKey = "syz-last-used"
+
+// hashedRemoteRe matches names of remotes added by fetchRemote (hash.String of the repo address).
+var hashedRemoteRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+func (git *git) touchRemote(name string) error {
+	_, err := git.git("config", fmt.Sprintf("remote.%v.%v", name, remoteLastUsedKey),
+		strconv.FormatInt(time.Now().Unix(), 10))
+	return err
+}
+
+func (git *git) CollectRemotes(maxAge time.Duration) ([]string, error) {
+	output, err := git.git("remote")
+	if err != nil {
+		return nil, err
+	}
+	// get-regexp exits with 1 if there are no matching keys.
+	lastUsedOutput, _ := git.git("config", "--get-regexp", `^remote\..*\.`+remoteLastUsedKey+`$`)
+	lastUsed := parseRemotesLastUsed(lastUsedOutput)
+	var removed []string
+	for _, name := range strings.Fields(string(output)) {
+		if !hashedRemoteRe.MatchString(name) {
+			continue
+		}
+		used, ok := lastUsed[name]
+		if !ok {
+			// The remote was added before usage tracking, start tracking it now.
+			if err := git.touchRemote(name); err != nil {
+				return removed, err
+			}
+			continue
+		}
+		if time.Since(used) < maxAge {
+			continue
+		}
+		// This also removes all remote-tracking refs of the remote.
+		if _, err := git.git("remote", "remove", name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, name)
+	}
+	if len(removed) != 0 {
+		// Let git decide if it's time to drop the now unreachable objects.
+		git.git("gc", "--auto", "--quiet")
+	}
+	return removed, nil
+}
+
+func parseRemotesLastUsed(output []byte) map[string]time.Time {
+	res := make(map[string]time.Time)
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(fields[0], "remote."), "."+remoteLastUsedKey)
+		unix, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[name] = time.Unix(unix, 0)
+	}
+	return res
+}
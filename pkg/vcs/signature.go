@@ -0,0 +1,98 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type SignatureStatus int
+
+const (
+	SignatureUnchecked SignatureStatus = iota // verification is not enabled (see OptVerifySignatures)
+	SignatureNone                             // neither the commit nor tags pointing to it are signed
+	SignatureGood
+	SignatureBad     // signature is invalid or made with a revoked key
+	SignatureUnknown // signature can't be checked (e.g. the public key is missing)
+)
+
+func (status SignatureStatus) String() string {
+	switch status {
+	case SignatureUnchecked:
+		return "unchecked"
+	case SignatureNone:
+		return "unsigned"
+	case SignatureGood:
+		return "good"
+	case SignatureBad:
+		return "bad"
+	case SignatureUnknown:
+		return "unknown"
+	}
+	return fmt.Sprintf("SignatureStatus(%v)", int(status))
+}
+
+// verifySignature returns signature status of the commit (any revision) and fails if the repo requires
+// signatures and neither the commit itself nor any tag pointing to it has a good signature.
+// It's called before the commit is checked out, so that unverified sources never end up in the work tree.
+// Public keys are taken from the gpg keyring (GNUPGHOME can be used to select a non-default one).
+func (git *git) verifySignature(rev string) (SignatureStatus, error) {
+	if !git.verifySignatures {
+		return SignatureUnchecked, nil
+	}
+	output, err := git.git("log", "-n", "1", "--format=%G?", rev)
+	if err != nil {
+		return SignatureUnchecked, err
+	}
+	status := gitParseSignatureStatus(output)
+	if status != SignatureGood {
+		// Releases are usually signed with tags rather than commits.
+		output, err := git.git("tag", "--points-at", rev)
+		if err != nil {
+			return SignatureUnchecked, err
+		}
+		for _, tag := range strings.Fields(string(output)) {
+			if output, err := git.git("verify-tag", "--raw", tag); err == nil && gitGoodTagSignature(output) {
+				status = SignatureGood
+				break
+			}
+		}
+	}
+	if status != SignatureGood {
+		git.discardWorkTree()
+		return status, fmt.Errorf("commit %v: signature verification failed: %v", rev, status)
+	}
+	return status, nil
+}
+
+// discardWorkTree removes all files from the work tree, so that sources of a previously checked out
+// commit are not used after a failed verification of the new one.
+func (git *git) discardWorkTree() {
+	git.git("read-tree", "--empty")
+	git.git("clean", "-fdx")
+}
+
+// gitGoodTagSignature checks gpg status output of git verify-tag --raw.
+// Same as for %G? == "G", the signature must be good and made with a valid (trusted) key.
+func gitGoodTagSignature(output []byte) bool {
+	return bytes.Contains(output, []byte("[GNUPG:] GOODSIG ")) &&
+		(bytes.Contains(output, []byte("[GNUPG:] TRUST_FULLY")) ||
+			bytes.Contains(output, []byte("[GNUPG:] TRUST_ULTIMATE")))
+}
+
+// gitParseSignatureStatus parses output of git log --format=%G?.
+func gitParseSignatureStatus(output []byte) SignatureStatus {
+	switch string(bytes.TrimSpace(output)) {
+	case "G":
+		return SignatureGood
+	case "B", "R":
+		return SignatureBad
+	case "E", "U", "X", "Y":
+		// The key validity is unknown, the signature or the key has expired, or the key is missing.
+		return SignatureUnknown
+	}
+	return SignatureNone
+}
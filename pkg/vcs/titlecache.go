@@ -0,0 +1,198 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vcs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// titleCache is persistent cache of commit searches by title (see OptTitleCache).
+// The cache file holds a separate entry per checked out repo/branch (see titleCacheKey),
+// so that switching between branches in the same repo does not invalidate the results.
+// Results of an entry are valid for its Tip commit. If the new HEAD is a descendant of Tip,
+// found commits are still reachable and missing titles need to be searched only
+// among the new commits. Otherwise (e.g. after a force push) the entry is discarded.
+type titleCache struct {
+	Branches map[string]*titleCacheEntry
+}
+
+type titleCacheEntry struct {
+	Tip     string
+	Used    time.Time
+	Found   map[string]*titleCacheCommit // canonical title -> found commit
+	Missing map[string]bool              // canonical titles that are not reachable from Tip
+}
+
+type titleCacheCommit struct {
+	Hash  string
+	Title string // title the commit was found by
+}
+
+// Entries that were not used for the longest time are evicted when the cache has more entries
+// (the jobs repo may be used to test patches on arbitrary repos/branches).
+const maxTitleCacheEntries = 100
+
+func (git *git) titleCacheFile() string {
+	return filepath.Join(git.dir, ".git", "syz-title-cache.json")
+}
+
+// titleCacheKey returns key of the cache entry for the repo/branch (empty for the local repo,
+// e.g. after CheckoutCommit).
+func titleCacheKey(repo, branch string) string {
+	if repo == "" {
+		return ""
+	}
+	return hash.String([]byte(repo + "|" + branch))
+}
+
+func (git *git) loadTitleCache() *titleCache {
+	cache := new(titleCache)
+	if data, err := ioutil.ReadFile(git.titleCacheFile()); err == nil {
+		if err := json.Unmarshal(data, cache); err != nil {
+			cache = new(titleCache)
+		}
+	}
+	if cache.Branches == nil {
+		cache.Branches = make(map[string]*titleCacheEntry)
+	}
+	return cache
+}
+
+func (git *git) saveTitleCache(cache *titleCache) {
+	for len(cache.Branches) > maxTitleCacheEntries {
+		oldest := ""
+		for key, entry := range cache.Branches {
+			if oldest == "" || entry.Used.Before(cache.Branches[oldest].Used) {
+				oldest = key
+			}
+		}
+		delete(cache.Branches, oldest)
+	}
+	if data, err := json.Marshal(cache); err == nil {
+		osutil.WriteFile(git.titleCacheFile(), data)
+	}
+}
+
+// titleCacheEntry returns the cache entry for the current checkout valid for head.
+func (git *git) titleCacheEntry(cache *titleCache, head string) *titleCacheEntry {
+	entry := cache.Branches[git.titleCacheBranch]
+	if entry != nil && entry.Tip != "" && entry.Tip != head {
+		if _, err := git.git("merge-base", "--is-ancestor", entry.Tip, head); err != nil {
+			entry = nil
+		}
+	}
+	if entry == nil {
+		entry = new(titleCacheEntry)
+		cache.Branches[git.titleCacheBranch] = entry
+	}
+	if entry.Found == nil {
+		entry.Found = make(map[string]*titleCacheCommit)
+	}
+	if entry.Missing == nil {
+		entry.Missing = make(map[string]bool)
+	}
+	return entry
+}
+
+// dropTitleCache discards the cache entry for repo/branch.
+func (git *git) dropTitleCache(repo, branch string) {
+	cache := git.loadTitleCache()
+	key := titleCacheKey(repo, branch)
+	if cache.Branches[key] == nil {
+		return
+	}
+	delete(cache.Branches, key)
+	git.saveTitleCache(cache)
+}
+
+func (git *git) cachedCommitsByTitles(titles []string) ([]*Commit, []string, error) {
+	if len(titles) == 0 {
+		return nil, nil, nil
+	}
+	output, err := git.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, nil, err
+	}
+	head := strings.TrimSpace(string(output))
+	cache := git.loadTitleCache()
+	entry := git.titleCacheEntry(cache, head)
+	var cachedHashes, cachedTitles, newTitles, staleTitles []string
+	for _, title := range titles {
+		canonical := CanonicalizeCommit(title)
+		switch {
+		case entry.Found[canonical] != nil:
+			cachedHashes = append(cachedHashes, entry.Found[canonical].Hash)
+			cachedTitles = append(cachedTitles, entry.Found[canonical].Title)
+		case entry.Missing[canonical]:
+			staleTitles = append(staleTitles, title)
+		default:
+			newTitles = append(newTitles, title)
+		}
+	}
+	var results []*Commit
+	var missing []string
+	if len(cachedHashes) != 0 {
+		commits, err := git.commitQuerier().QueryCommits(cachedHashes)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, com := range commits {
+			if com == nil {
+				// Should not happen, but don't fail if the commit was gc-ed somehow.
+				newTitles = append(newTitles, cachedTitles[i])
+				continue
+			}
+			com.Title = cachedTitles[i]
+			results = append(results, com)
+		}
+	}
+	// Titles that were missing at Tip can only be found among commits added after Tip.
+	if len(staleTitles) != 0 && entry.Tip != head {
+		commits, notFound, err := git.searchCommitsByTitles(entry.Tip+".."+head, staleTitles)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, commits...)
+		missing = append(missing, notFound...)
+	} else {
+		missing = append(missing, staleTitles...)
+	}
+	if len(newTitles) != 0 {
+		commits, notFound, err := git.searchCommitsByTitles(head, newTitles)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, commits...)
+		missing = append(missing, notFound...)
+	}
+	entry.Tip = head
+	entry.Used = time.Now()
+	for _, com := range results {
+		canonical := CanonicalizeCommit(com.Title)
+		entry.Found[canonical] = &titleCacheCommit{Hash: com.Hash, Title: com.Title}
+		delete(entry.Missing, canonical)
+	}
+	for _, title := range missing {
+		entry.Missing[CanonicalizeCommit(title)] = true
+	}
+	git.saveTitleCache(cache)
+	return results, missing, nil
+}
+
+// commitQuerier returns querier for commits found by title, info about them is cached on disk
+// as well, so that cache hits don't need to run git at all.
+func (git *git) commitQuerier() CommitQuerier {
+	cq, err := NewCachedCommitQuerier(git, filepath.Join(git.dir, ".git", "syz-commit-cache"))
+	if err != nil {
+		return git
+	}
+	return cq
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/mail"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -101,7 +102,9 @@ type Repo interface {
 	// ExtractFixTagsFromCommits extracts fixing tags for bugs from git log.
 	// Given email = "user@domain.com", it searches for tags of the form "user+tag@domain.com"
 	// and returns commits with these tags.
-	ExtractFixTagsFromCommits(baseCommit, email string) ([]*Commit, error)
+	// If dashboardURL is not empty, it also extracts tags from Closes:/Link: trailers
+	// of the form "dashboardURL/bug?extid=tag" (the scheme is not matched).
+	ExtractFixTagsFromCommits(baseCommit, email, dashboardURL string) ([]*Commit, error)
 }
 
 // Bisecter may be optionally implemented by Repo.
@@ -112,7 +115,9 @@ type Bisecter interface {
 	// Progress of the process is streamed to the provided trace.
 	// Returns the first commit on which the predicate returns BisectBad,
 	// or multiple commits if bisection is inconclusive due to BisectSkip.
-	Bisect(bad, good string, trace io.Writer, pred func() (BisectResult, error)) ([]*Commit, error)
+	// policy controls handling of skipped commits, nil means default git bisect behavior.
+	Bisect(bad, good string, policy *BisectPolicy, trace io.Writer,
+		pred func() (BisectResult, error)) ([]*Commit, error)
 
 	// PreviousReleaseTags returns list of preceding release tags that are reachable from the given commit.
 	PreviousReleaseTags(commit string) ([]string, error)
@@ -122,6 +127,26 @@ type Bisecter interface {
 	EnvForCommit(binDir, commit string, kernelConfig []byte) (*BisectEnv, error)
 }
 
+// BisectPolicy controls handling of skipped commits during bisection.
+type BisectPolicy struct {
+	// Bisection stops as inconclusive after this many skipped commits (0 means no limit).
+	// Result then contains all commits that remain to be tested.
+	MaxSkips int `json:"max_skips,omitempty"`
+	// When a commit is skipped, also skip this many of its first-parent ancestors.
+	// Build and boot breakages usually span a range of commits, so testing neighbours
+	// of a broken commit one-by-one is a waste of time.
+	SkipExpansion int `json:"skip_expansion,omitempty"`
+	// Known-broken commit windows in the "A..B" form that are skipped before bisection starts.
+	BrokenRanges []string `json:"broken_ranges,omitempty"`
+}
+
+// ReleaseTagger may be optionally implemented by Repo.
+type ReleaseTagger interface {
+	// ReleaseTag returns the latest release tag (e.g. v5.6) that the commit is based on,
+	// or an empty string if there is none (e.g. its history is not present in a shallow repo).
+	ReleaseTag(commit string) (string, error)
+}
+
 type ConfigMinimizer interface {
 	Minimize(original, baseline []byte, trace io.Writer, pred func(test []byte) (BisectResult, error)) ([]byte, error)
 }
@@ -135,6 +160,7 @@ type Commit struct {
 	Tags       []string
 	Parents    []string
 	Date       time.Time
+	Signature  SignatureStatus // filled in only by checkouts in repos with OptVerifySignatures
 }
 
 type BisectResult int
@@ -150,28 +176,43 @@ type BisectEnv struct {
 	KernelConfig []byte
 }
 
-func NewRepo(os, vm, dir string) (Repo, error) {
+type RepoOpt int
+
+const (
+	// OptShallow makes the repo fetch only recent history (see shallowDepth).
+	// Older history is fetched on demand when an operation needs it (e.g. bisection).
+	// Searches by title and for fix tags are limited to the last 90 days (see shallowSearchPeriod).
+	OptShallow RepoOpt = iota
+	// OptTitleCache makes the repo cache results of commit searches by title on disk,
+	// so that repeated GetCommitsByTitles calls search only commits added since the previous call.
+	OptTitleCache
+	// OptVerifySignatures makes Poll, CheckoutBranch and CheckoutCommit fail unless
+	// the checked out commit or a tag pointing to it has a good GPG signature.
+	OptVerifySignatures
+)
+
+func NewRepo(os, vm, dir string, opts ...RepoOpt) (Repo, error) {
 	switch os {
 	case "linux":
-		return newLinux(dir), nil
+		return newLinux(dir, opts), nil
 	case "akaros":
-		return newAkaros(vm, dir), nil
+		return newAkaros(vm, dir, opts), nil
 	case "fuchsia":
-		return newFuchsia(vm, dir), nil
+		return newFuchsia(vm, dir, opts), nil
 	case "openbsd":
-		return newOpenBSD(vm, dir), nil
+		return newOpenBSD(vm, dir, opts), nil
 	case "netbsd":
-		return newNetBSD(vm, dir), nil
+		return newNetBSD(vm, dir, opts), nil
 	case "freebsd":
-		return newFreeBSD(vm, dir), nil
+		return newFreeBSD(vm, dir, opts), nil
 	case "test":
-		return newTestos(dir), nil
+		return newTestos(dir, opts), nil
 	}
 	return nil, fmt.Errorf("vcs is unsupported for %v", os)
 }
 
 func NewSyzkallerRepo(dir string) Repo {
-	git := newGit(dir, nil)
+	git := newGit(dir, nil, nil)
 	git.sandbox = false
 	return git
 }
@@ -196,7 +237,12 @@ func Patch(dir string, patch []byte) error {
 		if _, err := cmd.CombinedOutput(); err == nil {
 			return fmt.Errorf("patch is already applied")
 		}
-		return fmt.Errorf("failed to apply patch:\n%s", output)
+		if !osutil.IsExist(filepath.Join(dir, ".git")) {
+			return fmt.Errorf("failed to apply patch:\n%s", output)
+		}
+		// Context drift is common for patches against a slightly older base,
+		// if the patch records blob hashes git can still merge it.
+		return patch3Way(dir, patch, output)
 	}
 	// Now apply for real.
 	cmd = osutil.Command("patch", "-p1", "--force", "--ignore-whitespace")
@@ -211,6 +257,57 @@ func Patch(dir string, patch []byte) error {
 	return nil
 }
 
+// PatchConflictError is returned by Patch if the patch does not apply
+// even with a 3-way merge.
+type PatchConflictError struct {
+	Files  []string // files with merge conflicts
+	Output []byte   // output of the failed plain patch application
+}
+
+func (err *PatchConflictError) Error() string {
+	return fmt.Sprintf("failed to apply patch, conflicts in %v:\n%s",
+		strings.Join(err.Files, ", "), err.Output)
+}
+
+func patch3Way(dir string, patch, patchOutput []byte) error {
+	// Same as for plain patch, do --check first: it reports conflicts without touching
+	// the working tree, so the tree is never left with conflict markers.
+	output, err := git3Way(dir, patch, "--check")
+	if err != nil {
+		return fmt.Errorf("failed to apply patch:\n%s\n3-way merge failed:\n%s", patchOutput, output)
+	}
+	if files := parsePatchConflicts(output); len(files) != 0 {
+		return &PatchConflictError{
+			Files:  files,
+			Output: patchOutput,
+		}
+	}
+	if output, err := git3Way(dir, patch); err != nil {
+		return fmt.Errorf("failed to apply patch after dry run:\n%s", output)
+	}
+	return nil
+}
+
+func git3Way(dir string, patch []byte, args ...string) ([]byte, error) {
+	cmd := osutil.Command("git", append([]string{"apply", "--3way"}, append(args, "-")...)...)
+	if err := osutil.Sandbox(cmd, true, true); err != nil {
+		return nil, err
+	}
+	cmd.Stdin = bytes.NewReader(patch)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+var patchConflictRe = regexp.MustCompile(`(?m)^Applied patch to '(.+)' with conflicts\.$`)
+
+func parsePatchConflicts(output []byte) []string {
+	var files []string
+	for _, match := range patchConflictRe.FindAllSubmatch(output, -1) {
+		files = append(files, string(match[1]))
+	}
+	return files
+}
+
 // CheckRepoAddress does a best-effort approximate check of a git repo address.
 func CheckRepoAddress(repo string) bool {
 	return gitRepoRe.MatchString(repo) || gitSSHRepoRe.MatchString(repo)
@@ -0,0 +1,84 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"bytes"
+)
+
+// Splice appends calls of p0 to p. Calls of p0 that only create a resource
+// in the same way as one of the calls already present in p (e.g. the same
+// socket(AF_INET, SOCK_STREAM, 0) call) are dropped and their uses are redirected
+// to the resource created in p. This way the appended calls operate on
+// the same objects as the original ones, which is what exposes cross-program
+// interactions. The resulting program is truncated to ncalls calls.
+// p0 is not modified.
+func (p *Prog) Splice(p0 *Prog, ncalls int) {
+	if p.Target != p0.Target {
+		panic("splicing programs for different targets")
+	}
+	setup := make(map[string]*Call)
+	for _, c := range p.Calls {
+		if key, ok := setupCallKey(p.Target, c); ok {
+			if _, ok := setup[key]; !ok {
+				setup[key] = c
+			}
+		}
+	}
+	for _, c := range p0.Clone().Calls {
+		if key, ok := setupCallKey(p.Target, c); ok && setup[key] != nil {
+			c0 := setup[key]
+			for use := range c.Ret.uses {
+				use.Res = c0.Ret
+				if c0.Ret.uses == nil {
+					c0.Ret.uses = make(map[*ResultArg]bool)
+				}
+				c0.Ret.uses[use] = true
+			}
+			c.Ret.uses = nil
+			continue
+		}
+		p.Calls = append(p.Calls, c)
+	}
+	for i := len(p.Calls) - 1; i >= ncalls; i-- {
+		p.removeCall(i)
+	}
+	p.sanitizeFix()
+	p.debugValidate()
+}
+
+// setupCallKey returns a key that identifies a resource-creating call
+// which does not depend on any other resources and creates only its return resource.
+// Two such calls with the same key create equivalent resources.
+func setupCallKey(target *Target, c *Call) (string, bool) {
+	if c.Ret == nil {
+		return "", false
+	}
+	selfContained := true
+	ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+		if a, ok := arg.(*ResultArg); ok && a != c.Ret && (a.Res != nil || len(a.uses) != 0) {
+			selfContained = false
+		}
+	})
+	if !selfContained {
+		return "", false
+	}
+	ctx := &serializer{
+		target: target,
+		buf:    new(bytes.Buffer),
+		vars:   make(map[*ResultArg]int),
+	}
+	ctx.printf("%v(", c.Meta.Name)
+	for i, a := range c.Args {
+		if IsPad(a.Type()) {
+			continue
+		}
+		if i != 0 {
+			ctx.printf(", ")
+		}
+		ctx.arg(a)
+	}
+	ctx.printf(")")
+	return ctx.buf.String(), true
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSplice(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	tests := []struct {
+		p, p0, res string
+		ncalls     int
+	}{
+		// Plain concatenation.
+		{`
+mutate0()
+`, `
+mutate1()
+`, `
+mutate0()
+mutate1()
+`, 10},
+		// Same setup call is deduplicated and its uses are redirected.
+		{`
+r0 = mutate5(&(0x7f0000000000)='./file0\x00', 0x0)
+mutate6(r0, &(0x7f0000000000)='\x00', 0x1)
+`, `
+r0 = mutate5(&(0x7f0000000000)='./file0\x00', 0x0)
+mutate0()
+mutate6(r0, &(0x7f0000000000)="01", 0x1)
+`, `
+r0 = mutate5(&(0x7f0000000000)='./file0\x00', 0x0)
+mutate6(r0, &(0x7f0000000000)='\x00', 0x1)
+mutate0()
+mutate6(r0, &(0x7f0000000000)="01", 0x1)
+`, 10},
+		// Different setup calls are preserved.
+		{`
+r0 = mutate5(&(0x7f0000000000)='./file0\x00', 0x0)
+mutate6(r0, &(0x7f0000000000)='\x00', 0x1)
+`, `
+r0 = mutate5(&(0x7f0000000000)='./file1\x00', 0x0)
+mutate6(r0, &(0x7f0000000000)="01", 0x1)
+`, `
+r0 = mutate5(&(0x7f0000000000)='./file0\x00', 0x0)
+mutate6(r0, &(0x7f0000000000)='\x00', 0x1)
+r1 = mutate5(&(0x7f0000000000)='./file1\x00', 0x0)
+mutate6(r1, &(0x7f0000000000)="01", 0x1)
+`, 10},
+		// The result is truncated to ncalls.
+		{`
+mutate0()
+mutate1()
+`, `
+mutate2()
+mutate3(&(0x7f0000000000)=[0x1], 0x1)
+`, `
+mutate0()
+mutate1()
+mutate2()
+`, 3},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			p, err := target.Deserialize([]byte(test.p), Strict)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p0, err := target.Deserialize([]byte(test.p0), Strict)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data0 := p0.Serialize()
+			p.Splice(p0, test.ncalls)
+			if got := string(p.Serialize()); got != test.res[1:] {
+				t.Fatalf("wrong result:\n%s\nwant:\n%s", got, test.res[1:])
+			}
+			if got := string(p0.Serialize()); got != string(data0) {
+				t.Fatalf("p0 was modified:\n%s\nwas:\n%s", got, data0)
+			}
+		})
+	}
+}
+
+func TestSpliceRandom(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		p0 := target.Generate(rs, 10, ct)
+		p.Splice(p0, 20)
+		if len(p.Calls) > 20 {
+			t.Fatalf("too many calls after splice: %v", len(p.Calls))
+		}
+		if _, err := target.Deserialize(p.Serialize(), NonStrict); err != nil {
+			t.Fatalf("failed to deserialize spliced program: %v\n%s", err, p.Serialize())
+		}
+	}
+}
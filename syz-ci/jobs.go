@@ -27,6 +27,7 @@ import (
 
 const (
 	commitPollPeriod = time.Hour
+	remoteGCPeriod   = 24 * time.Hour
 )
 
 type JobProcessor struct {
@@ -58,7 +59,7 @@ func newJobProcessor(cfg *Config, managers []*Manager, stop, shutdownPending cha
 func (jp *JobProcessor) loop() {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
-	var lastCommitPoll time.Time
+	var lastCommitPoll, lastRemoteGC time.Time
 loop:
 	for {
 		// Check jp.stop separately first, otherwise if stop signal arrives during a job execution,
@@ -80,6 +81,10 @@ loop:
 				jp.pollCommits()
 				lastCommitPoll = time.Now()
 			}
+			if jp.cfg.RemoteGCDays != 0 && time.Since(lastRemoteGC) > remoteGCPeriod {
+				jp.collectRemotes()
+				lastRemoteGC = time.Now()
+			}
 		case <-jp.stop:
 			break loop
 		}
@@ -98,6 +103,35 @@ func (jp *JobProcessor) pollCommits() {
 	}
 }
 
+// collectRemotes removes stale remotes that CheckoutCommit accumulates in job kernel checkouts,
+// otherwise every fetch gets slower over time.
+func (jp *JobProcessor) collectRemotes() {
+	maxAge := time.Duration(jp.cfg.RemoteGCDays) * 24 * time.Hour
+	seen := make(map[string]bool)
+	for _, mgr := range jp.managers {
+		dir := osutil.Abs(filepath.Join("jobs", mgr.managercfg.TargetOS, "kernel"))
+		if seen[dir] || !osutil.IsExist(dir) {
+			continue
+		}
+		seen[dir] = true
+		repo, err := vcs.NewRepo(mgr.managercfg.TargetOS, mgr.managercfg.Type, dir)
+		if err != nil {
+			jp.Errorf("failed to create kernel repo: %v", err)
+			continue
+		}
+		collector, ok := repo.(vcs.RemoteCollector)
+		if !ok {
+			continue
+		}
+		removed, err := collector.CollectRemotes(maxAge)
+		if err != nil {
+			jp.Errorf("failed to collect remotes in %v: %v", dir, err)
+			continue
+		}
+		log.Logf(0, "removed %v stale remotes in %v", len(removed), dir)
+	}
+}
+
 func brokenRepo(url string) bool {
 	// TODO(dvyukov): mmots contains weird squashed commits titled "linux-next" or "origin",
 	// which contain hundreds of other commits. This makes fix attribution totally broken.
@@ -119,7 +153,7 @@ func (jp *JobProcessor) pollManagerCommits(mgr *Manager) error {
 			continue
 		}
 		if resp.ReportEmail != "" {
-			commits1, err := jp.pollRepo(mgr, repo.URL, repo.Branch, resp.ReportEmail)
+			commits1, err := jp.pollRepo(mgr, repo.URL, repo.Branch, resp.ReportEmail, resp.DashboardURL)
 			if err != nil {
 				jp.Errorf("failed to poll %v %v: %v", repo.URL, repo.Branch, err)
 				continue
@@ -166,24 +200,32 @@ func (jp *JobProcessor) pollManagerCommits(mgr *Manager) error {
 	return mgr.dash.UploadCommits(results)
 }
 
-func (jp *JobProcessor) pollRepo(mgr *Manager, URL, branch, reportEmail string) ([]*vcs.Commit, error) {
+func (jp *JobProcessor) pollRepo(mgr *Manager, URL, branch, reportEmail, dashboardURL string) ([]*vcs.Commit, error) {
 	dir := osutil.Abs(filepath.Join("jobs", mgr.managercfg.TargetOS, "kernel"))
 	repo, err := vcs.NewRepo(mgr.managercfg.TargetOS, mgr.managercfg.Type, dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kernel repo: %v", err)
 	}
+	if err := vcs.SetRepoAuth(repo, mgr.mgrcfg.Repo, mgr.mgrcfg.RepoAuth); err != nil {
+		return nil, err
+	}
 	if _, err = repo.CheckoutBranch(URL, branch); err != nil {
 		return nil, fmt.Errorf("failed to checkout kernel repo %v/%v: %v", URL, branch, err)
 	}
-	return repo.ExtractFixTagsFromCommits("HEAD", reportEmail)
+	return repo.ExtractFixTagsFromCommits("HEAD", reportEmail, dashboardURL)
 }
 
 func (jp *JobProcessor) getCommitInfo(mgr *Manager, URL, branch string, commits []string) ([]*vcs.Commit, error) {
 	dir := osutil.Abs(filepath.Join("jobs", mgr.managercfg.TargetOS, "kernel"))
-	repo, err := vcs.NewRepo(mgr.managercfg.TargetOS, mgr.managercfg.Type, dir)
+	// The dashboard asks about the same fixing commit titles on every poll,
+	// the cache allows to search only the commits added since the previous poll.
+	repo, err := vcs.NewRepo(mgr.managercfg.TargetOS, mgr.managercfg.Type, dir, vcs.OptTitleCache)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create kernel repo: %v", err)
 	}
+	if err := vcs.SetRepoAuth(repo, mgr.mgrcfg.Repo, mgr.mgrcfg.RepoAuth); err != nil {
+		return nil, err
+	}
 	if _, err = repo.CheckoutBranch(URL, branch); err != nil {
 		return nil, fmt.Errorf("failed to checkout kernel repo %v/%v: %v", URL, branch, err)
 	}
@@ -414,7 +456,13 @@ func (jp *JobProcessor) bisect(job *Job, mgrcfg *mgrconfig.Config) error {
 			Config:         req.KernelConfig,
 			BaselineConfig: baseline,
 			Userspace:      mgr.mgrcfg.Userspace,
+			Auth:           mgr.mgrcfg.RepoAuth,
+			ReleaseScheme:  mgr.mgrcfg.ReleaseScheme,
+			LFS:            mgr.mgrcfg.LFS,
 		},
+		CompilerEras:      jp.cfg.BisectCompilerEras,
+		SkipPolicy:        jp.cfg.BisectSkipPolicy,
+		ParallelBisection: jp.cfg.BisectParallelism,
 		Syzkaller: bisect.SyzkallerConfig{
 			Repo:   jp.syzkallerRepo,
 			Commit: req.SyzkallerCommit,
@@ -463,6 +511,10 @@ func (jp *JobProcessor) bisect(job *Job, mgrcfg *mgrconfig.Config) error {
 				resp.Flags |= dashapi.BisectResultIgnore
 			}
 		}
+		// Attach the culprit diff so that the result can be assessed without opening the commit.
+		if err := addCulpritDiff(&resp.Commits[0], mgrcfg); err != nil {
+			log.Logf(0, "failed to get diff of %v: %v", res.Commits[0].Hash, err)
+		}
 	}
 	if res.Report != nil {
 		resp.CrashTitle = res.Report.Title
@@ -481,6 +533,19 @@ func (jp *JobProcessor) bisect(job *Job, mgrcfg *mgrconfig.Config) error {
 	return nil
 }
 
+func addCulpritDiff(com *dashapi.Commit, mgrcfg *mgrconfig.Config) error {
+	repo, err := vcs.NewRepo(mgrcfg.TargetOS, mgrcfg.Type, mgrcfg.KernelSrc)
+	if err != nil {
+		return err
+	}
+	differ, ok := repo.(vcs.CommitDiffer)
+	if !ok {
+		return nil
+	}
+	com.Diffstat, com.Patch, err = differ.CommitDiff(com.Hash, dashapi.MaxCulpritPatch)
+	return err
+}
+
 func (jp *JobProcessor) testPatch(job *Job, mgrcfg *mgrconfig.Config) error {
 	req, resp, mgr := job.req, job.resp, job.mgr
 
@@ -498,6 +563,12 @@ func (jp *JobProcessor) testPatch(job *Job, mgrcfg *mgrconfig.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to create kernel repo: %v", err)
 	}
+	if err := vcs.SetRepoAuth(repo, mgr.mgrcfg.Repo, mgr.mgrcfg.RepoAuth); err != nil {
+		return err
+	}
+	if err := vcs.SetLFS(repo, mgr.mgrcfg.LFS); err != nil {
+		return err
+	}
 	var kernelCommit *vcs.Commit
 	if vcs.CheckCommitHash(req.KernelBranch) {
 		kernelCommit, err = repo.CheckoutCommit(req.KernelRepo, req.KernelBranch)
@@ -527,7 +598,7 @@ func (jp *JobProcessor) testPatch(job *Job, mgrcfg *mgrconfig.Config) error {
 	}
 
 	log.Logf(0, "job: building kernel...")
-	kernelConfig, _, err := env.BuildKernel(mgr.mgrcfg.Compiler, mgr.mgrcfg.Userspace, mgr.mgrcfg.KernelCmdline,
+	kernelConfig, _, err := env.BuildKernel(mgr.mgrcfg.Compiler, "", mgr.mgrcfg.Userspace, mgr.mgrcfg.KernelCmdline,
 		mgr.mgrcfg.KernelSysctl, req.KernelConfig)
 	if err != nil {
 		return err
@@ -77,6 +77,8 @@ type Manager struct {
 	cmd        *ManagerCmd
 	dash       *dashapi.Dashboard
 	stop       chan struct{}
+	// Set when the tracked branch was force-pushed, the next manager start re-minimizes corpus.
+	forcePushed bool
 }
 
 func createManager(cfg *Config, mgrcfg *ManagerConfig, stop chan struct{}) (*Manager, error) {
@@ -105,10 +107,26 @@ func createManager(cfg *Config, mgrcfg *ManagerConfig, stop chan struct{}) (*Man
 		}
 	}
 	kernelDir := filepath.Join(dir, "kernel")
-	repo, err := vcs.NewRepo(mgrcfg.managercfg.TargetOS, mgrcfg.managercfg.Type, kernelDir)
+	var repoOpts []vcs.RepoOpt
+	if mgrcfg.ShallowClone {
+		repoOpts = append(repoOpts, vcs.OptShallow)
+	}
+	if mgrcfg.VerifySignatures {
+		repoOpts = append(repoOpts, vcs.OptVerifySignatures)
+	}
+	repo, err := vcs.NewRepo(mgrcfg.managercfg.TargetOS, mgrcfg.managercfg.Type, kernelDir, repoOpts...)
 	if err != nil {
 		log.Fatalf("failed to create repo for %v: %v", mgrcfg.Name, err)
 	}
+	if err := vcs.SetRepoAuth(repo, mgrcfg.Repo, mgrcfg.RepoAuth); err != nil {
+		return nil, fmt.Errorf("failed to set repo auth for %v: %v", mgrcfg.Name, err)
+	}
+	if err := vcs.SetReleaseScheme(repo, mgrcfg.ReleaseScheme); err != nil {
+		return nil, fmt.Errorf("bad release scheme for %v: %v", mgrcfg.Name, err)
+	}
+	if err := vcs.SetLFS(repo, mgrcfg.LFS); err != nil {
+		return nil, fmt.Errorf("failed to enable LFS for %v: %v", mgrcfg.Name, err)
+	}
 
 	mgr := &Manager{
 		name:       mgrcfg.managercfg.Name,
@@ -126,6 +144,13 @@ func createManager(cfg *Config, mgrcfg *ManagerConfig, stop chan struct{}) (*Man
 		dash:       dash,
 		stop:       stop,
 	}
+	if notifier, ok := repo.(vcs.ForcePushNotifier); ok {
+		// Called from Poll in the manager loop, so no synchronization is needed.
+		notifier.OnForcePush(func(ev *vcs.ForcePushEvent) {
+			log.Logf(0, "%v: %v/%v was rewritten, corpus will be re-minimized", mgr.name, ev.Repo, ev.Branch)
+			mgr.forcePushed = true
+		})
+	}
 	os.RemoveAll(mgr.currentDir)
 	return mgr, nil
 }
@@ -243,6 +268,7 @@ type BuildInfo struct {
 	KernelCommit      string // git hash of kernel checkout
 	KernelCommitTitle string
 	KernelCommitDate  time.Time
+	KernelRelease     string // latest release tag the kernel commit is based on (e.g. v5.6)
 	KernelConfigTag   string // SHA1 hash of .config contents
 }
 
@@ -270,6 +296,13 @@ func (mgr *Manager) build(kernelCommit *vcs.Commit) error {
 	tagData = append(tagData, kernelCommit.Hash...)
 	tagData = append(tagData, mgr.compilerID...)
 	tagData = append(tagData, mgr.configTag...)
+	release := ""
+	if tagger, ok := mgr.repo.(vcs.ReleaseTagger); ok {
+		var err error
+		if release, err = tagger.ReleaseTag(kernelCommit.Hash); err != nil {
+			log.Logf(0, "%v: failed to get release tag: %v", mgr.name, err)
+		}
+	}
 	info := &BuildInfo{
 		Time:              time.Now(),
 		Tag:               hash.String(tagData),
@@ -279,6 +312,7 @@ func (mgr *Manager) build(kernelCommit *vcs.Commit) error {
 		KernelCommit:      kernelCommit.Hash,
 		KernelCommitTitle: kernelCommit.Title,
 		KernelCommitDate:  kernelCommit.Date,
+		KernelRelease:     release,
 		KernelConfigTag:   mgr.configTag,
 	}
 
@@ -367,7 +401,12 @@ func (mgr *Manager) restartManager() {
 	}
 	bin := filepath.FromSlash("syzkaller/current/bin/syz-manager")
 	logFile := filepath.Join(mgr.currentDir, "manager.log")
-	mgr.cmd = NewManagerCmd(mgr.name, logFile, mgr.Errorf, bin, "-config", cfgFile)
+	args := []string{"-config", cfgFile}
+	if mgr.forcePushed {
+		args = append(args, "-reminimize_corpus")
+		mgr.forcePushed = false
+	}
+	mgr.cmd = NewManagerCmd(mgr.name, logFile, mgr.Errorf, bin, args...)
 }
 
 func (mgr *Manager) testImage(imageDir string, info *BuildInfo) error {
@@ -554,6 +593,7 @@ func (mgr *Manager) createDashboardBuild(info *BuildInfo, imageDir, typ string)
 		KernelCommit:        info.KernelCommit,
 		KernelCommitTitle:   info.KernelCommitTitle,
 		KernelCommitDate:    info.KernelCommitDate,
+		KernelRelease:       info.KernelRelease,
 		KernelConfig:        kernelConfig,
 	}
 	return build, nil
@@ -586,7 +626,7 @@ func (mgr *Manager) pollCommits(buildCommit string) ([]string, []dashapi.Commit,
 	var fixCommits []dashapi.Commit
 	if resp.ReportEmail != "" {
 		if !brokenRepo(mgr.mgrcfg.Repo) {
-			commits, err := mgr.repo.ExtractFixTagsFromCommits(buildCommit, resp.ReportEmail)
+			commits, err := mgr.repo.ExtractFixTagsFromCommits(buildCommit, resp.ReportEmail, resp.DashboardURL)
 			if err != nil {
 				return nil, nil, err
 			}
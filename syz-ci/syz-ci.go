@@ -63,10 +63,12 @@ import (
 	"regexp"
 	"sync"
 
+	"github.com/google/syzkaller/pkg/bisect"
 	"github.com/google/syzkaller/pkg/config"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/vcs"
 )
 
 var (
@@ -94,6 +96,18 @@ type Config struct {
 	CoverUploadPath string           `json:"cover_upload_path"`
 	BisectBinDir    string           `json:"bisect_bin_dir"`
 	Managers        []*ManagerConfig `json:"managers"`
+	// Toolchains to use for bisection of kernel commits in specific date ranges (optional),
+	// override the default compiler selection based on BisectBinDir.
+	BisectCompilerEras []bisect.CompilerEra `json:"bisect_compiler_eras"`
+	// Handling of commits that fail to build or boot during bisection (optional).
+	BisectSkipPolicy *vcs.BisectPolicy `json:"bisect_skip_policy"`
+	// Number of commits tested concurrently during bisection (optional).
+	// Each concurrent test uses a separate kernel checkout and VM pool.
+	// Parallel bisection does not support bisect_skip_policy (broken ranges and skip limits),
+	// so the two params can't be used together.
+	BisectParallelism int `json:"bisect_parallelism"`
+	// Remove remotes of job kernel checkouts that were not fetched for this many days (optional).
+	RemoteGCDays int `json:"remote_gc_days"`
 }
 
 type ManagerConfig struct {
@@ -113,8 +127,22 @@ type ManagerConfig struct {
 	// File with kernel cmdline values (optional).
 	KernelCmdline string `json:"kernel_cmdline"`
 	// File with sysctl values (e.g. output of sysctl -a, optional).
-	KernelSysctl string      `json:"kernel_sysctl"`
+	KernelSysctl string `json:"kernel_sysctl"`
+	// Fetch only recent kernel history, older history is fetched on demand (e.g. for bisection).
+	// Saves lots of disk space per manager. Fix tags are searched only in the last 90 days
+	// of history instead of a year, bisection still fetches the full history.
+	ShallowClone bool        `json:"shallow_clone"`
 	Jobs         ManagerJobs `json:"jobs"`
+	// Credentials for the private kernel repo (optional). They are used only for the repo address,
+	// never for other repos (e.g. ones provided in patch testing requests).
+	RepoAuth *vcs.RepoAuth `json:"repo_auth"`
+	// Refuse to build kernel commits that don't have a good GPG signature (on the commit or a tag).
+	// Public keys are taken from the gpg keyring in GNUPGHOME, it must be readable by the sandbox user.
+	VerifySignatures bool `json:"verify_signatures"`
+	// Format of release tags for non-mainline trees (optional, Linux "vX.Y[.Z]" tags by default).
+	ReleaseScheme *vcs.ReleaseScheme `json:"release_scheme"`
+	// Git LFS support for kernel trees that store large files in LFS (optional).
+	LFS *vcs.LFSConfig `json:"lfs"`
 
 	ManagerConfig json.RawMessage `json:"manager_config"`
 	managercfg    *mgrconfig.Config
@@ -248,6 +276,9 @@ func loadConfig(filename string) (*Config, error) {
 	if cfg.HTTP == "" {
 		return nil, fmt.Errorf("param 'http' is empty")
 	}
+	if cfg.BisectParallelism > 1 && cfg.BisectSkipPolicy != nil {
+		return nil, fmt.Errorf("params 'bisect_parallelism' and 'bisect_skip_policy' can't be used together")
+	}
 	// Manager name must not contain dots because it is used as GCE image name prefix.
 	managerNameRe := regexp.MustCompile("^[a-zA-Z0-9-_]{4,64}$")
 	var managers []*ManagerConfig
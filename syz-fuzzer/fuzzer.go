@@ -17,6 +17,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/google/syzkaller/pkg/cover"
 	"github.com/google/syzkaller/pkg/csource"
 	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/pkg/host"
@@ -33,6 +34,7 @@ import (
 type Fuzzer struct {
 	name              string
 	outputType        OutputType
+	schedule          Schedule
 	config            *ipc.Config
 	execOpts          *ipc.ExecOpts
 	procs             []*Proc
@@ -40,6 +42,7 @@ type Fuzzer struct {
 	workQueue         *WorkQueue
 	needPoll          chan struct{}
 	choiceTable       *prog.ChoiceTable
+	callsTuner        *callsTuner
 	stats             [StatCount]uint64
 	manager           *rpctype.RPCClient
 	target            *prog.Target
@@ -47,18 +50,36 @@ type Fuzzer struct {
 
 	faultInjectionEnabled    bool
 	comparisonTracingEnabled bool
+	// Comparison tracing may be reported as supported, but not produce any data
+	// (e.g. KCOV comparisons are broken for the arch). We count hint seed executions
+	// and disable hints if none of the first hintsProbeSeeds ones returns comparisons.
+	hintSeeds     uint32
+	hintSeedComps uint32
+	hintsDisabled uint32
 
 	corpusMu     sync.RWMutex
 	corpus       []*prog.Prog
 	corpusHashes map[hash.Sig]struct{}
 	corpusPrios  []int64
 	sumPrios     int64
+	corpusStats  []*corpusItemStats
+	corpusEnergy []int64
+	sumEnergy    int64
 
 	signalMu     sync.RWMutex
 	corpusSignal signal.Signal // signal of inputs in corpus
 	maxSignal    signal.Signal // max signal ever observed including flakes
 	newSignal    signal.Signal // diff of maxSignal since last sync with master
 
+	triageMu    sync.Mutex
+	triageStats map[string]rpctype.CallTriageStats // since last sync with master
+
+	// Coverage in kernel paths excluded by the manager (exclude_paths),
+	// inputs with most of the coverage there are chosen for mutation less often.
+	excludeMu      sync.RWMutex
+	excludedCover  map[uint32]bool
+	excludedInputs uint64 // since last sync with master
+
 	logMu sync.Mutex
 }
 
@@ -66,6 +87,7 @@ type FuzzerSnapshot struct {
 	corpus      []*prog.Prog
 	corpusPrios []int64
 	sumPrios    int64
+	corpusStats []*corpusItemStats
 }
 
 type Stat int
@@ -79,6 +101,7 @@ const (
 	StatSmash
 	StatHint
 	StatSeed
+	StatSplice
 	StatCount
 )
 
@@ -91,6 +114,7 @@ var statNames = [StatCount]string{
 	StatSmash:     "exec smash",
 	StatHint:      "exec hints",
 	StatSeed:      "exec seeds",
+	StatSplice:    "exec splice",
 }
 
 type OutputType int
@@ -222,6 +246,7 @@ func main() {
 		}
 	}
 	log.Logf(0, "syscalls: %v", len(r.CheckResult.EnabledCalls[sandbox]))
+	log.Logf(0, "corpus schedule: %v", r.Schedule)
 	for _, feat := range r.CheckResult.Features.Supported() {
 		log.Logf(0, "%v: %v", feat.Name, feat.Reason)
 	}
@@ -237,6 +262,8 @@ func main() {
 	fuzzer := &Fuzzer{
 		name:                     *flagName,
 		outputType:               outputType,
+		schedule:                 parseSchedule(r.Schedule),
+		callsTuner:               newCallsTuner(r.MinCalls, r.MaxCalls),
 		config:                   config,
 		execOpts:                 execOpts,
 		workQueue:                newWorkQueue(*flagProcs, needPoll),
@@ -320,6 +347,7 @@ func (fuzzer *Fuzzer) filterDataRaceFrames(frames []string) {
 
 func (fuzzer *Fuzzer) pollLoop() {
 	var execTotal uint64
+	hintsDisabledReported := false
 	var lastPoll time.Time
 	var lastPrint time.Time
 	ticker := time.NewTicker(3 * time.Second).C
@@ -350,9 +378,23 @@ func (fuzzer *Fuzzer) pollLoop() {
 				stats[statNames[stat]] = v
 				execTotal += v
 			}
+			if v := atomic.SwapUint64(&fuzzer.excludedInputs, 0); v != 0 {
+				stats["excluded inputs"] = v
+			}
+			if !hintsDisabledReported && atomic.LoadUint32(&fuzzer.hintsDisabled) != 0 {
+				stats["hints disabled"] = 1
+				hintsDisabledReported = true
+			}
 			if !fuzzer.poll(needCandidates, stats) {
 				lastPoll = time.Now()
 			}
+			if fuzzer.schedule == ScheduleEnergy {
+				fuzzer.updateEnergy()
+			}
+			if fuzzer.callsTuner != nil {
+				log.Logf(1, "program length rates:%v", fuzzer.callsTuner)
+				fuzzer.callsTuner.decay()
+			}
 		}
 	}
 }
@@ -363,6 +405,7 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 		NeedCandidates: needCandidates,
 		MaxSignal:      fuzzer.grabNewSignal().Serialize(),
 		Stats:          stats,
+		TriageStats:    fuzzer.grabTriageStats(),
 	}
 	r := &rpctype.PollRes{}
 	if err := fuzzer.manager.Call("Manager.Poll", a, r); err != nil {
@@ -372,6 +415,9 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 	log.Logf(1, "poll: candidates=%v inputs=%v signal=%v",
 		len(r.Candidates), len(r.NewInputs), maxSignal.Len())
 	fuzzer.addMaxSignal(maxSignal)
+	if r.UpdateExcludedCover {
+		fuzzer.setExcludedCover(r.ExcludedCover)
+	}
 	for _, inp := range r.NewInputs {
 		fuzzer.addInputFromAnotherFuzzer(inp)
 	}
@@ -384,6 +430,36 @@ func (fuzzer *Fuzzer) poll(needCandidates bool, stats map[string]uint64) bool {
 	return len(r.NewInputs) != 0 || len(r.Candidates) != 0 || maxSignal.Len() != 0
 }
 
+func (fuzzer *Fuzzer) setExcludedCover(cov []uint32) {
+	var excluded map[uint32]bool
+	if len(cov) != 0 {
+		excluded = make(map[uint32]bool, len(cov))
+		for _, pc := range cov {
+			excluded[pc] = true
+		}
+	}
+	log.Logf(0, "excluded coverage: %v PCs", len(excluded))
+	fuzzer.excludeMu.Lock()
+	fuzzer.excludedCover = excluded
+	fuzzer.excludeMu.Unlock()
+}
+
+// excludedInput returns true if most of the input coverage is in the excluded paths.
+func (fuzzer *Fuzzer) excludedInput(cov cover.Cover) bool {
+	fuzzer.excludeMu.RLock()
+	defer fuzzer.excludeMu.RUnlock()
+	if len(fuzzer.excludedCover) == 0 || len(cov) == 0 {
+		return false
+	}
+	excluded := 0
+	for pc := range cov {
+		if fuzzer.excludedCover[pc] {
+			excluded++
+		}
+	}
+	return excluded*2 > len(cov)
+}
+
 func (fuzzer *Fuzzer) sendInputToManager(inp rpctype.RPCInput) {
 	a := &rpctype.NewInputArgs{
 		Name:     fuzzer.name,
@@ -401,7 +477,9 @@ func (fuzzer *Fuzzer) addInputFromAnotherFuzzer(inp rpctype.RPCInput) {
 	}
 	sig := hash.Hash(inp.Prog)
 	sign := inp.Signal.Deserialize()
-	fuzzer.addInputToCorpus(p, sign, sig)
+	var cov cover.Cover
+	cov.Merge(inp.Cover)
+	fuzzer.addInputToCorpus(p, sign, sig, fuzzer.excludedInput(cov))
 }
 
 func (fuzzer *Fuzzer) addCandidateInput(candidate rpctype.RPCCandidate) {
@@ -434,24 +512,39 @@ func (fuzzer *Fuzzer) deserializeInput(inp []byte) *prog.Prog {
 }
 
 func (fuzzer *FuzzerSnapshot) chooseProgram(r *rand.Rand) *prog.Prog {
+	return fuzzer.corpus[fuzzer.chooseProgramIndex(r)]
+}
+
+func (fuzzer *FuzzerSnapshot) chooseProgramIndex(r *rand.Rand) int {
 	randVal := r.Int63n(fuzzer.sumPrios + 1)
-	idx := sort.Search(len(fuzzer.corpusPrios), func(i int) bool {
+	return sort.Search(len(fuzzer.corpusPrios), func(i int) bool {
 		return fuzzer.corpusPrios[i] >= randVal
 	})
-	return fuzzer.corpus[idx]
 }
 
-func (fuzzer *Fuzzer) addInputToCorpus(p *prog.Prog, sign signal.Signal, sig hash.Sig) {
+// excludedPrioDivisor is how many times less often inputs in excluded paths are chosen for mutation.
+// Such inputs are still kept in the corpus, they may be the only ones that reach some coverage
+// outside of the excluded paths, or the paths may be re-enabled later.
+const excludedPrioDivisor = 10
+
+func (fuzzer *Fuzzer) addInputToCorpus(p *prog.Prog, sign signal.Signal, sig hash.Sig, excluded bool) {
 	fuzzer.corpusMu.Lock()
 	if _, ok := fuzzer.corpusHashes[sig]; !ok {
 		fuzzer.corpus = append(fuzzer.corpus, p)
 		fuzzer.corpusHashes[sig] = struct{}{}
 		prio := int64(len(sign))
-		if sign.Empty() {
+		if excluded {
+			prio /= excludedPrioDivisor
+		}
+		if prio == 0 {
 			prio = 1
 		}
 		fuzzer.sumPrios += prio
 		fuzzer.corpusPrios = append(fuzzer.corpusPrios, fuzzer.sumPrios)
+		st := &corpusItemStats{prio: prio}
+		fuzzer.corpusStats = append(fuzzer.corpusStats, st)
+		fuzzer.sumEnergy += st.energy(0)
+		fuzzer.corpusEnergy = append(fuzzer.corpusEnergy, fuzzer.sumEnergy)
 	}
 	fuzzer.corpusMu.Unlock()
 
@@ -466,7 +559,10 @@ func (fuzzer *Fuzzer) addInputToCorpus(p *prog.Prog, sign signal.Signal, sig has
 func (fuzzer *Fuzzer) snapshot() FuzzerSnapshot {
 	fuzzer.corpusMu.RLock()
 	defer fuzzer.corpusMu.RUnlock()
-	return FuzzerSnapshot{fuzzer.corpus, fuzzer.corpusPrios, fuzzer.sumPrios}
+	if fuzzer.schedule == ScheduleEnergy {
+		return FuzzerSnapshot{fuzzer.corpus, fuzzer.corpusEnergy, fuzzer.sumEnergy, fuzzer.corpusStats}
+	}
+	return FuzzerSnapshot{fuzzer.corpus, fuzzer.corpusPrios, fuzzer.sumPrios, fuzzer.corpusStats}
 }
 
 func (fuzzer *Fuzzer) addMaxSignal(sign signal.Signal) {
@@ -489,6 +585,25 @@ func (fuzzer *Fuzzer) grabNewSignal() signal.Signal {
 	return sign
 }
 
+func (fuzzer *Fuzzer) addTriageStats(call string, stats rpctype.CallTriageStats) {
+	fuzzer.triageMu.Lock()
+	defer fuzzer.triageMu.Unlock()
+	if fuzzer.triageStats == nil {
+		fuzzer.triageStats = make(map[string]rpctype.CallTriageStats)
+	}
+	v := fuzzer.triageStats[call]
+	v.Merge(stats)
+	fuzzer.triageStats[call] = v
+}
+
+func (fuzzer *Fuzzer) grabTriageStats() map[string]rpctype.CallTriageStats {
+	fuzzer.triageMu.Lock()
+	defer fuzzer.triageMu.Unlock()
+	stats := fuzzer.triageStats
+	fuzzer.triageStats = nil
+	return stats
+}
+
 func (fuzzer *Fuzzer) corpusSignalDiff(sign signal.Signal) signal.Signal {
 	fuzzer.signalMu.RLock()
 	defer fuzzer.signalMu.RUnlock()
@@ -6,9 +6,14 @@ package main
 import (
 	"math"
 	"math/rand"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/google/syzkaller/pkg/cover"
 	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/ipc"
+	"github.com/google/syzkaller/pkg/rpctype"
 	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
 )
@@ -38,7 +43,7 @@ func TestChooseProgram(t *testing.T) {
 			sizeSig = 0
 		}
 		inp := generateInput(target, rs, 10, sizeSig)
-		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig)
+		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, false)
 		priorities[inp.p] = int64(len(inp.sign))
 	}
 	snapshot := fuzzer.snapshot()
@@ -55,6 +60,79 @@ func TestChooseProgram(t *testing.T) {
 	}
 }
 
+func TestUpdateEnergy(t *testing.T) {
+	target := getTarget(t, "test", "64")
+	rs := rand.NewSource(0)
+	fuzzer := &Fuzzer{
+		schedule:     ScheduleEnergy,
+		corpusHashes: make(map[hash.Sig]struct{}),
+	}
+	for i := 0; i < 4; i++ {
+		inp := generateInput(target, rs, 10, 10)
+		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, false)
+	}
+	stats := fuzzer.corpusStats
+	// Program 0 is fuzzed a lot, program 1 is slow, program 2 is productive,
+	// program 3 is fuzzed as much as 1 and 2 and serves as the baseline.
+	for i := 0; i < 100; i++ {
+		stats[0].executed(time.Millisecond, 0)
+	}
+	for i := 0; i < 10; i++ {
+		stats[1].executed(10*time.Millisecond, 0)
+		stats[2].executed(time.Millisecond, 1)
+		stats[3].executed(time.Millisecond, 0)
+	}
+	fuzzer.updateEnergy()
+	energy := make([]int64, len(fuzzer.corpusEnergy))
+	prev := int64(0)
+	for i, sum := range fuzzer.corpusEnergy {
+		energy[i] = sum - prev
+		prev = sum
+	}
+	if energy[0] >= energy[3] {
+		t.Errorf("frequently fuzzed program has energy %v, baseline %v", energy[0], energy[3])
+	}
+	if energy[1] >= energy[3] {
+		t.Errorf("slow program has energy %v, baseline %v", energy[1], energy[3])
+	}
+	if energy[2] <= energy[3] {
+		t.Errorf("productive program has energy %v, baseline %v", energy[2], energy[3])
+	}
+	if snapshot := fuzzer.snapshot(); snapshot.sumPrios != fuzzer.sumEnergy {
+		t.Errorf("snapshot does not use energy: %v vs %v", snapshot.sumPrios, fuzzer.sumEnergy)
+	}
+}
+
+func TestCallsTuner(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	var nilTuner *callsTuner
+	if got := nilTuner.choose(r); got != prog.RecommendedCalls {
+		t.Fatalf("nil tuner chose %v calls, want %v", got, prog.RecommendedCalls)
+	}
+	tuner := newCallsTuner(5, 40)
+	for i := 0; i < 1000; i++ {
+		ncalls := tuner.choose(r)
+		if ncalls < 5 || ncalls > 40 {
+			t.Fatalf("chose %v calls, want [5, 40]", ncalls)
+		}
+		// Programs with 10-14 calls are the most productive ones.
+		newSignal := 0
+		if ncalls >= 10 && ncalls <= 14 {
+			newSignal = 10
+		}
+		tuner.record(ncalls, time.Second, newSignal)
+	}
+	hits := 0
+	for i := 0; i < 1000; i++ {
+		if ncalls := tuner.choose(r); ncalls >= 10 && ncalls <= 14 {
+			hits++
+		}
+	}
+	if hits < 800 {
+		t.Fatalf("chose the most productive length only %v times out of 1000", hits)
+	}
+}
+
 func TestAddInputConcurrency(t *testing.T) {
 	target := getTarget(t, "test", "64")
 	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{})}
@@ -70,7 +148,7 @@ func TestAddInputConcurrency(t *testing.T) {
 			r := rand.New(rs)
 			for it := 0; it < iters; it++ {
 				inp := generateInput(target, rs, 10, it)
-				fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig)
+				fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, false)
 				snapshot := fuzzer.snapshot()
 				snapshot.chooseProgram(r).Clone()
 			}
@@ -97,3 +175,89 @@ func getTarget(t *testing.T, os, arch string) *prog.Target {
 	}
 	return target
 }
+
+func TestTriageStats(t *testing.T) {
+	fuzzer := &Fuzzer{}
+	if stats := fuzzer.grabTriageStats(); stats != nil {
+		t.Fatalf("got stats on empty fuzzer: %+v", stats)
+	}
+	fuzzer.addTriageStats("foo", rpctype.CallTriageStats{Flaky: 1})
+	fuzzer.addTriageStats("foo", rpctype.CallTriageStats{NewSignal: 1, Minimized: 1, RemovedCalls: 3})
+	fuzzer.addTriageStats("bar", rpctype.CallTriageStats{NewSignal: 1})
+	fuzzer.addTriageStats("bar", rpctype.CallTriageStats{NotExecuted: 1})
+	want := map[string]rpctype.CallTriageStats{
+		"foo": {NewSignal: 1, Flaky: 1, Minimized: 1, RemovedCalls: 3},
+		"bar": {NewSignal: 1, NotExecuted: 1},
+	}
+	if stats := fuzzer.grabTriageStats(); !reflect.DeepEqual(stats, want) {
+		t.Fatalf("got stats %+v, want %+v", stats, want)
+	}
+	if stats := fuzzer.grabTriageStats(); stats != nil {
+		t.Fatalf("stats are not reset after grab: %+v", stats)
+	}
+}
+
+func TestCheckHintSeed(t *testing.T) {
+	empty := &ipc.ProgInfo{Calls: make([]ipc.CallInfo, 2)}
+	withComps := &ipc.ProgInfo{Calls: make([]ipc.CallInfo, 2)}
+	withComps.Calls[1].Comps = make(prog.CompMap)
+	withComps.Calls[1].Comps.AddComp(1, 2)
+
+	fuzzer := &Fuzzer{}
+	for i := 0; i < hintsProbeSeeds-1; i++ {
+		fuzzer.checkHintSeed(empty)
+	}
+	fuzzer.checkHintSeed(withComps)
+	if fuzzer.hintsDisabled != 0 {
+		t.Fatalf("hints are disabled with comparisons present")
+	}
+
+	fuzzer = &Fuzzer{}
+	for i := 0; i < hintsProbeSeeds; i++ {
+		if fuzzer.hintsDisabled != 0 {
+			t.Fatalf("hints are disabled after %v seeds", i)
+		}
+		fuzzer.checkHintSeed(empty)
+	}
+	if fuzzer.hintsDisabled == 0 {
+		t.Fatalf("hints are not disabled without comparisons")
+	}
+}
+
+func TestExcludedInput(t *testing.T) {
+	fuzzer := &Fuzzer{}
+	var cov cover.Cover
+	cov.Merge([]uint32{1, 2, 3, 4})
+	if fuzzer.excludedInput(cov) {
+		t.Fatalf("input excluded without excluded coverage")
+	}
+	fuzzer.setExcludedCover([]uint32{1, 2, 10})
+	if fuzzer.excludedInput(cov) {
+		t.Fatalf("input with half of coverage excluded is excluded")
+	}
+	cov.Merge([]uint32{10})
+	if !fuzzer.excludedInput(cov) {
+		t.Fatalf("input with most of coverage excluded is not excluded")
+	}
+	fuzzer.setExcludedCover(nil)
+	if fuzzer.excludedInput(cov) {
+		t.Fatalf("input excluded after excluded coverage reset")
+	}
+}
+
+func TestExcludedInputPrio(t *testing.T) {
+	target := getTarget(t, "test", "64")
+	rs := rand.NewSource(0)
+	fuzzer := &Fuzzer{corpusHashes: make(map[hash.Sig]struct{})}
+	for _, excluded := range []bool{false, true} {
+		inp := generateInput(target, rs, 10, 100)
+		fuzzer.addInputToCorpus(inp.p, inp.sign, inp.sig, excluded)
+	}
+	if len(fuzzer.corpus) != 2 {
+		t.Fatalf("input in excluded paths is not added to the corpus")
+	}
+	prio, excludedPrio := fuzzer.corpusPrios[0], fuzzer.corpusPrios[1]-fuzzer.corpusPrios[0]
+	if prio != 100 || excludedPrio != 100/excludedPrioDivisor {
+		t.Fatalf("got priorities %v/%v, want %v/%v", prio, excludedPrio, 100, 100/excludedPrioDivisor)
+	}
+}
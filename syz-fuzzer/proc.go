@@ -66,6 +66,10 @@ func (proc *Proc) loop() {
 		// because fallback signal is weak.
 		generatePeriod = 2
 	}
+	// Splicing finds interactions between corpus programs that mutation rarely
+	// produces, but most spliced programs are not interesting, so we do it
+	// only for a fraction of fuzzing iterations.
+	const splicePeriod = 10
 	for i := 0; ; i++ {
 		item := proc.fuzzer.workQueue.dequeue()
 		if item != nil {
@@ -84,21 +88,53 @@ func (proc *Proc) loop() {
 
 		ct := proc.fuzzer.choiceTable
 		fuzzerSnapshot := proc.fuzzer.snapshot()
+		ncalls := proc.fuzzer.callsTuner.choose(proc.rnd)
 		if len(fuzzerSnapshot.corpus) == 0 || i%generatePeriod == 0 {
 			// Generate a new prog.
-			p := proc.fuzzer.target.Generate(proc.rnd, prog.RecommendedCalls, ct)
+			p := proc.fuzzer.target.Generate(proc.rnd, ncalls, ct)
 			log.Logf(1, "#%v: generated", proc.pid)
-			proc.execute(proc.execOpts, p, ProgNormal, StatGenerate)
+			start := time.Now()
+			_, newSignal := proc.executeNewSignal(proc.execOpts, p, ProgNormal, StatGenerate)
+			proc.fuzzer.callsTuner.record(len(p.Calls), time.Since(start), newSignal)
+		} else if len(fuzzerSnapshot.corpus) > 1 && proc.rnd.Intn(splicePeriod) == 0 {
+			proc.spliceInput(&fuzzerSnapshot)
 		} else {
 			// Mutate an existing prog.
-			p := fuzzerSnapshot.chooseProgram(proc.rnd).Clone()
-			p.Mutate(proc.rnd, prog.RecommendedCalls, ct, fuzzerSnapshot.corpus)
+			idx := fuzzerSnapshot.chooseProgramIndex(proc.rnd)
+			p := fuzzerSnapshot.corpus[idx].Clone()
+			p.Mutate(proc.rnd, ncalls, ct, fuzzerSnapshot.corpus)
 			log.Logf(1, "#%v: mutated", proc.pid)
-			proc.execute(proc.execOpts, p, ProgNormal, StatFuzz)
+			start := time.Now()
+			_, newSignal := proc.executeNewSignal(proc.execOpts, p, ProgNormal, StatFuzz)
+			elapsed := time.Since(start)
+			fuzzerSnapshot.corpusStats[idx].executed(elapsed, newSignal)
+			proc.fuzzer.callsTuner.record(len(p.Calls), elapsed, newSignal)
 		}
 	}
 }
 
+// spliceInput appends a random corpus program to another random corpus program
+// and executes the result. Setup calls that create the same resources are deduplicated,
+// so the appended calls operate on objects created by the first program.
+func (proc *Proc) spliceInput(fuzzerSnapshot *FuzzerSnapshot) {
+	idx := fuzzerSnapshot.chooseProgramIndex(proc.rnd)
+	idx0 := fuzzerSnapshot.chooseProgramIndex(proc.rnd)
+	if idx == idx0 {
+		return
+	}
+	p := fuzzerSnapshot.corpus[idx].Clone()
+	p0 := fuzzerSnapshot.corpus[idx0]
+	ncalls := len(p.Calls) + len(p0.Calls)
+	if ncalls > prog.MaxCalls {
+		ncalls = prog.MaxCalls
+	}
+	p.Splice(p0, ncalls)
+	log.Logf(1, "#%v: spliced", proc.pid)
+	start := time.Now()
+	_, newSignal := proc.executeNewSignal(proc.execOpts, p, ProgNormal, StatSplice)
+	fuzzerSnapshot.corpusStats[idx].executed(time.Since(start), newSignal)
+}
+
 func (proc *Proc) triageInput(item *WorkTriage) {
 	log.Logf(1, "#%v: triaging type=%x", proc.pid, item.flags)
 
@@ -128,7 +164,9 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 			// The call was not executed or failed.
 			notexecuted++
 			if notexecuted > signalRuns/2+1 {
-				return // if happens too often, give up
+				// If happens too often, give up.
+				proc.fuzzer.addTriageStats(callName, rpctype.CallTriageStats{NotExecuted: 1})
+				return
 			}
 			continue
 		}
@@ -137,11 +175,19 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 		// Without !minimized check manager starts losing some considerable amount
 		// of coverage after each restart. Mechanics of this are not completely clear.
 		if newSignal.Empty() && item.flags&ProgMinimized == 0 {
+			proc.fuzzer.addTriageStats(callName, rpctype.CallTriageStats{Flaky: 1})
 			return
 		}
 		inputCover.Merge(thisCover)
 	}
+	excluded := proc.fuzzer.excludedInput(inputCover)
+	if excluded {
+		log.Logf(2, "input in excluded paths: %v", callName)
+		atomic.AddUint64(&proc.fuzzer.excludedInputs, 1)
+	}
+	triageStats := rpctype.CallTriageStats{NewSignal: 1}
 	if item.flags&ProgMinimized == 0 {
+		ncalls := len(item.p.Calls)
 		item.p, item.call = prog.Minimize(item.p, item.call, false,
 			func(p1 *prog.Prog, call1 int) bool {
 				for i := 0; i < minimizeAttempts; i++ {
@@ -157,7 +203,12 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 				}
 				return false
 			})
+		if removed := ncalls - len(item.p.Calls); removed > 0 {
+			triageStats.Minimized = 1
+			triageStats.RemovedCalls = uint64(removed)
+		}
 	}
+	proc.fuzzer.addTriageStats(callName, triageStats)
 
 	data := item.p.Serialize()
 	sig := hash.Hash(data)
@@ -170,7 +221,7 @@ func (proc *Proc) triageInput(item *WorkTriage) {
 		Cover:  inputCover.Serialize(),
 	})
 
-	proc.fuzzer.addInputToCorpus(item.p, inputSignal, sig)
+	proc.fuzzer.addInputToCorpus(item.p, inputSignal, sig, excluded)
 
 	if item.flags&ProgSmashed == 0 {
 		proc.fuzzer.workQueue.enqueue(&WorkSmash{item.p, item.call})
@@ -204,13 +255,14 @@ func (proc *Proc) smashInput(item *WorkSmash) {
 	if proc.fuzzer.faultInjectionEnabled && item.call != -1 {
 		proc.failCall(item.p, item.call)
 	}
-	if proc.fuzzer.comparisonTracingEnabled && item.call != -1 {
+	if proc.fuzzer.comparisonTracingEnabled && item.call != -1 &&
+		atomic.LoadUint32(&proc.fuzzer.hintsDisabled) == 0 {
 		proc.executeHintSeed(item.p, item.call)
 	}
 	fuzzerSnapshot := proc.fuzzer.snapshot()
 	for i := 0; i < 100; i++ {
 		p := item.p.Clone()
-		p.Mutate(proc.rnd, prog.RecommendedCalls, proc.fuzzer.choiceTable, fuzzerSnapshot.corpus)
+		p.Mutate(proc.rnd, proc.fuzzer.callsTuner.choose(proc.rnd), proc.fuzzer.choiceTable, fuzzerSnapshot.corpus)
 		log.Logf(1, "#%v: smash mutated", proc.pid)
 		proc.execute(proc.execOpts, p, ProgNormal, StatSmash)
 	}
@@ -237,6 +289,7 @@ func (proc *Proc) executeHintSeed(p *prog.Prog, call int) {
 	if info == nil {
 		return
 	}
+	proc.fuzzer.checkHintSeed(info)
 
 	// Then mutate the initial program for every match between
 	// a syscall argument and a comparison operand.
@@ -247,16 +300,44 @@ func (proc *Proc) executeHintSeed(p *prog.Prog, call int) {
 	})
 }
 
+// hintsProbeSeeds is the number of hint seed executions after which we disable hints
+// if none of them returned any comparisons.
+const hintsProbeSeeds = 100
+
+func (fuzzer *Fuzzer) checkHintSeed(info *ipc.ProgInfo) {
+	for _, inf := range info.Calls {
+		if len(inf.Comps) != 0 {
+			atomic.AddUint32(&fuzzer.hintSeedComps, 1)
+			break
+		}
+	}
+	if atomic.AddUint32(&fuzzer.hintSeeds, 1) == hintsProbeSeeds &&
+		atomic.LoadUint32(&fuzzer.hintSeedComps) == 0 {
+		log.Logf(0, "no comparisons in %v hint seeds, disabling hints", hintsProbeSeeds)
+		atomic.StoreUint32(&fuzzer.hintsDisabled, 1)
+	}
+}
+
 func (proc *Proc) execute(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes, stat Stat) *ipc.ProgInfo {
+	info, _ := proc.executeNewSignal(execOpts, p, flags, stat)
+	return info
+}
+
+// executeNewSignal is the same as execute, but also returns number of calls
+// (including the extra pseudo-call) that produced new signal.
+func (proc *Proc) executeNewSignal(execOpts *ipc.ExecOpts, p *prog.Prog, flags ProgTypes,
+	stat Stat) (*ipc.ProgInfo, int) {
 	info := proc.executeRaw(execOpts, p, stat)
 	calls, extra := proc.fuzzer.checkNewSignal(p, info)
 	for _, callIndex := range calls {
 		proc.enqueueCallTriage(p, flags, callIndex, info.Calls[callIndex])
 	}
+	newSignal := len(calls)
 	if extra {
 		proc.enqueueCallTriage(p, flags, -1, info.Extra)
+		newSignal++
 	}
-	return info
+	return info, newSignal
 }
 
 func (proc *Proc) enqueueCallTriage(p *prog.Prog, flags ProgTypes, callIndex int, info ipc.CallInfo) {
@@ -0,0 +1,124 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// callsTuner chooses target number of calls for generated and mutated programs.
+// Program lengths within [min, max] are split into buckets, and for each bucket we track
+// how much new signal programs of that length produce per second of execution.
+// Most of the time the best bucket is used, but sometimes a random bucket is tried
+// to keep the estimates up-to-date (epsilon-greedy).
+type callsTuner struct {
+	min     int
+	max     int
+	width   int
+	mu      sync.Mutex
+	buckets []callsBucket
+}
+
+type callsBucket struct {
+	execTime  float64 // seconds
+	newSignal float64
+}
+
+const (
+	callsTunerBuckets = 8
+	callsTunerExplore = 0.1
+)
+
+func newCallsTuner(min, max int) *callsTuner {
+	if min == 0 && max == 0 {
+		return nil
+	}
+	width := (max - min + callsTunerBuckets) / callsTunerBuckets
+	return &callsTuner{
+		min:     min,
+		max:     max,
+		width:   width,
+		buckets: make([]callsBucket, (max-min)/width+1),
+	}
+}
+
+// choose returns target number of calls. Works on nil tuner.
+func (ct *callsTuner) choose(r *rand.Rand) int {
+	if ct == nil {
+		return prog.RecommendedCalls
+	}
+	ct.mu.Lock()
+	best := 0
+	if r.Float64() < callsTunerExplore {
+		best = r.Intn(len(ct.buckets))
+	} else {
+		bestRate := -1.0
+		for i := range ct.buckets {
+			if rate := ct.buckets[i].rate(); rate > bestRate {
+				best, bestRate = i, rate
+			}
+		}
+	}
+	ct.mu.Unlock()
+	lo := ct.min + best*ct.width
+	hi := lo + ct.width - 1
+	if hi > ct.max {
+		hi = ct.max
+	}
+	return lo + r.Intn(hi-lo+1)
+}
+
+// record accounts result of execution of a program with ncalls calls. Works on nil tuner.
+func (ct *callsTuner) record(ncalls int, elapsed time.Duration, newSignal int) {
+	if ct == nil {
+		return
+	}
+	if ncalls < ct.min {
+		ncalls = ct.min
+	}
+	if ncalls > ct.max {
+		ncalls = ct.max
+	}
+	ct.mu.Lock()
+	b := &ct.buckets[(ncalls-ct.min)/ct.width]
+	b.execTime += elapsed.Seconds()
+	b.newSignal += float64(newSignal)
+	ct.mu.Unlock()
+}
+
+// decay halves all accumulated statistics, so that the tuner follows
+// changes in the fuzzing process. Works on nil tuner.
+func (ct *callsTuner) decay() {
+	if ct == nil {
+		return
+	}
+	ct.mu.Lock()
+	for i := range ct.buckets {
+		ct.buckets[i].execTime /= 2
+		ct.buckets[i].newSignal /= 2
+	}
+	ct.mu.Unlock()
+}
+
+// String returns current per-bucket rates for logging.
+func (ct *callsTuner) String() string {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	res := ""
+	for i := range ct.buckets {
+		lo := ct.min + i*ct.width
+		res += fmt.Sprintf(" %v:%.3f", lo, ct.buckets[i].rate())
+	}
+	return res
+}
+
+func (b *callsBucket) rate() float64 {
+	// Optimistic prior: buckets that were not tried yet look attractive.
+	return (b.newSignal + 1) / (b.execTime + 1)
+}
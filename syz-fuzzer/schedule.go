@@ -0,0 +1,104 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+)
+
+// Schedule determines how programs are chosen from corpus for mutation.
+type Schedule int
+
+const (
+	// SchedulePrio chooses programs proportionally to their signal size.
+	SchedulePrio Schedule = iota
+	// ScheduleEnergy chooses programs proportionally to their energy
+	// (AFL-style power schedule, see corpusItemStats.energy).
+	ScheduleEnergy
+)
+
+// energyScale gives integer energy values enough resolution
+// for the fractional multipliers applied in energy.
+const energyScale = 100
+
+// corpusItemStats holds per-program statistics for the energy schedule.
+// fuzzed, execTime and found are updated concurrently by procs and must be accessed atomically,
+// the rest is accessed only under Fuzzer.corpusMu.
+type corpusItemStats struct {
+	prio     int64
+	fuzzed   uint64  // number of times the program was chosen for mutation
+	execTime uint64  // total execution time of mutants in nanoseconds
+	found    uint64  // number of mutants with new signal since the last energy update
+	recent   float64 // exponentially decayed found
+}
+
+func parseSchedule(str string) Schedule {
+	switch str {
+	case "", "prio":
+		return SchedulePrio
+	case "energy":
+		return ScheduleEnergy
+	default:
+		log.Fatalf("unknown corpus schedule %q", str)
+		return SchedulePrio
+	}
+}
+
+func (st *corpusItemStats) executed(elapsed time.Duration, newSignal int) {
+	atomic.AddUint64(&st.fuzzed, 1)
+	atomic.AddUint64(&st.execTime, uint64(elapsed))
+	if newSignal != 0 {
+		atomic.AddUint64(&st.found, 1)
+	}
+}
+
+// energy returns energy of the program given average execution time of all mutants.
+// The base is signal size of the program. Programs that were fuzzed less get more energy
+// (rarity, similar to AFL "fast" schedule), programs with faster mutants get more energy
+// (up to 4x either way) and programs that recently produced new signal get more energy.
+func (st *corpusItemStats) energy(avgExecTime float64) int64 {
+	e := float64(st.prio * energyScale)
+	fuzzed := atomic.LoadUint64(&st.fuzzed)
+	e /= 1 + math.Log2(1+float64(fuzzed))
+	if fuzzed != 0 && avgExecTime != 0 {
+		execTime := float64(atomic.LoadUint64(&st.execTime)) / float64(fuzzed)
+		if execTime != 0 {
+			e *= math.Max(0.25, math.Min(4, avgExecTime/execTime))
+		}
+	}
+	e *= 1 + st.recent
+	if e < 1 {
+		e = 1
+	}
+	return int64(e)
+}
+
+// updateEnergy recalculates energy of all corpus programs.
+func (fuzzer *Fuzzer) updateEnergy() {
+	fuzzer.corpusMu.Lock()
+	defer fuzzer.corpusMu.Unlock()
+	var totalTime, totalFuzzed uint64
+	for _, st := range fuzzer.corpusStats {
+		totalTime += atomic.LoadUint64(&st.execTime)
+		totalFuzzed += atomic.LoadUint64(&st.fuzzed)
+	}
+	avgExecTime := 0.0
+	if totalFuzzed != 0 {
+		avgExecTime = float64(totalTime) / float64(totalFuzzed)
+	}
+	// Snapshots may still reference the old slice, so we need a new one.
+	energy := make([]int64, len(fuzzer.corpusStats))
+	sum := int64(0)
+	for i, st := range fuzzer.corpusStats {
+		st.recent = st.recent/2 + float64(atomic.SwapUint64(&st.found, 0))
+		sum += st.energy(avgExecTime)
+		energy[i] = sum
+	}
+	fuzzer.corpusEnergy = energy
+	fuzzer.sumEnergy = sum
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// JSON API for automation scripts. It mirrors data shown on the web UI pages.
+// All responses are JSON objects, errors are returned as {"error": "..."} with non-200 status.
+// http_api_token protects only the API, the web UI serves the same data without authentication.
+// Incompatible changes to the response format require a new API version.
+
+const (
+	apiPrefix          = "/api/v1/"
+	apiDefaultPageSize = 100
+	apiMaxPageSize     = 1000
+)
+
+type APIStats struct {
+	Name        string            `json:"name"`
+	Revision    string            `json:"revision"`
+	Run         string            `json:"run"`
+	Uptime      int64             `json:"uptime"`       // seconds
+	FuzzingTime int64             `json:"fuzzing_time"` // VM-seconds
+	Corpus      int               `json:"corpus"`
+	TriageQueue int               `json:"triage_queue"`
+	Syscalls    int               `json:"syscalls"`
+	Stats       map[string]uint64 `json:"stats"`
+}
+
+type APIVMs struct {
+	Total       int    `json:"total"`
+	Fuzzing     uint32 `json:"fuzzing"`
+	Reproducing uint32 `json:"reproducing"`
+	ReproQueue  uint32 `json:"repro_queue"`
+	Phase       string `json:"phase"`
+}
+
+type APICrashList struct {
+	Total   int            `json:"total"`
+	Offset  int            `json:"offset"`
+	Crashes []*UICrashType `json:"crashes"`
+}
+
+func (mgr *Manager) initAPI() {
+	http.HandleFunc(apiPrefix+"stats", mgr.apiHandler(mgr.apiStats))
+	http.HandleFunc(apiPrefix+"vms", mgr.apiHandler(mgr.apiVMs))
+	http.HandleFunc(apiPrefix+"crashes", mgr.apiHandler(mgr.apiCrashes))
+	http.HandleFunc(apiPrefix+"crash", mgr.apiHandler(mgr.apiCrash))
+	http.HandleFunc(apiPrefix+"exclude", mgr.apiHandler(mgr.apiExclude))
+}
+
+type apiError struct {
+	code int
+	err  error
+}
+
+func (err *apiError) Error() string {
+	return err.err.Error()
+}
+
+func (mgr *Manager) apiHandler(fn func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var res interface{}
+		var err error
+		if token := mgr.cfg.HTTPAPIToken; token != "" {
+			auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+				err = &apiError{http.StatusUnauthorized, fmt.Errorf("bad API token")}
+			}
+		} else if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			// Calls that change the manager state are not allowed without authentication.
+			err = &apiError{http.StatusForbidden, fmt.Errorf("mutating API calls require http_api_token")}
+		}
+		if err == nil {
+			res, err = fn(r)
+		}
+		code := http.StatusOK
+		if err != nil {
+			code = http.StatusInternalServerError
+			if apiErr, ok := err.(*apiError); ok {
+				code = apiErr.code
+			}
+			res = map[string]string{"error": err.Error()}
+		}
+		data, err := json.MarshalIndent(res, "", "\t")
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to encode json: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(code)
+		w.Write(data)
+	}
+}
+
+func (mgr *Manager) apiStats(r *http.Request) (interface{}, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	res := &APIStats{
+		Name:        mgr.cfg.Name,
+		Revision:    prog.GitRevisionBase,
+		Run:         mgr.manifest.ID,
+		Uptime:      int64(time.Since(mgr.startTime) / time.Second),
+		FuzzingTime: int64(mgr.fuzzingTime / time.Second),
+		Corpus:      len(mgr.corpus),
+		TriageQueue: len(mgr.candidates),
+		Stats:       mgr.stats.all(),
+	}
+	if mgr.checkResult != nil {
+		res.Syscalls = len(mgr.checkResult.EnabledCalls[mgr.cfg.Sandbox])
+	}
+	return res, nil
+}
+
+func (mgr *Manager) apiVMs(r *http.Request) (interface{}, error) {
+	res := &APIVMs{
+		Fuzzing:     atomic.LoadUint32(&mgr.numFuzzing),
+		Reproducing: atomic.LoadUint32(&mgr.numReproducing),
+		ReproQueue:  atomic.LoadUint32(&mgr.numReproQueued),
+	}
+	if mgr.vmPool != nil {
+		res.Total = mgr.vmPool.Count()
+	}
+	mgr.mu.Lock()
+	phase := mgr.phase
+	mgr.mu.Unlock()
+	res.Phase = [...]string{
+		phaseInit:          "init",
+		phaseLoadedCorpus:  "loaded corpus",
+		phaseTriagedCorpus: "triaged corpus",
+		phaseQueriedHub:    "queried hub",
+		phaseTriagedHub:    "triaged hub",
+	}[phase]
+	return res, nil
+}
+
+// apiCrashes returns the crash list sorted by description.
+// Parameters: offset and limit (defaults to apiDefaultPageSize, at most apiMaxPageSize).
+func (mgr *Manager) apiCrashes(r *http.Request) (interface{}, error) {
+	offset, err := apiIntParam(r, "offset", 0)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := apiIntParam(r, "limit", apiDefaultPageSize)
+	if err != nil {
+		return nil, err
+	}
+	if limit == 0 || limit > apiMaxPageSize {
+		return nil, &apiError{http.StatusBadRequest,
+			fmt.Errorf("limit must be in [1, %v], got %v", apiMaxPageSize, limit)}
+	}
+	crashes, err := mgr.collectCrashes(mgr.cfg.Workdir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect crashes: %v", err)
+	}
+	res := &APICrashList{
+		Total:   len(crashes),
+		Offset:  offset,
+		Crashes: []*UICrashType{},
+	}
+	if offset < len(crashes) {
+		crashes = crashes[offset:]
+		if len(crashes) > limit {
+			crashes = crashes[:limit]
+		}
+		res.Crashes = crashes
+	}
+	return res, nil
+}
+
+// apiCrash returns details of a single crash, including individual crash logs and reports.
+// Log and report paths can be fetched with /file?name=.
+func (mgr *Manager) apiCrash(r *http.Request) (interface{}, error) {
+	crash := readCrash(mgr.cfg.Workdir, r.FormValue("id"), nil, mgr.startTime, true)
+	if crash == nil {
+		return nil, &apiError{http.StatusNotFound, fmt.Errorf("unknown crash %q", r.FormValue("id"))}
+	}
+	return crash, nil
+}
+
+func apiIntParam(r *http.Request, name string, def int) (int, error) {
+	str := r.FormValue(name)
+	if str == "" {
+		return def, nil
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil || val < 0 {
+		return 0, &apiError{http.StatusBadRequest, fmt.Errorf("bad %v parameter %q", name, str)}
+	}
+	return val, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+func TestAPIHandlerAuth(t *testing.T) {
+	type Test struct {
+		token  string
+		method string
+		auth   string
+		code   int
+	}
+	tests := []Test{
+		{"", http.MethodGet, "", http.StatusOK},
+		{"", http.MethodPost, "", http.StatusForbidden},
+		{"", http.MethodPost, "Bearer foo", http.StatusForbidden},
+		{"foo", http.MethodGet, "", http.StatusUnauthorized},
+		{"foo", http.MethodGet, "Bearer bar", http.StatusUnauthorized},
+		{"foo", http.MethodGet, "Bearer foo", http.StatusOK},
+		{"foo", http.MethodPost, "", http.StatusUnauthorized},
+		{"foo", http.MethodPost, "Bearer foo", http.StatusOK},
+	}
+	for i, test := range tests {
+		mgr := &Manager{cfg: &mgrconfig.Config{HTTPAPIToken: test.token}}
+		handler := mgr.apiHandler(func(r *http.Request) (interface{}, error) {
+			return map[string]string{"result": "ok"}, nil
+		})
+		req := httptest.NewRequest(test.method, apiPrefix+"test", nil)
+		if test.auth != "" {
+			req.Header.Set("Authorization", test.auth)
+		}
+		w := httptest.NewRecorder()
+		handler(w, req)
+		if w.Code != test.code {
+			t.Errorf("#%v: got code %v, want %v: %s", i, w.Code, test.code, w.Body.Bytes())
+		}
+	}
+}
+
+func TestAPICrashes(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "syz-manager-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+	const numCrashes = 5
+	for i := 0; i < numCrashes; i++ {
+		dir := filepath.Join(workdir, "crashes", fmt.Sprintf("%040x", i))
+		osutil.MkdirAll(dir)
+		if err := osutil.WriteFile(filepath.Join(dir, "description"),
+			[]byte(fmt.Sprintf("crash %v", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mgr := &Manager{
+		cfg:          &mgrconfig.Config{Workdir: workdir},
+		reproRequest: make(chan chan map[string]bool),
+	}
+	done := make(chan bool)
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case reply := <-mgr.reproRequest:
+				reply <- nil
+			case <-done:
+				return
+			}
+		}
+	}()
+	type Test struct {
+		query   string
+		code    int
+		crashes []string
+	}
+	tests := []Test{
+		{"", http.StatusOK, []string{"crash 0", "crash 1", "crash 2", "crash 3", "crash 4"}},
+		{"?offset=1&limit=2", http.StatusOK, []string{"crash 1", "crash 2"}},
+		{"?offset=4&limit=10", http.StatusOK, []string{"crash 4"}},
+		{"?offset=10", http.StatusOK, []string{}},
+		{"?limit=0", http.StatusBadRequest, nil},
+		{"?limit=-1", http.StatusBadRequest, nil},
+		{fmt.Sprintf("?limit=%v", apiMaxPageSize+1), http.StatusBadRequest, nil},
+		{"?offset=foo", http.StatusBadRequest, nil},
+	}
+	handler := mgr.apiHandler(mgr.apiCrashes)
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, apiPrefix+"crashes"+test.query, nil))
+		if w.Code != test.code {
+			t.Errorf("%q: got code %v, want %v: %s", test.query, w.Code, test.code, w.Body.Bytes())
+			continue
+		}
+		if test.code != http.StatusOK {
+			continue
+		}
+		res := new(APICrashList)
+		if err := json.Unmarshal(w.Body.Bytes(), res); err != nil {
+			t.Fatalf("%q: failed to parse response: %v", test.query, err)
+		}
+		if res.Total != numCrashes {
+			t.Errorf("%q: got total %v, want %v", test.query, res.Total, numCrashes)
+		}
+		crashes := []string{}
+		for _, crash := range res.Crashes {
+			crashes = append(crashes, crash.Description)
+		}
+		if fmt.Sprint(crashes) != fmt.Sprint(test.crashes) {
+			t.Errorf("%q: got crashes %q, want %q", test.query, crashes, test.crashes)
+		}
+	}
+}
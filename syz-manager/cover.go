@@ -6,20 +6,26 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/syzkaller/pkg/cover"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/sys/targets"
 )
 
 var (
 	initCoverOnce     sync.Once
+	initCoverDone     uint32 // set atomically once initCover has finished
 	initCoverError    error
 	initCoverVMOffset uint32
 	reportGenerator   *cover.ReportGenerator
@@ -27,6 +33,7 @@ var (
 
 func initCover(target *targets.Target, kernelObj, kernelSrc, kernelBuildSrc string) error {
 	initCoverOnce.Do(func() {
+		defer atomic.StoreUint32(&initCoverDone, 1)
 		if kernelObj == "" {
 			initCoverError = fmt.Errorf("kernel_obj is not specified")
 			return
@@ -90,3 +97,136 @@ func getVMOffset(target *targets.Target, vmlinux string) (uint32, error) {
 	}
 	return addr, nil
 }
+
+const (
+	coverSnapshotFile   = "cover.json"
+	coverCheckDelay     = 10 * time.Minute // let inputs from the triage queue land in corpus
+	coverSnapshotPeriod = time.Hour
+)
+
+// checkCoverRegression compares corpus coverage with the snapshot saved by the previous run
+// once the corpus is triaged, and then periodically saves a new snapshot.
+// Must be called with mgr.mu held.
+func (mgr *Manager) checkCoverRegression() {
+	if mgr.phase < phaseTriagedHub {
+		return
+	}
+	now := time.Now()
+	if mgr.coverCheckTime.IsZero() {
+		mgr.coverCheckTime = now.Add(coverCheckDelay)
+	}
+	if now.Before(mgr.coverCheckTime) || now.Sub(mgr.coverSnapshotTime) < coverSnapshotPeriod {
+		return
+	}
+	var cov cover.Cover
+	for _, inp := range mgr.corpus {
+		cov.Merge(inp.Cover)
+	}
+	file := filepath.Join(mgr.cfg.Workdir, coverSnapshotFile)
+	if mgr.coverSnapshotTime.IsZero() {
+		if data, err := ioutil.ReadFile(file); err == nil {
+			var old []uint32
+			if err := json.Unmarshal(data, &old); err != nil {
+				log.Logf(0, "failed to parse %v: %v", file, err)
+			} else {
+				var oldCov cover.Cover
+				oldCov.Merge(old)
+				delta := cover.Diff(oldCov, cov)
+				mgr.coverDelta = delta
+				log.Logf(0, "corpus coverage since the previous run: +%v -%v PCs",
+					len(delta.Added), len(delta.Removed))
+				mgr.stats.mergeNamed(map[string]uint64{
+					"cover lost": uint64(len(delta.Removed)),
+				})
+				data, err := delta.Serialize()
+				if err == nil {
+					err = osutil.WriteFile(filepath.Join(mgr.cfg.Workdir, "cover.delta.json"), data)
+				}
+				if err != nil {
+					log.Logf(0, "failed to save coverage delta: %v", err)
+				}
+			}
+		}
+	}
+	mgr.coverSnapshotTime = now
+	data, err := json.Marshal(cov.Serialize())
+	if err != nil {
+		panic(err)
+	}
+	if err := osutil.WriteFile(file, data); err != nil {
+		log.Logf(0, "failed to save coverage snapshot: %v", err)
+	}
+}
+
+// crashCoverContext returns source of the crashing function and nearby functions annotated
+// with coverage of the programs that triggered the crash, it shows maintainers what path
+// the fuzzer took into the function. Coverage of the programs is lost with the crashed kernel,
+// so it uses coverage recorded in the corpus for programs from the crash log (or, if none
+// of them are in the corpus, for the syscall that was executed last).
+// It's called synchronously when the crash is saved, so it does not wait for the long
+// coverage initialization, but starts it in background for subsequent crashes.
+// The context is attached once per crash title per manager run since coverage changes slowly.
+func (mgr *Manager) crashCoverContext(crash *Crash) []byte {
+	if !mgr.cfg.Cover || crash.Frame == "" {
+		return nil
+	}
+	if atomic.LoadUint32(&initCoverDone) == 0 {
+		go initCover(mgr.sysTarget, mgr.cfg.KernelObj, mgr.cfg.KernelSrc, mgr.cfg.KernelBuildSrc)
+		return nil
+	}
+	if initCoverError != nil {
+		return nil
+	}
+	mgr.mu.Lock()
+	done := mgr.coverContexts[crash.Title]
+	mgr.mu.Unlock()
+	if done {
+		return nil
+	}
+	cov := mgr.crashProgsCover(crash.Output)
+	if len(cov) == 0 {
+		return nil
+	}
+	progs := []cover.Prog{{PCs: coverToPCs(mgr.sysTarget, cov.Serialize())}}
+	const context, nearby = 5, 5
+	res, err := reportGenerator.FuncSource(progs, crash.Frame, context, nearby)
+	if err != nil {
+		log.Logf(1, "failed to get coverage of %v: %v", crash.Frame, err)
+		return nil
+	}
+	mgr.mu.Lock()
+	mgr.coverContexts[crash.Title] = true
+	mgr.mu.Unlock()
+	return res
+}
+
+// crashProgsCover returns corpus coverage of programs from the crash log.
+func (mgr *Manager) crashProgsCover(crashLog []byte) cover.Cover {
+	entries := mgr.target.ParseLog(crashLog)
+	if len(entries) == 0 {
+		return nil
+	}
+	var cov cover.Cover
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, ent := range entries {
+		if inp, ok := mgr.corpus[hash.String(ent.P.Serialize())]; ok {
+			cov.Merge(inp.Cover)
+		}
+	}
+	if len(cov) != 0 {
+		return cov
+	}
+	// The crashing program is most likely a mutant that is not in the corpus.
+	last := entries[len(entries)-1].P
+	if len(last.Calls) == 0 {
+		return nil
+	}
+	call := last.Calls[len(last.Calls)-1].Meta.Name
+	for _, inp := range mgr.corpus {
+		if inp.Call == call {
+			cov.Merge(inp.Cover)
+		}
+	}
+	return cov
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/syzkaller/pkg/cover"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// Exclusion of fragile kernel subsystems by source paths (exclude_paths config param).
+// Paths are resolved to the set of coverage PCs in these files, which is sent to fuzzers.
+// Fuzzers bias mutation against inputs that have most of their coverage in these PCs:
+// such inputs are chosen for mutation several times less often than other inputs.
+// The inputs are still added to the corpus, so no coverage is lost, and they return to
+// the normal priority once the paths are not excluded anymore (after fuzzer restart).
+// The list of paths can be changed at runtime with the /api/v1/exclude call
+// (requires http_api_token).
+
+type APIExclude struct {
+	Paths          []string `json:"paths"`
+	PCs            int      `json:"pcs"`
+	ExcludedInputs uint64   `json:"excluded_inputs"`
+}
+
+func (mgr *Manager) initExcludePaths() {
+	if len(mgr.cfg.ExcludePaths) == 0 {
+		return
+	}
+	// Symbolization of the kernel takes a while, don't block startup.
+	go func() {
+		if err := mgr.setExcludePaths(mgr.cfg.ExcludePaths); err != nil {
+			log.Logf(0, "failed to exclude paths: %v", err)
+		}
+	}()
+}
+
+func checkExcludePaths(paths []string) error {
+	for _, pattern := range paths {
+		if _, err := filepath.Match(pattern, ""); err != nil || pattern == "" || filepath.IsAbs(pattern) {
+			return fmt.Errorf("bad path pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+func (mgr *Manager) setExcludePaths(paths []string) error {
+	if err := checkExcludePaths(paths); err != nil {
+		return err
+	}
+	var pcs map[uint64]bool
+	if len(paths) != 0 {
+		if err := initCover(mgr.sysTarget, mgr.cfg.KernelObj, mgr.cfg.KernelSrc, mgr.cfg.KernelBuildSrc); err != nil {
+			return err
+		}
+		pcs = reportGenerator.PathPCs(paths)
+	}
+	cov := pcsToCover(mgr.sysTarget, pcs)
+	mgr.mu.Lock()
+	mgr.excludePaths = paths
+	mgr.excludedPCs = pcs
+	mgr.excludedCover = cov
+	mgr.excludeVersion++
+	mgr.mu.Unlock()
+	log.Logf(0, "excluding %v coverage PCs in %q", len(pcs), paths)
+	return nil
+}
+
+// pcsToCover converts coverage callback PCs to the form of coverage reported by fuzzers
+// (the reverse of coverToPCs): coverage contains truncated return addresses of the callbacks.
+func pcsToCover(target *targets.Target, pcs map[uint64]bool) []uint32 {
+	var res []uint32
+	for pc := range pcs {
+		// Instruction size depends on the arch (and on the instruction set for arm),
+		// so check all possible sizes.
+		for next := pc + 1; next <= pc+maxCallInstructionSize; next++ {
+			if cover.PreviousInstructionPC(target, next) == pc {
+				res = append(res, uint32(next))
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res
+}
+
+const maxCallInstructionSize = 8
+
+// excludedCoverUpdate returns the excluded coverage if it has changed since version.
+func (mgr *Manager) excludedCoverUpdate(version int) ([]uint32, int, bool) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if version == mgr.excludeVersion {
+		return nil, version, false
+	}
+	return mgr.excludedCover, mgr.excludeVersion, true
+}
+
+// apiExclude returns the current list of excluded paths.
+// POST requests replace the list with the path parameters (no parameters remove all exclusions).
+func (mgr *Manager) apiExclude(r *http.Request) (interface{}, error) {
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			return nil, &apiError{http.StatusBadRequest, err}
+		}
+		paths := r.Form["path"]
+		if err := checkExcludePaths(paths); err != nil {
+			return nil, &apiError{http.StatusBadRequest, err}
+		}
+		// Remaining errors are kernel symbolization failures.
+		if err := mgr.setExcludePaths(paths); err != nil {
+			return nil, &apiError{http.StatusInternalServerError, err}
+		}
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	res := &APIExclude{
+		Paths:          mgr.excludePaths,
+		PCs:            len(mgr.excludedPCs),
+		ExcludedInputs: mgr.stats.excludedInputs.get(),
+	}
+	if res.Paths == nil {
+		res.Paths = []string{}
+	}
+	return res, nil
+}
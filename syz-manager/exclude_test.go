@@ -0,0 +1,34 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestPCsToCover(t *testing.T) {
+	for _, arch := range []string{"amd64", "386", "arm64", "arm", "ppc64le", "mips64le", "s390x", "riscv64"} {
+		arch := arch
+		t.Run(arch, func(t *testing.T) {
+			target := targets.Get("linux", arch)
+			pcs := map[uint64]bool{0x81000010: true, 0x81000020: true, 0x81000124: true}
+			cov := pcsToCover(target, pcs)
+			if len(cov) == 0 {
+				t.Fatalf("no coverage for %v PCs", len(pcs))
+			}
+			restored := make(map[uint64]bool)
+			for _, pc := range coverToPCs(target, cov) {
+				if !pcs[pc] {
+					t.Errorf("coverage %v does not map back to excluded PCs", cov)
+				}
+				restored[pc] = true
+			}
+			if len(restored) != len(pcs) {
+				t.Errorf("restored %v PCs, want %v", len(restored), len(pcs))
+			}
+		})
+	}
+}
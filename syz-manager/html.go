@@ -40,7 +40,11 @@ func (mgr *Manager) initHTTP() {
 	http.HandleFunc("/file", mgr.httpFile)
 	http.HandleFunc("/report", mgr.httpReport)
 	http.HandleFunc("/rawcover", mgr.httpRawCover)
+	http.HandleFunc("/funccover", mgr.httpFuncCover)
 	http.HandleFunc("/input", mgr.httpInput)
+	http.HandleFunc("/manifest", mgr.httpManifest)
+	http.HandleFunc("/manifests", mgr.httpManifests)
+	mgr.initAPI()
 	// Browsers like to request this, without special handler this goes to / handler.
 	http.HandleFunc("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {})
 
@@ -76,7 +80,11 @@ func (mgr *Manager) httpSummary(w http.ResponseWriter, r *http.Request) {
 }
 
 func (mgr *Manager) httpConfig(w http.ResponseWriter, r *http.Request) {
-	data, err := json.MarshalIndent(mgr.cfg, "", "\t")
+	cfg := *mgr.cfg
+	if cfg.HTTPAPIToken != "" {
+		cfg.HTTPAPIToken = "<hidden>"
+	}
+	data, err := json.MarshalIndent(cfg, "", "\t")
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to encode json: %v", err),
 			http.StatusInternalServerError)
@@ -85,16 +93,69 @@ func (mgr *Manager) httpConfig(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+func (mgr *Manager) httpManifest(w http.ResponseWriter, r *http.Request) {
+	file, err := mgr.manifestPath(r.FormValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		http.Error(w, "failed to read the manifest", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(data)
+}
+
+func (mgr *Manager) httpManifests(w http.ResponseWriter, r *http.Request) {
+	data := &UIManifestsData{
+		Name: mgr.cfg.Name,
+	}
+	ids, err := listManifests(mgr.cfg.Workdir)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to list manifests: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for _, id := range append([]string{mgr.manifest.ID}, ids...) {
+		manifest, err := mgr.loadManifest(id)
+		if err != nil {
+			continue
+		}
+		data.Runs = append(data.Runs, &UIManifest{
+			Manifest: manifest,
+			Current:  id == mgr.manifest.ID,
+		})
+	}
+	if err := manifestsTemplate.Execute(w, data); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+}
+
 func (mgr *Manager) httpSyscalls(w http.ResponseWriter, r *http.Request) {
 	data := &UISyscallsData{
 		Name: mgr.cfg.Name,
 	}
 	for c, cc := range mgr.collectSyscallInfo() {
-		data.Calls = append(data.Calls, UICallType{
-			Name:   c,
-			Inputs: cc.count,
-			Cover:  len(cc.cov),
-		})
+		triage := mgr.stats.callTriage(c)
+		call := UICallType{
+			Name:        c,
+			Inputs:      cc.count,
+			Cover:       len(cc.cov),
+			NewSignal:   triage.NewSignal,
+			Flaky:       triage.Flaky,
+			NotExecuted: triage.NotExecuted,
+			Minimized:   triage.Minimized,
+		}
+		if total := triage.NewSignal + triage.Flaky; total != 0 {
+			call.FlakyPercent = triage.Flaky * 100 / total
+		}
+		if triage.Minimized != 0 {
+			call.AvgRemoved = fmt.Sprintf("%.1f", float64(triage.RemovedCalls)/float64(triage.Minimized))
+		}
+		data.Calls = append(data.Calls, call)
 	}
 	sort.Slice(data.Calls, func(i, j int) bool {
 		return data.Calls[i].Name < data.Calls[j].Name
@@ -115,6 +176,7 @@ func (mgr *Manager) collectStats() []UIStat {
 	stats := []UIStat{
 		{Name: "revision", Value: fmt.Sprint(head[:8]), Link: vcs.LogLink(vcs.SyzkallerRepo, head)},
 		{Name: "config", Value: mgr.cfg.Name, Link: "/config"},
+		{Name: "run", Value: mgr.manifest.ID, Link: "/manifests"},
 		{Name: "uptime", Value: fmt.Sprint(time.Since(mgr.startTime) / 1e9 * 1e9)},
 		{Name: "fuzzing", Value: fmt.Sprint(mgr.fuzzingTime / 60e9 * 60e9)},
 		{Name: "corpus", Value: fmt.Sprint(len(mgr.corpus)), Link: "/corpus"},
@@ -122,6 +184,13 @@ func (mgr *Manager) collectStats() []UIStat {
 		{Name: "cover", Value: fmt.Sprint(rawStats["cover"]), Link: "/cover"},
 		{Name: "signal", Value: fmt.Sprint(rawStats["signal"])},
 	}
+	if delta := mgr.coverDelta; delta != nil {
+		stats = append(stats, UIStat{
+			Name:  "cover delta",
+			Value: fmt.Sprintf("+%v -%v", len(delta.Added), len(delta.Removed)),
+			Link:  "/cover?delta=1",
+		})
+	}
 	delete(rawStats, "cover")
 	delete(rawStats, "signal")
 	if mgr.checkResult != nil {
@@ -224,9 +293,35 @@ func (mgr *Manager) httpCover(w http.ResponseWriter, r *http.Request) {
 	}
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
+	if r.FormValue("delta") != "" {
+		mgr.httpCoverDelta(w, r)
+		return
+	}
 	mgr.httpCoverCover(w, r)
 }
 
+func (mgr *Manager) httpCoverDelta(w http.ResponseWriter, r *http.Request) {
+	if mgr.coverDelta == nil {
+		http.Error(w, "no coverage snapshot from the previous run (yet)", http.StatusNotFound)
+		return
+	}
+	delta := *mgr.coverDelta
+	reportGenerator.SummarizeDelta(&delta, func(cov []uint32) []uint64 {
+		return coverToPCs(mgr.sysTarget, cov)
+	})
+	data := &UICoverDeltaData{
+		Name:    mgr.cfg.Name,
+		Added:   len(delta.Added),
+		Removed: len(delta.Removed),
+		Files:   delta.Files,
+	}
+	if err := coverDeltaTemplate.Execute(w, data); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+}
+
 func (mgr *Manager) httpCoverCover(w http.ResponseWriter, r *http.Request) {
 	var progs []cover.Prog
 	if sig := r.FormValue("input"); sig != "" {
@@ -254,6 +349,58 @@ func (mgr *Manager) httpCoverCover(w http.ResponseWriter, r *http.Request) {
 	runtime.GC()
 }
 
+func (mgr *Manager) httpFuncCover(w http.ResponseWriter, r *http.Request) {
+	if !mgr.cfg.Cover {
+		http.Error(w, "coverage is not enabled", http.StatusInternalServerError)
+		return
+	}
+	// Note: initCover is executed without mgr.mu because it takes very long time
+	// (but it only reads config and it protected by initCoverOnce).
+	if err := initCover(mgr.sysTarget, mgr.cfg.KernelObj, mgr.cfg.KernelSrc, mgr.cfg.KernelBuildSrc); err != nil {
+		http.Error(w, fmt.Sprintf("failed to generate coverage profile: %v", err), http.StatusInternalServerError)
+		return
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	var progs []cover.Prog
+	for _, inp := range mgr.corpus {
+		progs = append(progs, cover.Prog{
+			Data: string(inp.Prog),
+			PCs:  coverToPCs(mgr.sysTarget, inp.Cover),
+		})
+	}
+	funcs := reportGenerator.FuncCoverage(progs)
+	if mgr.funcCoverBase == nil || r.FormValue("reset") != "" {
+		mgr.funcCoverBase = funcs
+	}
+	newFuncs := make(map[string]bool)
+	for _, fc := range cover.NewlyCoveredFuncs(mgr.funcCoverBase, funcs) {
+		newFuncs[fc.Name] = true
+	}
+	data := &UIFuncCoverData{
+		Name:     mgr.cfg.Name,
+		NewCount: len(newFuncs),
+	}
+	onlyNew := r.FormValue("new") != ""
+	for _, fc := range funcs {
+		if onlyNew && !newFuncs[fc.Name] {
+			continue
+		}
+		data.Funcs = append(data.Funcs, &UIFuncCover{
+			FuncCover: fc,
+			New:       newFuncs[fc.Name],
+			Percent:   fmt.Sprintf("%.1f%%", 100*float64(fc.Covered)/float64(fc.PCs)),
+		})
+	}
+	if err := funcCoverTemplate.Execute(w, data); err != nil {
+		http.Error(w, fmt.Sprintf("failed to execute template: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+	runtime.GC()
+}
+
 func (mgr *Manager) httpCoverFallback(w http.ResponseWriter, r *http.Request) {
 	var maxSignal signal.Signal
 	for _, inp := range mgr.corpus {
@@ -502,6 +649,8 @@ func readCrash(workdir, dir string, repros map[string]bool, start time.Time, ful
 			}
 			tag, _ := ioutil.ReadFile(filepath.Join(crashdir, dir, "tag"+index))
 			crash.Tag = string(tag)
+			run, _ := ioutil.ReadFile(filepath.Join(crashdir, dir, "run"+index))
+			crash.Run = string(run)
 			reportFile := filepath.Join("crashes", dir, "report"+index)
 			if osutil.IsExist(filepath.Join(workdir, reportFile)) {
 				crash.Report = reportFile
@@ -520,10 +669,27 @@ func readCrash(workdir, dir string, repros map[string]bool, start time.Time, ful
 		ID:          dir,
 		Count:       len(crashes),
 		Triaged:     triaged,
+		Subsystems:  readLines(filepath.Join(crashdir, dir, "subsystems")),
+		Maintainers: readLines(filepath.Join(crashdir, dir, "maintainers")),
+		Related:     readLines(filepath.Join(crashdir, dir, "related")),
 		Crashes:     crashes,
 	}
 }
 
+func readLines(file string) []string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
 func reproStatus(hasRepro, hasCRepro, reproducing, nonReproducible bool) string {
 	status := ""
 	if hasRepro {
@@ -558,23 +724,57 @@ type UISyscallsData struct {
 	Calls []UICallType
 }
 
+// UICrashType and UICrash are also returned by the JSON API.
 type UICrashType struct {
-	Description string
-	LastTime    time.Time
-	Active      bool
-	ID          string
-	Count       int
-	Triaged     string
-	Crashes     []*UICrash
+	Description string     `json:"description"`
+	LastTime    time.Time  `json:"last_time"`
+	Active      bool       `json:"active"`
+	ID          string     `json:"id"`
+	Count       int        `json:"count"`
+	Triaged     string     `json:"triaged,omitempty"`
+	Subsystems  []string   `json:"subsystems,omitempty"`
+	Maintainers []string   `json:"maintainers,omitempty"`
+	Related     []string   `json:"related,omitempty"`
+	Crashes     []*UICrash `json:"crashes,omitempty"`
 }
 
 type UICrash struct {
-	Index  int
-	Time   time.Time
-	Active bool
-	Log    string
-	Report string
-	Tag    string
+	Index  int       `json:"index"`
+	Time   time.Time `json:"time"`
+	Active bool      `json:"active"`
+	Log    string    `json:"log"`
+	Report string    `json:"report,omitempty"`
+	Tag    string    `json:"tag,omitempty"`
+	Run    string    `json:"run,omitempty"`
+}
+
+type UIManifestsData struct {
+	Name string
+	Runs []*UIManifest
+}
+
+type UIManifest struct {
+	*Manifest
+	Current bool
+}
+
+type UIFuncCoverData struct {
+	Name     string
+	NewCount int
+	Funcs    []*UIFuncCover
+}
+
+type UICoverDeltaData struct {
+	Name    string
+	Added   int
+	Removed int
+	Files   []*cover.FileDelta
+}
+
+type UIFuncCover struct {
+	*cover.FuncCover
+	New     bool
+	Percent string
 }
 
 type UIStat struct {
@@ -587,6 +787,13 @@ type UICallType struct {
 	Name   string
 	Inputs int
 	Cover  int
+	// Triage outcomes for inputs with new signal.
+	NewSignal    uint64
+	Flaky        uint64
+	FlakyPercent uint64 // of inputs that were executed during triage
+	NotExecuted  uint64
+	Minimized    uint64
+	AvgRemoved   string // average number of calls removed by minimization
 }
 
 type UICorpus struct {
@@ -634,6 +841,7 @@ var summaryTemplate = html.CreatePage(`
 		<th><a onclick="return sortTable(this, 'Count', numSort)" href="#">Count</a></th>
 		<th><a onclick="return sortTable(this, 'Last Time', textSort, true)" href="#">Last Time</a></th>
 		<th><a onclick="return sortTable(this, 'Report', textSort)" href="#">Report</a></th>
+		<th><a onclick="return sortTable(this, 'Subsystems', textSort)" href="#">Subsystems</a></th>
 	</tr>
 	{{range $c := $.Crashes}}
 	<tr>
@@ -645,6 +853,7 @@ var summaryTemplate = html.CreatePage(`
 				<a href="/report?id={{$c.ID}}">{{$c.Triaged}}</a>
 			{{end}}
 		</td>
+		<td>{{range $i, $s := $c.Subsystems}}{{if $i}}, {{end}}{{$s}}{{end}}</td>
 	</tr>
 	{{end}}
 </table>
@@ -676,6 +885,12 @@ var syscallsTemplate = html.CreatePage(`
 		<th><a onclick="return sortTable(this, 'Syscall', textSort)" href="#">Syscall</a></th>
 		<th><a onclick="return sortTable(this, 'Inputs', numSort)" href="#">Inputs</a></th>
 		<th><a onclick="return sortTable(this, 'Coverage', numSort)" href="#">Coverage</a></th>
+		<th><a onclick="return sortTable(this, 'New signal', numSort)" href="#">New signal</a></th>
+		<th><a onclick="return sortTable(this, 'Flaky', numSort)" href="#">Flaky</a></th>
+		<th><a onclick="return sortTable(this, 'Flaky %', numSort)" href="#">Flaky %</a></th>
+		<th><a onclick="return sortTable(this, 'Not executed', numSort)" href="#">Not executed</a></th>
+		<th><a onclick="return sortTable(this, 'Minimized', numSort)" href="#">Minimized</a></th>
+		<th><a onclick="return sortTable(this, 'Avg removed calls', floatSort)" href="#">Avg removed calls</a></th>
 		<th>Prio</th>
 	</tr>
 	{{range $c := $.Calls}}
@@ -683,6 +898,12 @@ var syscallsTemplate = html.CreatePage(`
 		<td>{{$c.Name}}</td>
 		<td><a href='/corpus?call={{$c.Name}}'>{{$c.Inputs}}</a></td>
 		<td><a href='/cover?call={{$c.Name}}'>{{$c.Cover}}</a></td>
+		<td>{{$c.NewSignal}}</td>
+		<td>{{$c.Flaky}}</td>
+		<td>{{$c.FlakyPercent}}</td>
+		<td>{{$c.NotExecuted}}</td>
+		<td>{{$c.Minimized}}</td>
+		<td>{{$c.AvgRemoved}}</td>
 		<td><a href='/prio?call={{$c.Name}}'>prio</a></td>
 	</tr>
 	{{end}}
@@ -703,6 +924,15 @@ var crashTemplate = html.CreatePage(`
 {{if .Triaged}}
 Report: <a href="/report?id={{.ID}}">{{.Triaged}}</a>
 {{end}}
+{{if .Subsystems}}
+<br>Subsystems: {{range $i, $s := .Subsystems}}{{if $i}}, {{end}}{{$s}}{{end}}
+{{end}}
+{{if .Maintainers}}
+<br>Maintainers: {{range $i, $m := .Maintainers}}{{if $i}}, {{end}}{{$m}}{{end}}
+{{end}}
+{{if .Related}}
+<br>Related crashes in the same logs: {{range $i, $r := .Related}}{{if $i}}, {{end}}{{$r}}{{end}}
+{{end}}
 
 <table class="list_table">
 	<tr>
@@ -711,6 +941,7 @@ Report: <a href="/report?id={{.ID}}">{{.Triaged}}</a>
 		<th>Report</th>
 		<th>Time</th>
 		<th>Tag</th>
+		<th>Run</th>
 	</tr>
 	{{range $c := $.Crashes}}
 	<tr>
@@ -723,6 +954,105 @@ Report: <a href="/report?id={{.ID}}">{{.Triaged}}</a>
 		</td>
 		<td class="time {{if not $c.Active}}inactive{{end}}">{{formatTime $c.Time}}</td>
 		<td class="tag {{if not $c.Active}}inactive{{end}}" title="{{$c.Tag}}">{{formatShortHash $c.Tag}}</td>
+		<td>{{if $c.Run}}<a href="/manifest?id={{$c.Run}}">{{$c.Run}}</a>{{end}}</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
+
+var manifestsTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name }} syzkaller</title>
+	{{HEAD}}
+</head>
+<body>
+
+<table class="list_table">
+	<caption>Runs:</caption>
+	<tr>
+		<th>Run</th>
+		<th>Started</th>
+		<th>Syzkaller</th>
+		<th>Target</th>
+		<th>Kernel</th>
+		<th>Kernel config</th>
+		<th>Features</th>
+		<th>Syscalls</th>
+	</tr>
+	{{range $m := $.Runs}}
+	<tr>
+		<td><a href="/manifest?id={{$m.ID}}">{{$m.ID}}</a>{{if $m.Current}} (current){{end}}</td>
+		<td class="time">{{formatTime $m.Started}}</td>
+		<td class="tag" title="{{$m.SyzkallerRevision}}">{{formatShortHash $m.SyzkallerRevision}}</td>
+		<td>{{$m.Target}}</td>
+		<td class="tag" title="{{$m.KernelCommit}}">{{formatShortHash $m.KernelCommit}}</td>
+		<td class="tag" title="{{$m.KernelConfigHash}}">{{formatShortHash $m.KernelConfigHash}}</td>
+		<td>{{range $f := $m.Features}}{{$f}}<br>{{end}}</td>
+		<td>{{$m.EnabledSyscalls}}</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
+
+var funcCoverTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name }} syzkaller</title>
+	{{HEAD}}
+</head>
+<body>
+
+<a href="/funccover?new=1">{{$.NewCount}} newly covered functions</a>
+(<a href="/funccover?reset=1">reset baseline</a>)
+<br>
+<table class="list_table">
+	<caption>Functions:</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'Function', textSort)" href="#">Function</a></th>
+		<th><a onclick="return sortTable(this, 'Covered', numSort)" href="#">Covered</a></th>
+		<th><a onclick="return sortTable(this, 'Total', numSort)" href="#">Total</a></th>
+		<th><a onclick="return sortTable(this, 'Percent', floatSort)" href="#">Percent</a></th>
+		<th><a onclick="return sortTable(this, 'Inputs', numSort)" href="#">Inputs</a></th>
+	</tr>
+	{{range $f := $.Funcs}}
+	<tr>
+		<td>{{$f.Name}}{{if $f.New}} (new){{end}}</td>
+		<td>{{$f.Covered}}</td>
+		<td>{{$f.PCs}}</td>
+		<td>{{$f.Percent}}</td>
+		<td>{{$f.Progs}}</td>
+	</tr>
+	{{end}}
+</table>
+</body></html>
+`)
+
+var coverDeltaTemplate = html.CreatePage(`
+<!doctype html>
+<html>
+<head>
+	<title>{{.Name }} syzkaller</title>
+	{{HEAD}}
+</head>
+<body>
+
+<table class="list_table">
+	<caption>Corpus coverage since the previous run: +{{$.Added}} -{{$.Removed}} PCs</caption>
+	<tr>
+		<th><a onclick="return sortTable(this, 'File', textSort)" href="#">File</a></th>
+		<th><a onclick="return sortTable(this, 'Added', numSort)" href="#">Added</a></th>
+		<th><a onclick="return sortTable(this, 'Removed', numSort)" href="#">Removed</a></th>
+	</tr>
+	{{range $f := $.Files}}
+	<tr>
+		<td>{{$f.File}}</td>
+		<td>{{$f.Added}}</td>
+		<td>{{$f.Removed}}</td>
 	</tr>
 	{{end}}
 </table>
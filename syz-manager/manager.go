@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -41,6 +42,9 @@ var (
 	flagConfig = flag.String("config", "", "configuration file")
 	flagDebug  = flag.Bool("debug", false, "dump all VM output to console")
 	flagBench  = flag.String("bench", "", "write execution statistics into this file periodically")
+
+	flagReminimize = flag.Bool("reminimize_corpus", false, "re-minimize and re-smash all corpus programs"+
+		" (e.g. after the kernel tree was rewritten)")
 )
 
 type Manager struct {
@@ -59,9 +63,11 @@ type Manager struct {
 	crashTypes     map[string]bool
 	vmStop         chan bool
 	checkResult    *rpctype.CheckArgs
+	manifest       *Manifest
 	fresh          bool
 	numFuzzing     uint32
 	numReproducing uint32
+	numReproQueued uint32
 
 	dash *dashapi.Dashboard
 
@@ -77,7 +83,18 @@ type Manager struct {
 	lastMinCorpus    int
 	memoryLeakFrames map[string]bool
 	dataRaceFrames   map[string]bool
+	coverContexts    map[string]bool // crash titles with coverage context sent to dashboard
 	saturatedCalls   map[string]bool
+	funcCoverBase    []*cover.FuncCover // baseline for newly covered functions on /funccover
+	excludePaths     []string
+	excludedPCs      map[uint64]bool // coverage PCs in excludePaths
+	excludedCover    []uint32        // excludedPCs in the form of fuzzer coverage
+	excludeVersion   int             // incremented on every change of excludePaths
+
+	// Corpus coverage snapshots for detection of coverage regressions between runs.
+	coverCheckTime    time.Time
+	coverSnapshotTime time.Time
+	coverDelta        *cover.Delta // corpus coverage change since the previous run, shown on /cover?delta=1
 
 	needMoreRepros chan chan bool
 	hubReproQueue  chan *Crash
@@ -107,7 +124,8 @@ const currentDBVersion = 4
 
 type Crash struct {
 	vmIndex int
-	hub     bool // this crash was created based on a repro from hub
+	hub     bool     // this crash was created based on a repro from hub
+	related []string // titles of other crashes that happened later in the same console log
 	*report.Report
 }
 
@@ -165,13 +183,14 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 		reporter:              reporter,
 		crashdir:              crashdir,
 		startTime:             time.Now(),
-		stats:                 &Stats{haveHub: cfg.HubClient != ""},
+		stats:                 &Stats{haveHub: cfg.HubClient != "", haveSeed: cfg.DashboardSeed},
 		crashTypes:            make(map[string]bool),
 		configEnabledSyscalls: syscalls,
 		corpus:                make(map[string]rpctype.RPCInput),
 		disabledHashes:        make(map[string]struct{}),
 		memoryLeakFrames:      make(map[string]bool),
 		dataRaceFrames:        make(map[string]bool),
+		coverContexts:         make(map[string]bool),
 		fresh:                 true,
 		vmStop:                make(chan bool),
 		hubReproQueue:         make(chan *Crash, 10),
@@ -181,6 +200,8 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 		saturatedCalls:        make(map[string]bool),
 	}
 
+	mgr.initManifest()
+
 	log.Logf(0, "loading corpus...")
 	mgr.corpusDB, err = db.Open(filepath.Join(cfg.Workdir, "corpus.db"))
 	if err != nil {
@@ -190,6 +211,7 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 	// Create HTTP server.
 	mgr.initHTTP()
 	mgr.collectUsedFiles()
+	mgr.initExcludePaths()
 
 	// Create RPC server for fuzzers.
 	mgr.port, err = startRPCServer(mgr)
@@ -199,6 +221,9 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 
 	if cfg.DashboardAddr != "" {
 		mgr.dash = dashapi.New(cfg.DashboardClient, cfg.DashboardAddr, cfg.DashboardKey)
+		if cfg.DashboardSeed {
+			go mgr.seedFromDashboard()
+		}
 	}
 
 	go func() {
@@ -213,6 +238,7 @@ func RunManager(cfg *mgrconfig.Config, target *prog.Target, sysTarget *targets.T
 				continue
 			}
 			mgr.fuzzingTime += diff * time.Duration(atomic.LoadUint32(&mgr.numFuzzing))
+			mgr.checkCoverRegression()
 			executed := mgr.stats.execTotal.get()
 			crashes := mgr.stats.crashes.get()
 			corpusCover := mgr.stats.corpusCover.get()
@@ -335,6 +361,7 @@ func (mgr *Manager) vmLoop() {
 		log.Logf(1, "loop: phase=%v shutdown=%v instances=%v/%v %+v repro: pending=%v reproducing=%v queued=%v",
 			phase, shutdown == nil, len(instances), vmCount, instances,
 			len(pendingRepro), len(reproducing), len(reproQueue))
+		atomic.StoreUint32(&mgr.numReproQueued, uint32(len(reproQueue)))
 
 		canRepro := func() bool {
 			return phase >= phaseTriagedHub &&
@@ -473,10 +500,14 @@ func (mgr *Manager) loadCorpus() {
 		fallthrough
 	case currentDBVersion:
 	}
-	broken := 0
+	if *flagReminimize {
+		minimized, smashed = false, false
+	}
+	broken, changed := 0, 0
 	for key, rec := range mgr.corpusDB.Records {
-		bad, disabled := checkProgram(mgr.target, mgr.targetEnabledSyscalls, rec.Val)
+		p, bad, disabled := parseProgram(mgr.target, mgr.targetEnabledSyscalls, rec.Val)
 		if bad {
+			log.Logf(1, "deleting broken corpus program %v", key)
 			mgr.corpusDB.Delete(key)
 			broken++
 			continue
@@ -488,15 +519,26 @@ func (mgr *Manager) loadCorpus() {
 			mgr.disabledHashes[hash.String(rec.Val)] = struct{}{}
 			continue
 		}
-		mgr.candidates = append(mgr.candidates, rpctype.RPCCandidate{
+		candidate := rpctype.RPCCandidate{
 			Prog:      rec.Val,
 			Minimized: minimized,
 			Smashed:   smashed,
-		})
+		}
+		if data := reserializeProgram(p, rec.Val); data != nil {
+			// Syscall descriptions have changed since the program was added,
+			// so its minimization is stale as well. Triage will add the updated
+			// program under a new hash and the old record will be dropped
+			// during corpus minimization.
+			log.Logf(1, "corpus program %v changed after description update", key)
+			candidate.Prog = data
+			candidate.Minimized = false
+			changed++
+		}
+		mgr.candidates = append(mgr.candidates, candidate)
 	}
 	mgr.fresh = len(mgr.corpusDB.Records) == 0
-	log.Logf(0, "%-24v: %v (deleted %v broken)",
-		"corpus", len(mgr.candidates), broken)
+	log.Logf(0, "%-24v: %v (deleted %v broken, %v changed by description updates)",
+		"corpus", len(mgr.candidates), broken, changed)
 
 	// Now this is ugly.
 	// We duplicate all inputs in the corpus and shuffle the second part.
@@ -516,19 +558,35 @@ func (mgr *Manager) loadCorpus() {
 }
 
 func checkProgram(target *prog.Target, enabled map[*prog.Syscall]bool, data []byte) (bad, disabled bool) {
+	_, bad, disabled = parseProgram(target, enabled, data)
+	return
+}
+
+// parseProgram is checkProgram that also returns the deserialized program (nil if bad).
+func parseProgram(target *prog.Target, enabled map[*prog.Syscall]bool, data []byte) (
+	p *prog.Prog, bad, disabled bool) {
 	p, err := target.Deserialize(data, prog.NonStrict)
 	if err != nil {
-		return true, true
+		return nil, true, true
 	}
 	if len(p.Calls) > prog.MaxCalls {
-		return true, true
+		return nil, true, true
 	}
 	for _, c := range p.Calls {
 		if !enabled[c.Meta] {
-			return false, true
+			return p, false, true
 		}
 	}
-	return false, false
+	return p, false, false
+}
+
+// reserializeProgram returns p (deserialized from data) re-serialized against the current descriptions
+// if the result differs from data, or nil if the program is unchanged.
+func reserializeProgram(p *prog.Prog, data []byte) []byte {
+	if res := p.Serialize(); !bytes.Equal(res, data) {
+		return res
+	}
+	return nil
 }
 
 func (mgr *Manager) runInstance(index int) (*Crash, error) {
@@ -589,6 +647,13 @@ func (mgr *Manager) runInstance(index int) (*Crash, error) {
 		hub:     false,
 		Report:  rep,
 	}
+	// The console log may contain more crashes after the first one.
+	// Corrupted ones are mostly consequences of the first crash (e.g. panic_on_warn).
+	for _, rep1 := range report.SplitCrashes(mgr.reporter, rep.Output[rep.StartPos:]) {
+		if rep1.Title != rep.Title && !rep1.Corrupted && !rep1.Suppressed {
+			crash.related = append(crash.related, rep1.Title)
+		}
+	}
 	return crash, nil
 }
 
@@ -628,13 +693,17 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 		corrupted = " [corrupted]"
 	}
 	log.Logf(0, "vm-%v: crash: %v%v", crash.vmIndex, crash.Title, corrupted)
+	for _, title := range crash.related {
+		log.Logf(0, "vm-%v: related crash: %v", crash.vmIndex, title)
+	}
 	if err := mgr.reporter.Symbolize(crash.Report); err != nil {
 		log.Logf(0, "failed to symbolize report: %v", err)
 	}
 
 	mgr.stats.crashes.inc()
 	mgr.mu.Lock()
-	if !mgr.crashTypes[crash.Title] {
+	newCrashType := !mgr.crashTypes[crash.Title]
+	if newCrashType {
 		mgr.crashTypes[crash.Title] = true
 		mgr.stats.crashTypes.inc()
 	}
@@ -652,6 +721,7 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 			Log:        crash.Output,
 			Report:     crash.Report.Report,
 		}
+		dc.CoverContext = mgr.crashCoverContext(crash)
 		resp, err := mgr.dash.ReportCrash(dc)
 		if err != nil {
 			log.Logf(0, "failed to report crash to dashboard: %v", err)
@@ -669,6 +739,32 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 	if err := osutil.WriteFile(filepath.Join(dir, "description"), []byte(crash.Title+"\n")); err != nil {
 		log.Logf(0, "failed to write crash: %v", err)
 	}
+	// Subsystems and maintainers are inferred from the guilty file,
+	// so keep the latest non-empty result for the local crash pages.
+	if len(crash.Subsystems) != 0 {
+		osutil.WriteFile(filepath.Join(dir, "subsystems"), []byte(strings.Join(crash.Subsystems, "\n")+"\n"))
+	}
+	if len(crash.Recipients) != 0 {
+		var maintainers []string
+		for _, rec := range crash.Recipients {
+			maintainers = append(maintainers, rec.Address.String())
+		}
+		osutil.WriteFile(filepath.Join(dir, "maintainers"), []byte(strings.Join(maintainers, "\n")+"\n"))
+	}
+	if len(crash.related) != 0 {
+		related := readLines(filepath.Join(dir, "related"))
+		seen := make(map[string]bool)
+		for _, title := range related {
+			seen[title] = true
+		}
+		for _, title := range crash.related {
+			if !seen[title] {
+				seen[title] = true
+				related = append(related, title)
+			}
+		}
+		osutil.WriteFile(filepath.Join(dir, "related"), []byte(strings.Join(related, "\n")+"\n"))
+	}
 	// Save up to 100 reports. If we already have 100, overwrite the oldest one.
 	// Newer reports are generally more useful. Overwriting is also needed
 	// to be able to understand if a particular bug still happens or already fixed.
@@ -692,6 +788,7 @@ func (mgr *Manager) saveCrash(crash *Crash) bool {
 	if mgr.cfg.Tag != "" {
 		osutil.WriteFile(filepath.Join(dir, fmt.Sprintf("tag%v", oldestI)), []byte(mgr.cfg.Tag))
 	}
+	osutil.WriteFile(filepath.Join(dir, fmt.Sprintf("run%v", oldestI)), []byte(mgr.manifest.ID))
 	if len(crash.Report.Report) > 0 {
 		osutil.WriteFile(filepath.Join(dir, fmt.Sprintf("report%v", oldestI)), crash.Report.Report)
 	}
@@ -1036,6 +1133,7 @@ func (mgr *Manager) machineChecked(a *rpctype.CheckArgs, enabledSyscalls map[*pr
 	}
 	mgr.checkResult = a
 	mgr.targetEnabledSyscalls = enabledSyscalls
+	mgr.updateManifest()
 	mgr.loadCorpus()
 	mgr.firstConnect = time.Now()
 }
@@ -1163,11 +1261,17 @@ func (mgr *Manager) dashboardReporter() {
 			PCs:               mgr.stats.corpusCover.get(),
 			Cover:             mgr.stats.corpusSignal.get(),
 			CrashTypes:        mgr.stats.crashTypes.get(),
+			TriageQueueLen:    uint64(len(mgr.candidates)),
+			ReproQueueLen:     uint64(atomic.LoadUint32(&mgr.numReproQueued)),
+			FuzzingVMs:        uint64(atomic.LoadUint32(&mgr.numFuzzing)),
 			FuzzingTime:       mgr.fuzzingTime - lastFuzzingTime,
 			Crashes:           crashes - lastCrashes,
 			SuppressedCrashes: suppressedCrashes - lastSuppressedCrashes,
 			Execs:             execs - lastExecs,
 		}
+		if mgr.vmPool != nil {
+			req.NumVMs = uint64(mgr.vmPool.Count())
+		}
 		mgr.mu.Unlock()
 
 		if err := mgr.dash.UploadManagerStats(req); err != nil {
@@ -0,0 +1,56 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/prog"
+)
+
+func TestReserializeProgram(t *testing.T) {
+	target, err := prog.GetTarget("test", "64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabled := make(map[*prog.Syscall]bool)
+	for _, c := range target.Syscalls {
+		enabled[c] = true
+	}
+	tests := []struct {
+		data    string
+		bad     bool
+		changed string
+	}{
+		{
+			data: "test$int(0x1, 0x2, 0x3, 0x4, 0x5)\n",
+		},
+		{
+			// Missing arguments are filled in by non-strict parsing,
+			// e.g. after new arguments were added to the descriptions.
+			data:    "test$int(0x1, 0x2)\n",
+			changed: "test$int(0x1, 0x2, 0x0, 0x0, 0x0)\n",
+		},
+		{
+			data: "test$unknown_call()\n",
+			bad:  true,
+		},
+	}
+	for i, test := range tests {
+		p, bad, disabled := parseProgram(target, enabled, []byte(test.data))
+		if bad != test.bad {
+			t.Fatalf("#%v: bad=%v, want %v", i, bad, test.bad)
+		}
+		if bad {
+			continue
+		}
+		if disabled || p == nil {
+			t.Fatalf("#%v: disabled=%v, prog=%v", i, disabled, p)
+		}
+		changed := reserializeProgram(p, []byte(test.data))
+		if string(changed) != test.changed {
+			t.Errorf("#%v: got %q, want %q", i, changed, test.changed)
+		}
+	}
+}
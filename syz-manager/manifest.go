@@ -0,0 +1,149 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/prog"
+)
+
+// Manifest describes conditions of a single manager run.
+// The current manifest is stored in workdir/manifest.json,
+// manifests of previous runs are moved to workdir/manifests on restart.
+// Crash logs reference the run that produced them (see runN files in crash dirs).
+type Manifest struct {
+	ID                string
+	Started           time.Time
+	SyzkallerRevision string
+	Target            string
+	KernelCommit      string // the manager config tag
+	KernelConfigHash  string `json:",omitempty"` // hash of .config in kernel_obj, if present
+	Features          []string
+	EnabledSyscalls   int
+	Config            *mgrconfig.Config
+}
+
+const (
+	manifestFile = "manifest.json"
+	manifestDir  = "manifests"
+	maxManifests = 100
+)
+
+func newManifest(cfg *mgrconfig.Config, start time.Time) *Manifest {
+	manifest := &Manifest{
+		// Nanoseconds make IDs of quick restarts unique, fixed width keeps IDs sorted by time.
+		ID:                fmt.Sprintf("%v-%09d", start.Format("20060102-150405"), start.Nanosecond()),
+		Started:           start,
+		SyzkallerRevision: prog.GitRevision,
+		Target:            cfg.Target,
+		KernelCommit:      cfg.Tag,
+		Config:            cfg,
+	}
+	if cfg.KernelObj != "" {
+		if data, err := ioutil.ReadFile(filepath.Join(cfg.KernelObj, ".config")); err == nil {
+			manifest.KernelConfigHash = hash.String(data)
+		}
+	}
+	return manifest
+}
+
+// initManifest rotates manifest of the previous run and saves manifest of the current one.
+func (mgr *Manager) initManifest() {
+	mgr.manifest = newManifest(mgr.cfg, mgr.startTime)
+	dir := filepath.Join(mgr.cfg.Workdir, manifestDir)
+	osutil.MkdirAll(dir)
+	current := filepath.Join(mgr.cfg.Workdir, manifestFile)
+	old := new(Manifest)
+	if data, err := ioutil.ReadFile(current); err == nil && json.Unmarshal(data, old) == nil &&
+		old.ID != "" && old.ID != mgr.manifest.ID {
+		if err := osutil.Rename(current, filepath.Join(dir, old.ID+".json")); err != nil {
+			log.Logf(0, "failed to rotate manifest: %v", err)
+		}
+	}
+	ids, err := listManifests(mgr.cfg.Workdir)
+	if err != nil {
+		log.Logf(0, "failed to list manifests: %v", err)
+	}
+	for len(ids) > maxManifests {
+		os.Remove(filepath.Join(dir, ids[len(ids)-1]+".json"))
+		ids = ids[:len(ids)-1]
+	}
+	mgr.saveManifest()
+}
+
+// updateManifest records results of the machine check in the manifest.
+// Must be called with mgr.mu held.
+func (mgr *Manager) updateManifest() {
+	mgr.manifest.Features = nil
+	for _, feat := range mgr.checkResult.Features.Supported() {
+		if feat.Enabled {
+			mgr.manifest.Features = append(mgr.manifest.Features, feat.Name)
+		}
+	}
+	mgr.manifest.EnabledSyscalls = len(mgr.targetEnabledSyscalls)
+	mgr.saveManifest()
+}
+
+func (mgr *Manager) saveManifest() {
+	if err := config.SaveFile(filepath.Join(mgr.cfg.Workdir, manifestFile), mgr.manifest); err != nil {
+		log.Logf(0, "failed to save manifest: %v", err)
+	}
+}
+
+// listManifests returns IDs of manifests of previous runs, newest first.
+func listManifests(workdir string) ([]string, error) {
+	files, err := osutil.ListDir(filepath.Join(workdir, manifestDir))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, file := range files {
+		if strings.HasSuffix(file, ".json") {
+			ids = append(ids, strings.TrimSuffix(file, ".json"))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// loadManifest loads manifest of the run id (the current run, if id is empty).
+func (mgr *Manager) loadManifest(id string) (*Manifest, error) {
+	file, err := mgr.manifestPath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	manifest := new(Manifest)
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %v", file, err)
+	}
+	return manifest, nil
+}
+
+// manifestPath returns path to manifest of the run id (the current run, if id is empty).
+func (mgr *Manager) manifestPath(id string) (string, error) {
+	if id == "" || id == mgr.manifest.ID {
+		return filepath.Join(mgr.cfg.Workdir, manifestFile), nil
+	}
+	if strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("bad manifest id %q", id)
+	}
+	return filepath.Join(mgr.cfg.Workdir, manifestDir, id+".json"), nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+)
+
+func TestManifests(t *testing.T) {
+	workdir, err := ioutil.TempDir("", "syz-manager-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+	cfg := &mgrconfig.Config{
+		Workdir: workdir,
+		Target:  "linux/amd64",
+		Tag:     "kernel-commit",
+	}
+	// Several restarts within the same second must not overwrite manifests of each other.
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var ids []string
+	var mgr *Manager
+	for i := 0; i < 3; i++ {
+		mgr = &Manager{
+			cfg:       cfg,
+			startTime: start.Add(time.Duration(i) * time.Millisecond),
+		}
+		mgr.initManifest()
+		ids = append(ids, mgr.manifest.ID)
+	}
+	if ids[0] == ids[1] || ids[1] == ids[2] {
+		t.Fatalf("duplicate manifest IDs: %q", ids)
+	}
+	listed, err := listManifests(workdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 2 || listed[0] != ids[1] || listed[1] != ids[0] {
+		t.Fatalf("listed manifests %q, want %q", listed, []string{ids[1], ids[0]})
+	}
+	for i, id := range ids {
+		manifest, err := mgr.loadManifest(id)
+		if err != nil {
+			t.Fatalf("failed to load manifest %q: %v", id, err)
+		}
+		if manifest.ID != id {
+			t.Errorf("loaded manifest %q, want %q", manifest.ID, id)
+		}
+		if want := start.Add(time.Duration(i) * time.Millisecond); !manifest.Started.Equal(want) {
+			t.Errorf("manifest %q started at %v, want %v", id, manifest.Started, want)
+		}
+		if manifest.Target != cfg.Target || manifest.KernelCommit != cfg.Tag {
+			t.Errorf("manifest %q: bad target/commit %q/%q", id, manifest.Target, manifest.KernelCommit)
+		}
+	}
+	if manifest, err := mgr.loadManifest(""); err != nil || manifest.ID != ids[2] {
+		t.Errorf("loaded current manifest %+v, %v, want %q", manifest, err, ids[2])
+	}
+	for _, id := range []string{"../manifest", "foo.bar", "20200102-030405-000000042"} {
+		if _, err := mgr.loadManifest(id); err == nil {
+			t.Errorf("loaded bad/missing manifest %q", id)
+		}
+	}
+}
@@ -24,6 +24,9 @@ type RPCServer struct {
 	targetEnabledSyscalls map[*prog.Syscall]bool
 	stats                 *Stats
 	sandbox               string
+	schedule              string
+	minCalls              int
+	maxCalls              int
 	batchSize             int
 
 	mu           sync.Mutex
@@ -37,10 +40,11 @@ type RPCServer struct {
 }
 
 type Fuzzer struct {
-	name          string
-	inputs        []rpctype.RPCInput
-	newMaxSignal  signal.Signal
-	rotatedSignal signal.Signal
+	name           string
+	inputs         []rpctype.RPCInput
+	newMaxSignal   signal.Signal
+	rotatedSignal  signal.Signal
+	excludeVersion int
 }
 
 type BugFrames struct {
@@ -55,6 +59,7 @@ type RPCManagerView interface {
 	newInput(inp rpctype.RPCInput, sign signal.Signal) bool
 	candidateBatch(size int) []rpctype.RPCCandidate
 	rotateCorpus() bool
+	excludedCoverUpdate(version int) ([]uint32, int, bool)
 }
 
 func startRPCServer(mgr *Manager) (int, error) {
@@ -64,6 +69,9 @@ func startRPCServer(mgr *Manager) (int, error) {
 		configEnabledSyscalls: mgr.configEnabledSyscalls,
 		stats:                 mgr.stats,
 		sandbox:               mgr.cfg.Sandbox,
+		schedule:              mgr.cfg.Schedule,
+		minCalls:              mgr.cfg.MinCalls,
+		maxCalls:              mgr.cfg.MaxCalls,
 		fuzzers:               make(map[string]*Fuzzer),
 		rnd:                   rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
@@ -99,6 +107,9 @@ func (serv *RPCServer) Connect(a *rpctype.ConnectArgs, r *rpctype.ConnectRes) er
 	r.EnabledCalls = serv.configEnabledSyscalls
 	r.GitRevision = prog.GitRevision
 	r.TargetRevision = serv.target.Revision
+	r.Schedule = serv.schedule
+	r.MinCalls = serv.minCalls
+	r.MaxCalls = serv.maxCalls
 	// TODO: temporary disabled b/c we suspect this negatively affects fuzzing.
 	if false && serv.mgr.rotateCorpus() && serv.rnd.Intn(3) != 0 {
 		// We do rotation every other time because there are no objective
@@ -259,6 +270,7 @@ func (serv *RPCServer) NewInput(a *rpctype.NewInputArgs, r *int) error {
 
 func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
 	serv.stats.mergeNamed(a.Stats)
+	serv.stats.mergeTriage(a.TriageStats)
 
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
@@ -279,6 +291,7 @@ func (serv *RPCServer) Poll(a *rpctype.PollArgs, r *rpctype.PollRes) error {
 		}
 	}
 	r.MaxSignal = f.newMaxSignal.Split(500).Serialize()
+	r.ExcludedCover, f.excludeVersion, r.UpdateExcludedCover = serv.mgr.excludedCoverUpdate(f.excludeVersion)
 	if a.NeedCandidates {
 		r.Candidates = serv.mgr.candidateBatch(serv.batchSize)
 	}
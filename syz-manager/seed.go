@@ -0,0 +1,94 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"time"
+
+	"github.com/google/syzkaller/dashboard/dashapi"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/prog"
+)
+
+const (
+	seedRetries    = 3
+	seedRetryDelay = time.Minute
+)
+
+// seedFromDashboard downloads reproducers of bugs in the dashboard namespace
+// and adds them to the candidates, so that new managers don't start cold.
+// Note: only reproducers are downloaded, the dashboard does not store corpus programs.
+func (mgr *Manager) seedFromDashboard() {
+	progs := mgr.downloadSeeds()
+	// Programs can be checked only after machine check (we need the list of enabled syscalls).
+	var enabledSyscalls map[*prog.Syscall]bool
+	for {
+		mgr.mu.Lock()
+		phase := mgr.phase
+		enabledSyscalls = mgr.targetEnabledSyscalls
+		mgr.mu.Unlock()
+		if phase >= phaseLoadedCorpus {
+			break
+		}
+		time.Sleep(10 * time.Second)
+	}
+	candidates := make([][]byte, 0, len(progs))
+	for _, data := range progs {
+		if bad, disabled := checkProgram(mgr.target, enabledSyscalls, data); bad || disabled {
+			mgr.stats.seedReproDrop.inc()
+			continue
+		}
+		candidates = append(candidates, data)
+	}
+	mgr.stats.seedRepro.add(len(candidates))
+	mgr.addNewCandidates(candidates)
+	log.Logf(0, "dashboard seed: added %v candidates (dropped %v)",
+		len(candidates), len(progs)-len(candidates))
+}
+
+func (mgr *Manager) downloadSeeds() [][]byte {
+	var list *dashapi.BugListResp
+	for attempt := 0; ; attempt++ {
+		var err error
+		if list, err = mgr.dash.BugList(); err == nil {
+			break
+		}
+		if attempt == seedRetries {
+			log.Logf(0, "dashboard seed: failed to load bug list: %v", err)
+			return nil
+		}
+		log.Logf(0, "dashboard seed: failed to load bug list, retrying: %v", err)
+		time.Sleep(seedRetryDelay)
+	}
+	log.Logf(0, "dashboard seed: loading %v bugs", len(list.List))
+	var progs [][]byte
+	pending := list.List
+	for attempt := 0; len(pending) != 0 && attempt <= seedRetries; attempt++ {
+		if attempt != 0 {
+			log.Logf(0, "dashboard seed: failed to load %v bugs, retrying", len(pending))
+			time.Sleep(seedRetryDelay)
+		}
+		var failed []string
+		for _, id := range pending {
+			bug, err := mgr.dash.LoadBug(id)
+			if err != nil {
+				log.Logf(1, "dashboard seed: failed to load bug %v: %v", id, err)
+				failed = append(failed, id)
+				continue
+			}
+			mgr.stats.seedBugs.inc()
+			if len(bug.ReproSyz) != 0 {
+				progs = append(progs, bug.ReproSyz)
+			}
+			if n := mgr.stats.seedBugs.get(); n%100 == 0 {
+				log.Logf(0, "dashboard seed: loaded %v/%v bugs, %v repros", n, len(list.List), len(progs))
+			}
+		}
+		pending = failed
+	}
+	if len(pending) != 0 {
+		log.Logf(0, "dashboard seed: gave up on %v bugs", len(pending))
+	}
+	return progs
+}
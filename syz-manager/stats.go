@@ -6,6 +6,8 @@ package main
 import (
 	"sync"
 	"sync/atomic"
+
+	"github.com/google/syzkaller/pkg/rpctype"
 )
 
 type Stat uint64
@@ -17,6 +19,7 @@ type Stats struct {
 	vmRestarts       Stat
 	newInputs        Stat
 	rotatedInputs    Stat
+	excludedInputs   Stat
 	execTotal        Stat
 	hubSendProgAdd   Stat
 	hubSendProgDel   Stat
@@ -25,13 +28,18 @@ type Stats struct {
 	hubRecvProgDrop  Stat
 	hubRecvRepro     Stat
 	hubRecvReproDrop Stat
+	seedBugs         Stat
+	seedRepro        Stat
+	seedReproDrop    Stat
 	corpusCover      Stat
 	corpusSignal     Stat
 	maxSignal        Stat
 
-	mu         sync.Mutex
-	namedStats map[string]uint64
-	haveHub    bool
+	mu          sync.Mutex
+	namedStats  map[string]uint64
+	triageStats map[string]*rpctype.CallTriageStats
+	haveHub     bool
+	haveSeed    bool
 }
 
 func (stats *Stats) all() map[string]uint64 {
@@ -56,6 +64,14 @@ func (stats *Stats) all() map[string]uint64 {
 		m["hub: recv repro"] = stats.hubRecvRepro.get()
 		m["hub: recv repro drop"] = stats.hubRecvReproDrop.get()
 	}
+	if excluded := stats.excludedInputs.get(); excluded != 0 {
+		m["excluded inputs"] = excluded
+	}
+	if stats.haveSeed {
+		m["seed: bugs loaded"] = stats.seedBugs.get()
+		m["seed: repro"] = stats.seedRepro.get()
+		m["seed: repro drop"] = stats.seedReproDrop.get()
+	}
 	stats.mu.Lock()
 	defer stats.mu.Unlock()
 	for k, v := range stats.namedStats {
@@ -74,12 +90,37 @@ func (stats *Stats) mergeNamed(named map[string]uint64) {
 		switch k {
 		case "exec total":
 			stats.execTotal.add(int(v))
+		case "excluded inputs":
+			stats.excludedInputs.add(int(v))
 		default:
 			stats.namedStats[k] += v
 		}
 	}
 }
 
+func (stats *Stats) mergeTriage(triage map[string]rpctype.CallTriageStats) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	if stats.triageStats == nil {
+		stats.triageStats = make(map[string]*rpctype.CallTriageStats)
+	}
+	for call, v := range triage {
+		if stats.triageStats[call] == nil {
+			stats.triageStats[call] = new(rpctype.CallTriageStats)
+		}
+		stats.triageStats[call].Merge(v)
+	}
+}
+
+func (stats *Stats) callTriage(call string) rpctype.CallTriageStats {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	if v := stats.triageStats[call]; v != nil {
+		return *v
+	}
+	return rpctype.CallTriageStats{}
+}
+
 func (s *Stat) get() uint64 {
 	return atomic.LoadUint64((*uint64)(s))
 }
@@ -49,6 +49,9 @@ type Config struct {
 	KernelRepo    string `json:"kernel_repo"`
 	KernelBranch  string `json:"kernel_branch"`
 	SyzkallerRepo string `json:"syzkaller_repo"`
+	// Toolchains to use for kernel commits in specific date ranges (optional),
+	// override the default compiler selection based on BinDir.
+	CompilerEras []bisect.CompilerEra `json:"compiler_eras"`
 	// Directory with user-space system for building kernel images
 	// (for linux that's the input to tools/create-gce-image.sh).
 	Userspace string `json:"userspace"`
@@ -94,6 +97,7 @@ func main() {
 			Sysctl:    mycfg.Sysctl,
 			Cmdline:   mycfg.Cmdline,
 		},
+		CompilerEras: mycfg.CompilerEras,
 		Syzkaller: bisect.SyzkallerConfig{
 			Repo: mycfg.SyzkallerRepo,
 		},
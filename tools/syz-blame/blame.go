@@ -0,0 +1,61 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-blame attributes kernel source lines (e.g. guilty lines from crash reports)
+// to commits that introduced them and prints the commits with their authors.
+// Example invocation:
+//
+// syz-blame -kernel_src $LINUX_CHECKOUT net/ipv4/tcp.c:1234 mm/slab.c:42
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/vcs"
+)
+
+var (
+	flagKernelSrc = flag.String("kernel_src", "", "path to kernel checkout")
+	flagCommit    = flag.String("commit", "HEAD", "blame the source as of this commit")
+)
+
+func main() {
+	flag.Parse()
+	if *flagKernelSrc == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	repo, err := vcs.NewRepo("linux", "", *flagKernelSrc)
+	if err != nil {
+		fail(err)
+	}
+	blamer, ok := repo.(vcs.Blamer)
+	if !ok {
+		fail(fmt.Errorf("the repo does not support blame"))
+	}
+	for _, arg := range flag.Args() {
+		pos := strings.LastIndexByte(arg, ':')
+		if pos == -1 {
+			fail(fmt.Errorf("bad argument %q, want file:line", arg))
+		}
+		line, err := strconv.Atoi(arg[pos+1:])
+		if err != nil {
+			fail(fmt.Errorf("bad argument %q, want file:line", arg))
+		}
+		com, err := blamer.Blame(arg[:pos], line, *flagCommit)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("%v: %v %q (%v <%v>, %v)\n", arg, com.Hash[:12], com.Title,
+			com.AuthorName, com.Author, com.Date.Format("2006-01-02"))
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "%v\n", err)
+	os.Exit(1)
+}
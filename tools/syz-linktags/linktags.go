@@ -0,0 +1,85 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-linktags maps mailing list Message-IDs to kernel commits that reference them
+// with Link: tags (lore.kernel.org, lkml.kernel.org, patch.msgid.link) and vice versa.
+// Arguments that look like commit hashes are resolved to Message-IDs, the rest to commits.
+// Example invocation:
+//
+// syz-linktags -kernel_src $LINUX_CHECKOUT 20200101000000.1234-1-foo@bar.com 0123456789ab
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/syzkaller/pkg/vcs"
+)
+
+var (
+	flagKernelSrc = flag.String("kernel_src", "", "path to kernel checkout")
+	flagCommit    = flag.String("commit", "HEAD", "index commits reachable from this commit")
+)
+
+func main() {
+	flag.Parse()
+	if *flagKernelSrc == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	repo, err := vcs.NewRepo("linux", "", *flagKernelSrc)
+	if err != nil {
+		fail(err)
+	}
+	indexer, ok := repo.(vcs.LinkIndexer)
+	if !ok {
+		fail(fmt.Errorf("the repo does not support Link: tags"))
+	}
+	idx, err := indexer.ListLinkTags(*flagCommit)
+	if err != nil {
+		fail(err)
+	}
+	querier, _ := repo.(vcs.CommitQuerier)
+	for _, arg := range flag.Args() {
+		if !vcs.CheckCommitHash(arg) {
+			fmt.Printf("%v:\n", arg)
+			for _, hash := range idx.Commits(arg) {
+				fmt.Printf("\t%v\n", describeCommit(querier, hash))
+			}
+			continue
+		}
+		hash := arg
+		if querier != nil {
+			// Resolve abbreviated hashes, the index is keyed by full hashes.
+			commits, err := querier.QueryCommits([]string{arg})
+			if err != nil {
+				fail(fmt.Errorf("commit %v: %v", arg, err))
+			}
+			if commits[0] == nil {
+				fail(fmt.Errorf("commit %v is not found", arg))
+			}
+			hash = commits[0].Hash
+		}
+		fmt.Printf("%v:\n", describeCommit(querier, hash))
+		for _, id := range idx.MessageIDs(hash) {
+			fmt.Printf("\t%v\n", id)
+		}
+	}
+}
+
+func describeCommit(querier vcs.CommitQuerier, hash string) string {
+	if querier == nil {
+		return hash
+	}
+	commits, err := querier.QueryCommits([]string{hash})
+	if err != nil || commits[0] == nil {
+		return hash
+	}
+	return fmt.Sprintf("%v %q", commits[0].Hash[:12], commits[0].Title)
+}
+
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "%v\n", err)
+	os.Exit(1)
+}
@@ -35,6 +35,12 @@ var (
 	flagLeak       = flag.Bool("leak", false, "do leak checking")
 	flagEnable     = flag.String("enable", "none", "enable only listed additional features")
 	flagDisable    = flag.String("disable", "none", "enable all additional features except listed")
+	flagWrap       = flag.String("wrap", "", "wrap the program into a shell script that builds and runs it "+
+		"(script, initd - init script, systemd - installer of a systemd service)")
+	flagWrapName    = flag.String("wrap_name", "syz-repro", "name of the installed script/service for -wrap")
+	flagWrapUnshare = flag.Bool("wrap_unshare", false, "run the program in fresh namespaces for -wrap")
+	flagWrapCgroup  = flag.Bool("wrap_cgroup", false, "run the program in a dedicated cgroup for -wrap")
+	flagWrapLoop    = flag.Int("wrap_loop", 0, "create that many loop devices before running the program for -wrap")
 )
 
 func main() {
@@ -106,7 +112,26 @@ func main() {
 	} else {
 		src = formatted
 	}
-	os.Stdout.Write(src)
+	if *flagWrap == "" {
+		os.Stdout.Write(src)
+	} else {
+		if target.OS != "linux" {
+			fmt.Fprintf(os.Stderr, "-wrap is supported only for linux\n")
+			os.Exit(1)
+		}
+		wrapped, err := wrapSource(src, wrapOptions{
+			Mode:    *flagWrap,
+			Name:    *flagWrapName,
+			Unshare: *flagWrapUnshare,
+			Cgroup:  *flagWrapCgroup,
+			Loop:    *flagWrapLoop,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to wrap C source: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(wrapped)
+	}
 	if !*flagBuild {
 		return
 	}
@@ -0,0 +1,136 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// wrapOptions control wrapping of the C reproducer into a shell script that can be
+// dropped into a test VM image. The script compiles the reproducer on the target machine,
+// prepares the requested environment and runs it.
+type wrapOptions struct {
+	Mode    string // "script", "initd" or "systemd"
+	Name    string // name of the installed script/service
+	Unshare bool   // run the reproducer in fresh mount/net/ipc/uts/pid namespaces
+	Cgroup  bool   // run the reproducer in a dedicated cgroup v2 group
+	Loop    int    // number of loop devices to create before running the reproducer
+	Source  string
+}
+
+func wrapSource(src []byte, opts wrapOptions) ([]byte, error) {
+	var templ *template.Template
+	switch opts.Mode {
+	case "script":
+		templ = wrapScriptTemplate
+	case "initd":
+		templ = wrapInitdTemplate
+	case "systemd":
+		templ = wrapSystemdTemplate
+	default:
+		return nil, fmt.Errorf("unknown wrap mode %q (supported: script, initd, systemd)", opts.Mode)
+	}
+	if bytes.Contains(src, []byte("\n"+wrapSourceEOF+"\n")) {
+		return nil, fmt.Errorf("C source contains heredoc delimiter %v", wrapSourceEOF)
+	}
+	if opts.Name == "" {
+		opts.Name = "syz-repro"
+	}
+	opts.Source = string(src)
+	buf := new(bytes.Buffer)
+	if err := templ.Execute(buf, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const wrapSourceEOF = "SYZ_REPRO_SOURCE_EOF"
+
+var wrapTemplates = template.Must(template.New("").Parse(`
+{{define "run"}}set -e
+dir=$(mktemp -d /tmp/{{.Name}}.XXXXXX)
+cat > "$dir/repro.c" <<'` + wrapSourceEOF + `'
+{{.Source}}
+` + wrapSourceEOF + `
+${CC:-cc} -o "$dir/repro" "$dir/repro.c" -pthread
+{{- if .Loop}}
+for i in $(seq 0 $(({{.Loop}} - 1))); do
+	[ -e /dev/loop$i ] || mknod -m 660 /dev/loop$i b 7 $i
+done
+{{- end}}
+{{- if .Cgroup}}
+if ! [ -e /sys/fs/cgroup/cgroup.controllers ]; then
+	mkdir -p /sys/fs/cgroup/unified
+	mountpoint -q /sys/fs/cgroup/unified || mount -t cgroup2 none /sys/fs/cgroup/unified
+	cgroot=/sys/fs/cgroup/unified
+else
+	cgroot=/sys/fs/cgroup
+fi
+mkdir -p "$cgroot/{{.Name}}"
+echo $$ > "$cgroot/{{.Name}}/cgroup.procs"
+{{- end}}
+cd "$dir"
+{{if .Unshare}}exec unshare --mount --net --ipc --uts --pid --fork "$dir/repro"{{else}}exec "$dir/repro"{{end}}
+{{end}}
+
+{{define "script"}}#!/bin/sh
+# Generated by syz-prog2c.
+{{template "run" .}}{{end}}
+
+{{define "initd"}}#!/bin/sh
+### BEGIN INIT INFO
+# Provides:          {{.Name}}
+# Required-Start:    $local_fs
+# Required-Stop:
+# Default-Start:     2 3 4 5
+# Default-Stop:
+# Short-Description: syzkaller reproducer
+### END INIT INFO
+# Generated by syz-prog2c. Install as /etc/init.d/{{.Name}}.
+case "$1" in
+start)
+	(
+{{template "run" .}}	) > /var/log/{{.Name}}.log 2>&1 &
+	;;
+stop)
+	pkill -f "/tmp/{{.Name}}\..*/repro" || true
+	;;
+*)
+	echo "usage: $0 {start|stop}"
+	exit 1
+	;;
+esac
+{{end}}
+
+{{define "systemd"}}#!/bin/sh
+# Generated by syz-prog2c. Installs and enables {{.Name}}.service.
+set -e
+cat > /usr/local/bin/{{.Name}} <<'SYZ_REPRO_SCRIPT_EOF'
+{{template "script" .}}SYZ_REPRO_SCRIPT_EOF
+chmod +x /usr/local/bin/{{.Name}}
+cat > /etc/systemd/system/{{.Name}}.service <<'SYZ_REPRO_UNIT_EOF'
+[Unit]
+Description=syzkaller reproducer
+After=local-fs.target
+
+[Service]
+Type=simple
+ExecStart=/usr/local/bin/{{.Name}}
+StandardOutput=journal+console
+
+[Install]
+WantedBy=multi-user.target
+SYZ_REPRO_UNIT_EOF
+systemctl daemon-reload
+systemctl enable {{.Name}}.service
+{{end}}
+`))
+
+var (
+	wrapScriptTemplate  = wrapTemplates.Lookup("script")
+	wrapInitdTemplate   = wrapTemplates.Lookup("initd")
+	wrapSystemdTemplate = wrapTemplates.Lookup("systemd")
+)
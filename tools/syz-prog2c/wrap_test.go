@@ -0,0 +1,94 @@
+// Copyright 2020 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+func TestWrapSource(t *testing.T) {
+	const src = "int main() { return 0; }\n"
+	tests := []struct {
+		opts    wrapOptions
+		src     string
+		want    []string
+		notWant []string
+		err     string
+	}{
+		{
+			opts:    wrapOptions{Mode: "script"},
+			want:    []string{"#!/bin/sh\n", src, "mktemp -d /tmp/syz-repro.XXXXXX", `exec "$dir/repro"`},
+			notWant: []string{"unshare", "mknod", "cgroup"},
+		},
+		{
+			opts: wrapOptions{Mode: "script", Name: "foo", Unshare: true, Cgroup: true, Loop: 4},
+			want: []string{
+				"mktemp -d /tmp/foo.XXXXXX",
+				"exec unshare --mount --net --ipc --uts --pid --fork",
+				"seq 0 $((4 - 1))",
+				`mkdir -p "$cgroot/foo"`,
+			},
+		},
+		{
+			opts: wrapOptions{Mode: "initd", Name: "foo"},
+			want: []string{"# Provides:          foo\n", "/var/log/foo.log", "start)", "stop)"},
+		},
+		{
+			opts: wrapOptions{Mode: "systemd"},
+			want: []string{
+				"cat > /etc/systemd/system/syz-repro.service",
+				"ExecStart=/usr/local/bin/syz-repro",
+				"systemctl enable syz-repro.service",
+			},
+		},
+		{
+			opts: wrapOptions{Mode: "docker"},
+			err:  "unknown wrap mode",
+		},
+		{
+			opts: wrapOptions{Mode: "script"},
+			src:  src + wrapSourceEOF + "\n",
+			err:  "heredoc delimiter",
+		},
+	}
+	for i, test := range tests {
+		input := test.src
+		if input == "" {
+			input = src
+		}
+		out, err := wrapSource([]byte(input), test.opts)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("#%v: got error %v, want %q", i, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%v: %v", i, err)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("#%v: output does not contain %q:\n%s", i, want, out)
+			}
+		}
+		for _, notWant := range test.notWant {
+			if strings.Contains(string(out), notWant) {
+				t.Errorf("#%v: output contains %q:\n%s", i, notWant, out)
+			}
+		}
+		if _, err := exec.LookPath("sh"); err == nil {
+			cmd := osutil.Command("sh", "-n")
+			cmd.Stdin = strings.NewReader(string(out))
+			if _, err := osutil.Run(time.Minute, cmd); err != nil {
+				t.Errorf("#%v: bad shell syntax: %v\n%s", i, err, out)
+			}
+		}
+	}
+}